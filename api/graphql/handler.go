@@ -0,0 +1,168 @@
+// Package graphql serves a GraphQL schema over HTTP and WebSocket. It adds
+// what the executor leaves to the transport: depth and complexity limits
+// checked before execution, per-request batching loaders, and the
+// graphql-transport-ws protocol for subscriptions.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	qerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// maxRequestBytes caps the size of an operation sent over HTTP
+const maxRequestBytes = 1 << 20
+
+// Request is one GraphQL operation
+type Request struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of an operation
+type Response = graphqlgo.Response
+
+// Options configures a Handler
+type Options struct {
+	// Limits bounds the depth and complexity of operations
+	Limits Limits
+
+	// Prepare returns the context an operation runs with, typically carrying
+	// fresh loaders. It is called once per HTTP request or WebSocket operation.
+	Prepare func(ctx context.Context) context.Context
+}
+
+// Handler executes operations against a schema
+type Handler struct {
+	logger   *slog.Logger
+	schema   *graphqlgo.Schema
+	analyzer *Analyzer
+	prepare  func(ctx context.Context) context.Context
+	upgrader websocket.Upgrader
+}
+
+// NewHandler parses sdl and binds it to resolver, the root resolver whose
+// methods implement the Query, Mutation and Subscription fields
+func NewHandler(logger *slog.Logger, sdl string, resolver any, opts Options) (*Handler, error) {
+	schema, err := graphqlgo.ParseSchema(sdl, resolver,
+		graphqlgo.UseStringDescriptions(),
+		graphqlgo.PanicHandler(&panicHandler{logger: logger}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GraphQL schema: %w", err)
+	}
+	analyzer, err := NewAnalyzer(sdl, opts.Limits)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Prepare == nil {
+		opts.Prepare = func(ctx context.Context) context.Context { return ctx }
+	}
+
+	return &Handler{
+		logger:   logger,
+		schema:   schema,
+		analyzer: analyzer,
+		prepare:  opts.Prepare,
+		upgrader: websocket.Upgrader{Subprotocols: []string{subprotocol}},
+	}, nil
+}
+
+// Limits returns the limits the handler enforces
+func (h *Handler) Limits() Limits {
+	return h.analyzer.Limits()
+}
+
+// ServeHTTP runs queries sent with GET and operations sent as a JSON POST
+// body, and upgrades WebSocket requests to the graphql-transport-ws protocol
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		h.serveWebSocket(w, r)
+		return
+	}
+
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse("variables must be a JSON object", ""))
+				return
+			}
+		}
+	case http.MethodPost:
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		if err := decoder.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse("request body must be a JSON object with a query", ""))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("use GET or POST", ""))
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse("query is required", ""))
+		return
+	}
+
+	cost, err := h.analyzer.Check(req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		writeJSON(w, http.StatusOK, limitResponse(err))
+		return
+	}
+	switch {
+	case cost.Operation == "subscription":
+		writeJSON(w, http.StatusBadRequest, errorResponse("subscriptions need a WebSocket connection using the "+subprotocol+" protocol", ""))
+		return
+	case cost.Operation == "mutation" && r.Method == http.MethodGet:
+		w.Header().Set("Allow", "POST")
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("mutations must be sent with POST", ""))
+		return
+	}
+
+	resp := h.schema.Exec(h.prepare(r.Context()), req.Query, req.OperationName, req.Variables)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// errorResponse builds a response carrying one error
+func errorResponse(message, code string) *Response {
+	err := &qerrors.QueryError{Message: message}
+	if code != "" {
+		err.Extensions = map[string]any{"code": code}
+	}
+	return &Response{Errors: []*qerrors.QueryError{err}}
+}
+
+func limitResponse(err error) *Response {
+	if limitErr, ok := err.(*LimitError); ok {
+		return errorResponse(limitErr.Message, limitErr.Code)
+	}
+	return errorResponse(err.Error(), "")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// panicHandler logs resolver panics and reports them without internals
+type panicHandler struct {
+	logger *slog.Logger
+}
+
+func (p *panicHandler) MakePanicError(ctx context.Context, value any) *qerrors.QueryError {
+	p.logger.ErrorContext(ctx, "GraphQL resolver panicked", "panic", value)
+	return &qerrors.QueryError{Message: "internal error", Extensions: map[string]any{"code": "INTERNAL"}}
+}
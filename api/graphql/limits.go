@@ -0,0 +1,228 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Default query limits
+const (
+	DefaultMaxDepth      = 10
+	DefaultMaxComplexity = 1000
+	// defaultListSize is the assumed length of list fields that take no first argument
+	defaultListSize = 10
+)
+
+// Limits bound how much work one operation may ask for. Zero values fall
+// back to the defaults.
+type Limits struct {
+	// MaxDepth caps how deeply fields may nest
+	MaxDepth int
+
+	// MaxComplexity caps the estimated number of fields resolved. Each field
+	// costs one, and the fields below a list cost once per expected element:
+	// the first argument for paginated fields, otherwise ten.
+	MaxComplexity int
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = DefaultMaxDepth
+	}
+	if l.MaxComplexity <= 0 {
+		l.MaxComplexity = DefaultMaxComplexity
+	}
+	return l
+}
+
+// Analyzer measures operations against Limits before they are executed
+type Analyzer struct {
+	schema *ast.Schema
+	limits Limits
+}
+
+// NewAnalyzer creates an analyzer for the schema in sdl
+func NewAnalyzer(sdl string, limits Limits) (*Analyzer, error) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+	return &Analyzer{schema: schema, limits: limits.withDefaults()}, nil
+}
+
+// Limits returns the limits the analyzer enforces
+func (a *Analyzer) Limits() Limits {
+	return a.limits
+}
+
+// Cost is the measured size of an operation
+type Cost struct {
+	// Operation is "query", "mutation" or "subscription"; empty when the document is invalid
+	Operation  string
+	Depth      int
+	Complexity int
+}
+
+// Check measures the selected operation and returns a *LimitError when it
+// exceeds the limits. Documents that do not validate are left for the
+// executor to report, so clients see one set of validation messages.
+func (a *Analyzer) Check(query, operationName string, variables map[string]any) (Cost, error) {
+	doc, errs := gqlparser.LoadQuery(a.schema, query)
+	if len(errs) > 0 {
+		return Cost{}, nil
+	}
+
+	var op *ast.OperationDefinition
+	if operationName == "" && len(doc.Operations) == 1 {
+		op = doc.Operations[0]
+	} else {
+		op = doc.Operations.ForName(operationName)
+	}
+	if op == nil {
+		return Cost{}, nil
+	}
+
+	m := measurer{vars: variables}
+	cost := Cost{
+		Operation:  string(op.Operation),
+		Depth:      m.depth(op.SelectionSet, 0),
+		Complexity: m.complexity(op.SelectionSet, false),
+	}
+	switch {
+	case cost.Depth > a.limits.MaxDepth:
+		return cost, &LimitError{Code: CodeTooDeep, Message: fmt.Sprintf("query depth %d exceeds the limit of %d", cost.Depth, a.limits.MaxDepth)}
+	case cost.Complexity > a.limits.MaxComplexity:
+		return cost, &LimitError{Code: CodeTooComplex, Message: fmt.Sprintf("query complexity %d exceeds the limit of %d", cost.Complexity, a.limits.MaxComplexity)}
+	}
+	return cost, nil
+}
+
+// LimitError reports an operation refused by Analyzer.Check
+type LimitError struct {
+	Code    string
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+// Error codes reported in the extensions of a LimitError
+const (
+	CodeTooDeep    = "QUERY_TOO_DEEP"
+	CodeTooComplex = "QUERY_TOO_COMPLEX"
+)
+
+type measurer struct {
+	vars map[string]any
+}
+
+// depth returns how deeply the selection set nests. Introspection fields
+// are skipped so tools can always load the schema.
+func (m measurer) depth(set ast.SelectionSet, level int) int {
+	deepest := level
+	for _, field := range m.fields(set) {
+		deepest = max(deepest, m.depth(field.SelectionSet, level+1))
+	}
+	return deepest
+}
+
+// complexity returns the estimated number of fields the selection set
+// resolves. inConnection is set below a paginated field, whose edges are
+// already counted by its first argument.
+func (m measurer) complexity(set ast.SelectionSet, inConnection bool) int {
+	total := 0
+	for _, field := range m.fields(set) {
+		cost := 1
+		if len(field.SelectionSet) > 0 {
+			n, paginated := m.listSize(field, inConnection)
+			cost += n * m.complexity(field.SelectionSet, paginated)
+		}
+		total += cost
+	}
+	return total
+}
+
+// listSize returns how many times a field's selection set is expected to be
+// resolved and whether the field is paginated
+func (m measurer) listSize(field *ast.Field, inConnection bool) (int, bool) {
+	if field.Definition == nil {
+		return 1, false
+	}
+	if arg := field.Definition.Arguments.ForName("first"); arg != nil {
+		size := defaultListSize
+		if value, ok := m.argument(field, "first"); ok {
+			size = value
+		} else if arg.DefaultValue != nil {
+			if value, ok := toInt(arg.DefaultValue.Raw); ok {
+				size = value
+			}
+		}
+		return max(size, 1), true
+	}
+	if field.Definition.Type.Elem != nil && !inConnection {
+		return defaultListSize, false
+	}
+	return 1, false
+}
+
+// argument reads an integer argument given inline or through a variable
+func (m measurer) argument(field *ast.Field, name string) (int, bool) {
+	arg := field.Arguments.ForName(name)
+	if arg == nil || arg.Value == nil {
+		return 0, false
+	}
+	if arg.Value.Kind == ast.Variable {
+		value, ok := m.vars[arg.Value.Raw]
+		if !ok {
+			return 0, false
+		}
+		return toInt(value)
+	}
+	return toInt(arg.Value.Raw)
+}
+
+// fields flattens fragments into the fields they select
+func (m measurer) fields(set ast.SelectionSet) []*ast.Field {
+	var fields []*ast.Field
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if !strings.HasPrefix(s.Name, "__") {
+				fields = append(fields, s)
+			}
+		case *ast.InlineFragment:
+			fields = append(fields, m.fields(s.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				fields = append(fields, m.fields(s.Definition.SelectionSet)...)
+			}
+		}
+	}
+	return fields
+}
+
+func toInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	case string:
+		var n int
+		_, err := fmt.Sscan(v, &n)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchWait is how long a loader collects keys before fetching them
+	DefaultBatchWait = 2 * time.Millisecond
+	// DefaultMaxBatch caps the keys fetched by one call
+	DefaultMaxBatch = 100
+)
+
+// BatchFunc fetches the values for keys in one call. Keys missing from the
+// result load as the zero value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batches and caches lookups made while resolving one request, so
+// resolving a field on every item of a list costs one storage call rather
+// than one per item. Loaders are created per request and are safe for
+// concurrent use by the resolvers of that request.
+type Loader[K comparable, V any] struct {
+	ctx      context.Context
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	pending *batch[K, V]
+	// loaded maps each key requested so far to the batch that fetches it
+	loaded map[K]*batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	once    sync.Once
+	done    chan struct{}
+	results map[K]V
+	err     error
+}
+
+// NewLoader creates a loader whose fetches run with ctx, usually the request context
+func NewLoader[K comparable, V any](ctx context.Context, fetch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		ctx:      ctx,
+		fetch:    fetch,
+		wait:     DefaultBatchWait,
+		maxBatch: DefaultMaxBatch,
+		loaded:   make(map[K]*batch[K, V]),
+	}
+}
+
+// Load returns the value for key, waiting briefly so that keys requested by
+// sibling resolvers are fetched in the same batch
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	b, ok := l.loaded[key]
+	if !ok {
+		b = l.pending
+		if b == nil {
+			b = &batch[K, V]{done: make(chan struct{})}
+			l.pending = b
+			time.AfterFunc(l.wait, func() { l.dispatch(b) })
+		}
+		b.keys = append(b.keys, key)
+		l.loaded[key] = b
+		if len(b.keys) >= l.maxBatch {
+			go l.dispatch(b)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+	if b.err != nil {
+		var zero V
+		return zero, b.err
+	}
+	return b.results[key], nil
+}
+
+// dispatch fetches a batch once, whether its wait ran out or it filled up
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.pending == b {
+			l.pending = nil
+		}
+		keys := b.keys
+		l.mu.Unlock()
+
+		b.results, b.err = l.fetch(l.ctx, keys)
+		close(b.done)
+	})
+}
@@ -0,0 +1,262 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// subprotocol is the graphql-transport-ws protocol spoken by graphql-ws clients
+// (https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md)
+const subprotocol = "graphql-transport-ws"
+
+const (
+	// connectionInitTimeout is how long a client has to send connection_init
+	connectionInitTimeout = 10 * time.Second
+	// pingInterval keeps idle connections open through proxies
+	pingInterval = 30 * time.Second
+	// writeTimeout bounds a single write to a slow client
+	writeTimeout = 10 * time.Second
+)
+
+// Message types of the graphql-transport-ws protocol
+const (
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgPing           = "ping"
+	msgPong           = "pong"
+	msgSubscribe      = "subscribe"
+	msgNext           = "next"
+	msgError          = "error"
+	msgComplete       = "complete"
+)
+
+// Close codes of the graphql-transport-ws protocol
+const (
+	closeBadRequest         = 4400
+	closeUnauthorized       = 4401
+	closeInitTimeout        = 4408
+	closeSubscriberExists   = 4409
+	closeTooManyInitRequest = 4429
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsSession is one WebSocket connection and the operations running on it
+type wsSession struct {
+	h      *Handler
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	writeMu sync.Mutex
+
+	mu           sync.Mutex
+	acknowledged bool
+	operations   map[string]context.CancelFunc
+}
+
+func (h *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		h.logger.Warn("GraphQL WebSocket upgrade failed", "error", err)
+		return
+	}
+	if conn.Subprotocol() != subprotocol {
+		closeConn(conn, websocket.CloseProtocolError, "Subprotocol not acceptable, use "+subprotocol)
+		return
+	}
+
+	// The request context ends when the handler returns, which it does not
+	// until the connection closes
+	ctx, cancel := context.WithCancel(r.Context())
+	s := &wsSession{
+		h:          h,
+		conn:       conn,
+		ctx:        ctx,
+		cancel:     cancel,
+		operations: make(map[string]context.CancelFunc),
+	}
+	s.run()
+}
+
+func (s *wsSession) run() {
+	defer s.conn.Close()
+	defer s.cancel()
+
+	initTimer := time.AfterFunc(connectionInitTimeout, func() {
+		s.mu.Lock()
+		acknowledged := s.acknowledged
+		s.mu.Unlock()
+		if !acknowledged {
+			s.close(closeInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	go s.keepAlive()
+
+	for {
+		var msg wsMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				s.close(closeBadRequest, "Invalid message")
+			}
+			return
+		}
+		if !s.handle(msg) {
+			return
+		}
+	}
+}
+
+// handle processes one client message and reports whether the connection stays open
+func (s *wsSession) handle(msg wsMessage) bool {
+	switch msg.Type {
+	case msgConnectionInit:
+		s.mu.Lock()
+		already := s.acknowledged
+		s.acknowledged = true
+		s.mu.Unlock()
+		if already {
+			s.close(closeTooManyInitRequest, "Too many initialisation requests")
+			return false
+		}
+		s.write(wsMessage{Type: msgConnectionAck})
+
+	case msgPing:
+		s.write(wsMessage{Type: msgPong})
+
+	case msgPong:
+
+	case msgSubscribe:
+		return s.subscribe(msg)
+
+	case msgComplete:
+		s.mu.Lock()
+		cancel, ok := s.operations[msg.ID]
+		delete(s.operations, msg.ID)
+		s.mu.Unlock()
+		if ok {
+			cancel()
+		}
+
+	default:
+		s.close(closeBadRequest, "Invalid message type "+msg.Type)
+		return false
+	}
+	return true
+}
+
+// subscribe starts an operation, streaming each result as a next message
+func (s *wsSession) subscribe(msg wsMessage) bool {
+	var req Request
+	if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil || req.Query == "" {
+		s.close(closeBadRequest, "Invalid subscribe message")
+		return false
+	}
+
+	s.mu.Lock()
+	if !s.acknowledged {
+		s.mu.Unlock()
+		s.close(closeUnauthorized, "Unauthorized")
+		return false
+	}
+	if _, exists := s.operations[msg.ID]; exists {
+		s.mu.Unlock()
+		s.close(closeSubscriberExists, "Subscriber for "+msg.ID+" already exists")
+		return false
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.operations[msg.ID] = cancel
+	s.mu.Unlock()
+
+	if _, err := s.h.analyzer.Check(req.Query, req.OperationName, req.Variables); err != nil {
+		s.finish(msg.ID)
+		payload, _ := json.Marshal(limitResponse(err).Errors)
+		s.write(wsMessage{ID: msg.ID, Type: msgError, Payload: payload})
+		return true
+	}
+
+	results, err := s.h.schema.Subscribe(s.h.prepare(ctx), req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		s.finish(msg.ID)
+		payload, _ := json.Marshal(errorResponse(err.Error(), "").Errors)
+		s.write(wsMessage{ID: msg.ID, Type: msgError, Payload: payload})
+		return true
+	}
+
+	go func() {
+		for result := range results {
+			payload, err := json.Marshal(result)
+			if err != nil {
+				s.h.logger.Error("Failed to encode GraphQL result", "error", err)
+				continue
+			}
+			s.write(wsMessage{ID: msg.ID, Type: msgNext, Payload: payload})
+		}
+		// A client that sent complete itself expects no reply
+		if s.finish(msg.ID) {
+			s.write(wsMessage{ID: msg.ID, Type: msgComplete})
+		}
+	}()
+	return true
+}
+
+// finish forgets an operation and reports whether it was still running
+func (s *wsSession) finish(id string) bool {
+	s.mu.Lock()
+	cancel, ok := s.operations[id]
+	delete(s.operations, id)
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// keepAlive pings the client until the session ends, then closes the
+// connection so the read loop returns
+func (s *wsSession) keepAlive() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.conn.Close()
+			return
+		case <-ticker.C:
+			s.write(wsMessage{Type: msgPing})
+		}
+	}
+}
+
+func (s *wsSession) write(msg wsMessage) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := s.conn.WriteJSON(msg); err != nil {
+		s.cancel()
+	}
+}
+
+func (s *wsSession) close(code int, reason string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	closeConn(s.conn, code, reason)
+	s.cancel()
+}
+
+func closeConn(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	conn.Close()
+}
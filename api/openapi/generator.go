@@ -0,0 +1,303 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Generator derives JSON schemas from Go types using their json, form and
+// binding struct tags. Named struct types become component schemas that are
+// referenced by name; everything else is inlined.
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	inline  map[reflect.Type]*Schema
+}
+
+// NewGenerator creates a generator that knows about time.Time and json.RawMessage
+func NewGenerator() *Generator {
+	g := &Generator{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+		inline:  map[reflect.Type]*Schema{},
+	}
+	g.Define(reflect.TypeFor[time.Time](), &Schema{Type: Types{"string"}, Format: "date-time"})
+	g.Define(reflect.TypeFor[json.RawMessage](), &Schema{Description: "Any JSON value"})
+	return g
+}
+
+// Define sets the schema inlined wherever t appears, for types whose JSON
+// encoding does not follow from their Go kind (such as custom marshalers)
+func (g *Generator) Define(t reflect.Type, schema *Schema) {
+	g.inline[t] = schema
+}
+
+// Component registers schema as a named component for t; uses of t refer to it
+func (g *Generator) Component(t reflect.Type, schema *Schema) {
+	name := g.componentName(t)
+	g.names[t] = name
+	g.schemas[name] = schema
+}
+
+// Enum registers T as a string component restricted to values
+func Enum[T ~string](g *Generator, description string, values ...T) {
+	enum := make([]any, len(values))
+	for i, v := range values {
+		enum[i] = string(v)
+	}
+	g.Component(reflect.TypeFor[T](), &Schema{
+		Type:        Types{"string"},
+		Description: description,
+		Enum:        enum,
+	})
+}
+
+// Schemas returns the component schemas registered so far
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// Schema returns the schema for v's type
+func (g *Generator) Schema(v any) *Schema {
+	return g.SchemaFor(reflect.TypeOf(v))
+}
+
+// SchemaFor returns the schema for t, registering components for any named
+// structs it contains
+func (g *Generator) SchemaFor(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if t.Kind() == reflect.Pointer {
+		return nullable(g.SchemaFor(t.Elem()))
+	}
+	if s, ok := g.inline[t]; ok {
+		copied := *s
+		return &copied
+	}
+	if name, ok := g.names[t]; ok {
+		return Ref(name)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: Types{"integer"}}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: Types{"integer"}, Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: Types{"string"}, Format: "byte"}
+		}
+		return &Schema{Type: Types{"array"}, Items: g.SchemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: Types{"object"}, AdditionalProperties: g.SchemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		// Register the name before walking the fields so recursive types terminate
+		name := g.componentName(t)
+		g.names[t] = name
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.structSchema(t)
+		return Ref(name)
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %s", t))
+}
+
+// QueryParameters returns a query parameter for each form-tagged field of v's struct type
+func (g *Generator) QueryParameters(v any) []*Parameter {
+	var params []*Parameter
+	g.eachField(reflect.TypeOf(v), "form", func(name string, field reflect.StructField, rules bindingRules) {
+		schema := g.SchemaFor(field.Type)
+		rules.apply(schema)
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       "query",
+			Required: rules.required,
+			Schema:   schema,
+		})
+	})
+	return params
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}
+	g.eachField(t, "json", func(name string, field reflect.StructField, rules bindingRules) {
+		prop := g.SchemaFor(field.Type)
+		rules.apply(prop)
+		schema.Properties[name] = prop
+		if rules.required {
+			schema.Required = append(schema.Required, name)
+		}
+	})
+	return schema
+}
+
+// eachField calls fn for every exported field of struct type t that is
+// encoded under tagKey, flattening embedded structs the way encoding/json does
+func (g *Generator) eachField(t reflect.Type, tagKey string, fn func(name string, field reflect.StructField, rules bindingRules)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, hasTag := field.Tag.Lookup(tagKey)
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.eachField(field.Type, tagKey, fn)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			// Query parameters are only bound from tagged fields
+			if tagKey == "form" && !hasTag {
+				continue
+			}
+			name = field.Name
+		}
+		fn(name, field, parseBinding(field.Tag.Get("binding")))
+	}
+}
+
+// componentName names t's component after the Go type, capitalised, and
+// qualifies it with the package name if another type already took the name
+func (g *Generator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := capitalise(t.Name())
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = capitalise(pkg) + name
+	}
+	return name
+}
+
+// bindingRules is the subset of validator tags that map onto JSON Schema keywords
+type bindingRules struct {
+	required bool
+	min, max *float64
+	oneOf    []any
+	format   string
+}
+
+func parseBinding(tag string) bindingRules {
+	var rules bindingRules
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			// Later rules apply to elements, which schemas here do not model
+			return rules
+		case "required":
+			rules.required = true
+		case "min", "gte":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				rules.min = &n
+			}
+		case "max", "lte":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				rules.max = &n
+			}
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				rules.oneOf = append(rules.oneOf, v)
+			}
+		case "url", "http_url":
+			rules.format = "uri"
+		case "uuid", "uuid4":
+			rules.format = "uuid"
+		case "email":
+			rules.format = "email"
+		}
+	}
+	return rules
+}
+
+// apply narrows schema with the rules; min and max bound the length of
+// strings and arrays and the value of numbers, as they do in the validator
+func (r bindingRules) apply(schema *Schema) {
+	target := schema
+	if len(schema.AnyOf) > 0 {
+		target = schema.AnyOf[0]
+	}
+	if target.Ref != "" {
+		return
+	}
+	if r.format != "" {
+		target.Format = r.format
+	}
+	if r.oneOf != nil {
+		target.Enum = r.oneOf
+	}
+	switch {
+	case target.Type.has("string"):
+		target.MinLength, target.MaxLength = toInt(r.min), toInt(r.max)
+	case target.Type.has("array"):
+		target.MinItems, target.MaxItems = toInt(r.min), toInt(r.max)
+	case target.Type.has("integer"), target.Type.has("number"):
+		target.Minimum, target.Maximum = r.min, r.max
+	}
+}
+
+func (t Types) has(name string) bool {
+	for _, v := range t {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable allows null in addition to schema
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" || len(schema.Type) == 0 {
+		if schema.Ref == "" && len(schema.AnyOf) == 0 {
+			// An untyped schema already accepts null
+			return schema
+		}
+		return &Schema{AnyOf: []*Schema{schema, {Type: Types{"null"}}}}
+	}
+	if !schema.Type.has("null") {
+		schema.Type = append(schema.Type, "null")
+	}
+	return schema
+}
+
+func toInt(f *float64) *int {
+	if f == nil {
+		return nil
+	}
+	n := int(*f)
+	return &n
+}
+
+func capitalise(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
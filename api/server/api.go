@@ -1,63 +1,338 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 
+	"github.com/joel-thompson/my-go-service/api/graphql"
+	"github.com/joel-thompson/my-go-service/cache"
+	"github.com/joel-thompson/my-go-service/events"
+	"github.com/joel-thompson/my-go-service/httpcompress"
+	"github.com/joel-thompson/my-go-service/jobs"
+	"github.com/joel-thompson/my-go-service/ratelimit"
+	"github.com/joel-thompson/my-go-service/retention"
 	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/joel-thompson/my-go-service/validation"
+	"github.com/joel-thompson/my-go-service/webhooks"
 )
 
+// ItemStore is the subset of storage used for item reads and writes.
+// *storage.Store and the caching *cache.Store both implement it.
+type ItemStore interface {
+	CreateItem(ctx context.Context, req storage.CreateItemRequest) (*storage.Item, error)
+	ListItems(ctx context.Context, req storage.ListItemsRequest) (*storage.ListItemsResponse, error)
+	ListItemsVersion(ctx context.Context, req storage.ListItemsRequest) (*storage.ItemsVersion, error)
+	ListItemsPage(ctx context.Context, req storage.ItemPageRequest) (*storage.ItemPage, error)
+	GetItem(ctx context.Context, id uuid.UUID) (*storage.Item, error)
+	UpdateItem(ctx context.Context, id uuid.UUID, req storage.UpdateItemRequest) (*storage.Item, error)
+	DeleteItem(ctx context.Context, id uuid.UUID) (*storage.Item, []string, error)
+	TransitionItem(ctx context.Context, id uuid.UUID, transition storage.Transition) (*storage.Item, error)
+}
+
 // API holds the server dependencies
 type API struct {
-	logger *slog.Logger
-	store  *storage.Store
+	logger             *slog.Logger
+	store              *storage.Store
+	items              ItemStore
+	cache              *cache.Store
+	metadataSchema     *storage.MetadataSchema
+	validator          *validation.Validator
+	blobs              storage.BlobStore
+	maxAttachmentBytes int64
+	events             *events.Broker
+	webhooks           *webhooks.Dispatcher
+	jobs               *jobs.Pool
+	retention          *retention.Service
+	limiter            ratelimit.Limiter
+	live               atomic.Pointer[Live]
+	configStatus       func() ConfigStatus
+	apiKeyHeader       string
+	apiKeys            []string
+	trustedProxies     []string
+	legacyRoutes       LegacyRoutes
+	graphqlLimits      graphql.Limits
+	compression        Compression
+}
+
+// Options holds optional API settings
+type Options struct {
+	// MetadataSchema validates item metadata on create and update when set
+	MetadataSchema *storage.MetadataSchema
+
+	// Validator checks and normalises item fields (default validation.DefaultLimits)
+	Validator *validation.Validator
+
+	// Blobs stores attachment contents
+	Blobs storage.BlobStore
+
+	// MaxAttachmentBytes limits the size of a single attachment (default 25 MiB)
+	MaxAttachmentBytes int64
+
+	// Events delivers item changes to GET /items/events
+	Events *events.Broker
+
+	// Webhooks sends test deliveries on POST /webhooks/:id/test
+	Webhooks *webhooks.Dispatcher
+
+	// Jobs lists the job kinds POST /jobs accepts
+	Jobs *jobs.Pool
+
+	// Retention previews and runs retention rules
+	Retention *retention.Service
+
+	// Cache serves item reads when set; otherwise items are read from the database
+	Cache *cache.Store
+
+	// RateLimiter enforces RateLimits when set
+	RateLimiter ratelimit.Limiter
+
+	// RateLimits sets the token bucket for each route group
+	RateLimits RateLimits
+
+	// APIKeyHeader names the header that identifies callers for rate limiting (default X-API-Key)
+	APIKeyHeader string
+
+	// APIKeys lists the keys that get their own rate limit buckets; callers
+	// sending any other key are limited by IP
+	APIKeys []string
+
+	// TrustedProxies lists proxy IPs or CIDRs whose X-Forwarded-For is believed
+	// when resolving the client IP; none are trusted by default
+	TrustedProxies []string
+
+	// LegacyRoutes controls the deprecated unversioned aliases of the /v1 routes
+	LegacyRoutes LegacyRoutes
+
+	// GraphQLLimits bounds the depth and complexity of /graphql operations
+	GraphQLLimits graphql.Limits
+
+	// CacheControl sets Cache-Control on GET /items and GET /items/:id
+	CacheControl CacheControl
+
+	// Compression configures response compression and gzip request bodies
+	Compression Compression
+
+	// MaxBodyBytes caps request bodies on routes without a limit of their own (default DefaultMaxBodyBytes)
+	MaxBodyBytes int64
+
+	// HandlerTimeout cancels a request's context, and its storage calls, once
+	// exceeded; streaming routes are exempt (default DefaultHandlerTimeout)
+	HandlerTimeout time.Duration
+
+	// ClientIdentities maps verified client certificate subjects to caller
+	// identities; when nil a certificate's common name is its identity
+	ClientIdentities map[string]string
+
+	// ConfigStatus reports the configuration generation on GET /admin/config
+	ConfigStatus func() ConfigStatus
 }
 
 // New creates a new API instance
-func New(logger *slog.Logger, db *sqlx.DB) *API {
-	return &API{
-		logger: logger,
-		store:  storage.New(db),
+func New(logger *slog.Logger, db *sqlx.DB, opts Options) *API {
+	if opts.MaxAttachmentBytes <= 0 {
+		opts.MaxAttachmentBytes = DefaultMaxAttachmentBytes
+	}
+	if opts.APIKeyHeader == "" {
+		opts.APIKeyHeader = DefaultAPIKeyHeader
+	}
+	if opts.LegacyRoutes.Sunset.IsZero() {
+		opts.LegacyRoutes.Sunset = DefaultLegacySunset
+	}
+	if opts.Validator == nil {
+		opts.Validator = validation.New(validation.DefaultLimits())
 	}
+	if opts.Compression.MinSize <= 0 {
+		opts.Compression.MinSize = DefaultCompressionMinSize
+	}
+	if opts.Compression.ContentTypes == nil {
+		opts.Compression.ContentTypes = DefaultCompressibleTypes
+	}
+	if opts.Compression.Level == "" {
+		opts.Compression.Level = httpcompress.Default
+	}
+	if opts.Compression.MaxDecompressedBytes <= 0 {
+		opts.Compression.MaxDecompressedBytes = DefaultMaxDecompressedBytes
+	}
+
+	store := storage.New(db)
+	var items ItemStore = store
+	if opts.Cache != nil {
+		items = opts.Cache
+	}
+
+	a := &API{
+		logger:             logger,
+		store:              store,
+		items:              items,
+		cache:              opts.Cache,
+		limiter:            opts.RateLimiter,
+		configStatus:       opts.ConfigStatus,
+		apiKeyHeader:       opts.APIKeyHeader,
+		apiKeys:            opts.APIKeys,
+		trustedProxies:     opts.TrustedProxies,
+		legacyRoutes:       opts.LegacyRoutes,
+		graphqlLimits:      opts.GraphQLLimits,
+		compression:        opts.Compression,
+		metadataSchema:     opts.MetadataSchema,
+		validator:          opts.Validator,
+		blobs:              opts.Blobs,
+		maxAttachmentBytes: opts.MaxAttachmentBytes,
+		events:             opts.Events,
+		webhooks:           opts.Webhooks,
+		jobs:               opts.Jobs,
+		retention:          opts.Retention,
+	}
+	a.Reload(Live{
+		RateLimits:          opts.RateLimits,
+		CacheControl:        opts.CacheControl,
+		MaxBodyBytes:        opts.MaxBodyBytes,
+		HandlerTimeout:      opts.HandlerTimeout,
+		ClientIdentities:    opts.ClientIdentities,
+		CompressionDisabled: opts.Compression.Disabled,
+	})
+	return a
 }
 
-// SetupRoutes configures all API routes
-func (a *API) SetupRoutes() *gin.Engine {
+// SetupRoutes configures all API routes. It fails when the GraphQL schema
+// cannot be built, rather than serving without /graphql.
+func (a *API) SetupRoutes() (*gin.Engine, error) {
 	// Set Gin to release mode to reduce log verbosity
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
 
+	// Only believe X-Forwarded-For from configured proxies, so clients cannot
+	// pick their own IP for logging and rate limiting
+	if err := router.SetTrustedProxies(a.trustedProxies); err != nil {
+		a.logger.Error("Invalid trusted proxies, trusting none", "error", err)
+		router.SetTrustedProxies(nil)
+	}
+
 	// Add middleware
 	router.Use(gin.Recovery())
+	router.Use(a.clientCertMiddleware())
 	router.Use(a.loggingMiddleware())
+	if a.limiter != nil {
+		router.Use(a.rateLimitMiddleware())
+	}
+	router.Use(a.bodyLimitMiddleware())
+	router.Use(a.compressionMiddleware())
+	router.Use(a.timeoutMiddleware())
 
 	// Health check endpoint
 	router.GET("/health", a.handleHealth)
 
+	// API documentation
+	router.GET("/openapi.json", a.handleGetOpenAPI)
+	router.GET("/docs", a.handleGetDocs)
+
+	// GraphQL endpoint; the schema evolves without versions, so it sits outside /v1
+	graphqlHandler, err := a.newGraphQLHandler()
+	if err != nil {
+		return nil, fmt.Errorf("build GraphQL schema: %w", err)
+	}
+	router.GET("/graphql", gin.WrapH(graphqlHandler))
+	router.POST("/graphql", gin.WrapH(graphqlHandler))
+
+	// Versioned API routes, plus their deprecated unversioned aliases
+	for i := range apiVersions {
+		v := &apiVersions[i]
+		a.registerRoutes(router.Group(v.Prefix(), a.versionMiddleware(v)))
+	}
+	if !a.legacyRoutes.Disabled {
+		a.registerRoutes(router.Group("", a.legacyMiddleware()))
+	}
+
+	return router, nil
+}
+
+// registerRoutes adds the versioned API routes to r. Handlers are shared by
+// every version; version-specific response shapes come from APIVersion.Adapt.
+func (a *API) registerRoutes(r gin.IRoutes) {
 	// Hello world endpoint
-	router.GET("/hello", a.handleHello)
+	r.GET("/hello", a.handleHello)
+
+	// Items endpoints; responses and bodies are negotiated between JSON,
+	// YAML and MessagePack, plus CSV for the list
+	items := a.negotiateFormats(itemFormats)
+	r.POST("/items", items, a.handleCreateItem)
+	r.GET("/items", a.negotiateFormats(listFormats), a.handleListItems)
+	r.GET("/items/events", a.handleStreamItemEvents)
+	r.GET("/items/:id", items, a.handleGetItem)
+	r.PUT("/items/:id", items, a.handleUpdateItem)
+	r.DELETE("/items/:id", items, a.handleDeleteItem)
+
+	// Item lifecycle endpoints
+	r.POST("/items/:id/publish", items, a.handlePublishItem)
+	r.POST("/items/:id/archive", items, a.handleArchiveItem)
+	r.POST("/items/:id/unarchive", items, a.handleUnarchiveItem)
+	r.GET("/items/:id/history", items, a.handleGetItemHistory)
+
+	// Item link endpoints
+	r.POST("/items/:id/links", items, a.handleCreateItemLink)
+	r.GET("/items/:id/links", items, a.handleListItemLinks)
+	r.DELETE("/items/:id/links/:linkId", items, a.handleDeleteItemLink)
+	r.GET("/items/:id/graph", items, a.handleGetItemGraph)
+
+	// Item attachment endpoints; uploads are multipart and downloads are the
+	// stored bytes, so only the JSON listings are negotiated
+	r.POST("/items/:id/attachments", a.handleUploadAttachment)
+	r.GET("/items/:id/attachments", items, a.handleListAttachments)
+	r.GET("/items/:id/attachments/:attachmentId", a.handleDownloadAttachment)
+	r.DELETE("/items/:id/attachments/:attachmentId", items, a.handleDeleteAttachment)
+
+	// Job endpoints
+	r.POST("/jobs", a.handleEnqueueJob)
+	r.GET("/jobs", a.handleListJobs)
+	r.GET("/jobs/:id", a.handleGetJob)
+	r.POST("/jobs/:id/cancel", a.handleCancelJob)
+
+	// Retention endpoints
+	r.GET("/retention/rules", a.handleListRetentionRules)
+	r.GET("/retention/rules/:name/preview", a.handlePreviewRetentionRule)
+	r.POST("/retention/rules/:name/run", a.handleRunRetentionRule)
+	r.GET("/retention/runs", a.handleListRetentionRuns)
+
+	// Outbox endpoints
+	r.GET("/outbox/stats", a.handleGetOutboxStats)
+
+	// Cache endpoints
+	r.GET("/cache/stats", a.handleGetCacheStats)
 
-	// Items endpoints
-	router.POST("/items", a.handleCreateItem)
-	router.GET("/items", a.handleListItems)
-	router.GET("/items/:id", a.handleGetItem)
-	router.PUT("/items/:id", a.handleUpdateItem)
-	router.DELETE("/items/:id", a.handleDeleteItem)
+	// Admin endpoints
+	r.GET("/admin/config", a.handleGetConfigStatus)
+	r.GET("/admin/db", a.handleGetDatabaseStats)
 
-	return router
+	// Webhook endpoints
+	r.POST("/webhooks", a.handleCreateWebhook)
+	r.GET("/webhooks", a.handleListWebhooks)
+	r.GET("/webhooks/:id", a.handleGetWebhook)
+	r.DELETE("/webhooks/:id", a.handleDeleteWebhook)
+	r.POST("/webhooks/:id/test", a.handleTestWebhook)
+	r.GET("/webhooks/:id/deliveries", a.handleListWebhookDeliveries)
+	r.GET("/webhooks/deliveries/:deliveryId", a.handleGetWebhookDelivery)
+	r.POST("/webhooks/deliveries/:deliveryId/redeliver", a.handleRedeliverWebhookDelivery)
 }
 
 // loggingMiddleware adds structured logging to all requests
 func (a *API) loggingMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		a.logger.Info("HTTP request",
+		attrs := []any{
 			slog.String("method", c.Request.Method),
 			slog.String("path", c.Request.URL.Path),
 			slog.String("remote_addr", c.ClientIP()),
-		)
+		}
+		if identity := clientIdentity(c); identity != "" {
+			attrs = append(attrs, slog.String("identity", identity))
+		}
+		a.logger.Info("HTTP request", attrs...)
 		c.Next()
 	}
 }
@@ -0,0 +1,350 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// DefaultMaxAttachmentBytes is used when Options.MaxAttachmentBytes is not set
+const DefaultMaxAttachmentBytes = 25 * 1024 * 1024
+
+// multipartOverheadBytes allows for boundaries, part headers and small form fields
+const multipartOverheadBytes = 1024 * 1024
+
+// sniffLen is the number of bytes http.DetectContentType looks at
+const sniffLen = 512
+
+// maxFilenameLength is the size in characters of the item_attachments.filename column
+const maxFilenameLength = 255
+
+// errAttachmentTooLarge is returned by attachmentReader once the size limit is exceeded
+var errAttachmentTooLarge = errors.New("attachment exceeds size limit")
+
+// attachmentReader hashes and counts bytes as they stream to the blob store
+// and fails the upload as soon as the size limit is exceeded
+type attachmentReader struct {
+	r     io.Reader
+	hash  hash.Hash
+	size  int64
+	limit int64
+}
+
+func (a *attachmentReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	a.size += int64(n)
+	a.hash.Write(p[:n])
+	if a.size > a.limit {
+		return n, errAttachmentTooLarge
+	}
+	return n, err
+}
+
+// handleUploadAttachment streams a multipart "file" field into the blob store
+func (a *API) handleUploadAttachment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return
+	}
+
+	if _, err := a.items.GetItem(c.Request.Context(), id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Item not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to get item", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to upload attachment",
+		})
+		return
+	}
+
+	// Cap the whole body too so oversized non-file parts cannot be streamed forever
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, a.maxAttachmentBytes+multipartOverheadBytes)
+
+	// MultipartReader walks the body part by part instead of buffering the
+	// whole form the way ParseMultipartForm would
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Expected a multipart/form-data request",
+		})
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Missing \"file\" form field",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid multipart body",
+			})
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		a.storeAttachment(c, id, part.FileName(), part)
+		part.Close()
+		return
+	}
+}
+
+// storeAttachment sniffs, hashes and stores a single uploaded file
+func (a *API) storeAttachment(c *gin.Context, itemID uuid.UUID, filename string, body io.Reader) {
+	ctx := c.Request.Context()
+
+	filename = filepath.Base(filename)
+	if filename == "." || filename == string(filepath.Separator) {
+		filename = "attachment"
+	}
+	if utf8.RuneCountInString(filename) > maxFilenameLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid filename",
+			"details": fmt.Sprintf("Filename must be at most %d characters", maxFilenameLength),
+		})
+		return
+	}
+
+	// Read the first bytes for content sniffing, then stitch them back on
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		a.logger.Error("Failed to read attachment", "item_id", itemID, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read uploaded file",
+		})
+		return
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+
+	attachmentID := uuid.New()
+	key := storage.AttachmentKey(itemID, attachmentID)
+	counted := &attachmentReader{
+		r:     io.MultiReader(bytes.NewReader(head), body),
+		hash:  sha256.New(),
+		limit: a.maxAttachmentBytes,
+	}
+
+	if err := a.blobs.Put(ctx, key, counted, contentType); err != nil {
+		// Remove anything a failed upload may have left behind
+		if delErr := a.blobs.Delete(ctx, key); delErr != nil {
+			a.logger.Warn("Failed to clean up partial attachment", "key", key, "error", delErr)
+		}
+		// Blob stores may not wrap the reader's error, so check the count directly
+		var maxBytesErr *http.MaxBytesError
+		if counted.size > counted.limit || errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("Attachment exceeds the %d byte limit", a.maxAttachmentBytes),
+			})
+			return
+		}
+		a.logger.Error("Failed to store attachment", "item_id", itemID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store attachment",
+		})
+		return
+	}
+
+	attachment, err := a.store.CreateAttachment(ctx, storage.Attachment{
+		ID:          attachmentID,
+		ItemID:      itemID,
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   counted.size,
+		SHA256:      hex.EncodeToString(counted.hash.Sum(nil)),
+		StorageKey:  key,
+	})
+	if err != nil {
+		if delErr := a.blobs.Delete(ctx, key); delErr != nil {
+			a.logger.Warn("Failed to clean up orphaned attachment", "key", key, "error", delErr)
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Item not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to save attachment", "item_id", itemID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save attachment",
+		})
+		return
+	}
+
+	a.logger.Info("Attachment uploaded", "item_id", itemID, "attachment_id", attachment.ID, "size_bytes", attachment.SizeBytes)
+	a.respond(c, http.StatusCreated, attachment)
+}
+
+// handleListAttachments lists the attachments of an item
+func (a *API) handleListAttachments(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return
+	}
+
+	attachments, err := a.store.ListAttachments(c.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Item not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to list attachments", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve attachments",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"item_id":     id,
+		"attachments": attachments,
+	})
+}
+
+// handleDownloadAttachment streams an attachment's contents
+func (a *API) handleDownloadAttachment(c *gin.Context) {
+	itemID, attachmentID, ok := a.parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	attachment, err := a.store.GetAttachment(c.Request.Context(), itemID, attachmentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Attachment not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to get attachment", "item_id", itemID, "attachment_id", attachmentID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve attachment",
+		})
+		return
+	}
+
+	blob, err := a.blobs.Get(c.Request.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			a.logger.Error("Attachment blob is missing", "attachment_id", attachmentID, "key", attachment.StorageKey)
+		} else {
+			a.logger.Error("Failed to open attachment", "attachment_id", attachmentID, "error", err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve attachment",
+		})
+		return
+	}
+	defer blob.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.SizeBytes, attachment.ContentType, blob, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"ETag":                `"` + attachment.SHA256 + `"`,
+		"Digest":              "sha-256=" + attachment.SHA256,
+	})
+}
+
+// handleDeleteAttachment removes an attachment and its blob
+func (a *API) handleDeleteAttachment(c *gin.Context) {
+	itemID, attachmentID, ok := a.parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	attachment, err := a.store.DeleteAttachment(c.Request.Context(), itemID, attachmentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Attachment not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to delete attachment", "item_id", itemID, "attachment_id", attachmentID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete attachment",
+		})
+		return
+	}
+
+	// The metadata row is gone, so a failure here only leaves an unreachable blob
+	if err := a.blobs.Delete(c.Request.Context(), attachment.StorageKey); err != nil {
+		a.logger.Warn("Failed to delete attachment blob", "key", attachment.StorageKey, "error", err)
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"message":    "Attachment deleted successfully",
+		"attachment": attachment,
+	})
+}
+
+// parseAttachmentParams parses the item and attachment IDs, writing a 400 response on failure
+func (a *API) parseAttachmentParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	idStr := c.Param("id")
+	itemID, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	attachmentIDStr := c.Param("attachmentId")
+	attachmentID, err := uuid.Parse(attachmentIDStr)
+	if err != nil {
+		a.logger.Error("Invalid attachment ID", "attachment_id", attachmentIDStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid attachment ID format",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return itemID, attachmentID, true
+}
+
+// deleteItemBlobs removes the blobs of attachments that were deleted with their item
+func (a *API) deleteItemBlobs(ctx context.Context, blobKeys []string) {
+	for _, key := range blobKeys {
+		if err := a.blobs.Delete(ctx, key); err != nil {
+			a.logger.Warn("Failed to delete attachment blob", "key", key, "error", err)
+		}
+	}
+}
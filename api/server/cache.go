@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetCacheStats reports item cache sizes and hit/miss counters
+func (a *API) handleGetCacheStats(c *gin.Context) {
+	if a.cache == nil {
+		a.respond(c, http.StatusOK, gin.H{
+			"enabled": false,
+		})
+		return
+	}
+
+	stats := a.cache.Stats()
+	a.respond(c, http.StatusOK, gin.H{
+		"enabled":       true,
+		"items":         stats.Items,
+		"lists":         stats.Lists,
+		"remote":        stats.Remote,
+		"hits_local":    stats.HitsLocal,
+		"hits_remote":   stats.HitsRemote,
+		"misses":        stats.Misses,
+		"coalesced":     stats.Coalesced,
+		"invalidations": stats.Invalidations,
+		"evictions":     stats.Evictions,
+		"remote_errors": stats.RemoteErrors,
+	})
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// identityKey holds the caller identity established from a client certificate
+const identityKey = "client_identity"
+
+// clientCertMiddleware maps a verified client certificate to an identity.
+// With ClientIdentities configured the certificate's subject, or failing
+// that "CN=<common name>", must be listed, or the request is refused with
+// 403; without them the common name is the identity. Requests without a
+// certificate pass through unidentified.
+func (a *API) clientCertMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			c.Next()
+			return
+		}
+
+		cert := c.Request.TLS.VerifiedChains[0][0]
+		subject := cert.Subject.String()
+		identity := cert.Subject.CommonName
+		if identities := a.settings().ClientIdentities; identities != nil {
+			var ok bool
+			identity, ok = identities[subject]
+			if !ok {
+				identity, ok = identities["CN="+cert.Subject.CommonName]
+			}
+			if !ok {
+				a.logger.Warn("Unmapped client certificate", "subject", subject)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error":   "Client certificate not authorized",
+					"details": "No identity is mapped to certificate subject " + subject,
+				})
+				return
+			}
+		}
+
+		c.Set(identityKey, identity)
+		c.Next()
+	}
+}
+
+// clientIdentity returns the identity established by clientCertMiddleware, or ""
+func clientIdentity(c *gin.Context) string {
+	return c.GetString(identityKey)
+}
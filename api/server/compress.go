@@ -0,0 +1,253 @@
+package server
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/joel-thompson/my-go-service/httpcompress"
+)
+
+const (
+	// DefaultCompressionMinSize is the smallest body worth compressing; below
+	// it the encoding overhead outweighs the savings
+	DefaultCompressionMinSize = 1024
+	// DefaultMaxDecompressedBytes caps a decompressed request body, so a
+	// small gzip bomb cannot expand into gigabytes
+	DefaultMaxDecompressedBytes int64 = 32 << 20
+)
+
+// DefaultCompressibleTypes lists the media types compressed by default.
+// Images, archives and other attachments are usually compressed already.
+var DefaultCompressibleTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/yaml",
+	"application/msgpack",
+	"application/x-ndjson",
+	"text/csv",
+	"text/event-stream",
+	"text/html",
+	"text/plain",
+}
+
+// Compression configures response compression and gzip request bodies
+type Compression struct {
+	// Disabled sends every response uncompressed; gzip request bodies are
+	// still accepted. It seeds Live.CompressionDisabled, which Reload changes.
+	Disabled bool
+
+	// MinSize is the smallest response body compressed (default DefaultCompressionMinSize).
+	// Streaming responses are compressed from their first flush whatever their size.
+	MinSize int
+
+	// ContentTypes lists the media types to compress (default DefaultCompressibleTypes)
+	ContentTypes []string
+
+	// Level trades CPU for size (default httpcompress.Default)
+	Level httpcompress.Level
+
+	// MaxDecompressedBytes caps a gzip request body once decompressed (default DefaultMaxDecompressedBytes)
+	MaxDecompressedBytes int64
+}
+
+// compressionMiddleware decompresses gzip request bodies and compresses
+// responses with the coding negotiated from Accept-Encoding
+func (a *API) compressionMiddleware() gin.HandlerFunc {
+	pool := httpcompress.NewPool(a.compression.Level)
+
+	return func(c *gin.Context) {
+		if !a.decompressRequest(c) {
+			return
+		}
+
+		// Upgraded connections, such as GraphQL subscriptions, are not HTTP bodies
+		if a.settings().CompressionDisabled || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			config:         &a.compression,
+			pool:           pool,
+			encoding:       httpcompress.Negotiate(c.GetHeader("Accept-Encoding")),
+		}
+		c.Writer = w
+		defer func() {
+			if err := w.close(); err != nil {
+				a.logger.Warn("Failed to finish compressed response", "error", err)
+			}
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// decompressRequest replaces a gzip request body with its decompressed
+// contents, capped at MaxDecompressedBytes; reading past the cap fails with
+// *http.MaxBytesError. Other codings are refused with 415. It reports
+// whether the request should continue.
+func (a *API) decompressRequest(c *gin.Context) bool {
+	coding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+	switch coding {
+	case "", "identity":
+		return true
+	case httpcompress.Gzip, "x-gzip":
+	default:
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":   "Unsupported request encoding",
+			"details": "Content-Encoding " + coding + " is not accepted (supported: gzip)",
+		})
+		return false
+	}
+
+	gz, err := gzip.NewReader(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error": "Request body is not valid gzip",
+		})
+		return false
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, gz, a.compression.MaxDecompressedBytes)
+	c.Request.Header.Del("Content-Encoding")
+	c.Request.Header.Del("Content-Length")
+	c.Request.ContentLength = -1
+	return true
+}
+
+// compressWriter holds back the start of a response until it knows whether
+// to compress it: once MinSize bytes are buffered, on the first Flush, or
+// when the handler finishes, whichever comes first.
+type compressWriter struct {
+	gin.ResponseWriter
+	config   *Compression
+	pool     *httpcompress.Pool
+	encoding string
+
+	buf     []byte
+	decided bool
+	enc     httpcompress.Writer
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.config.MinSize {
+			return len(p), nil
+		}
+		return len(p), w.decide(true)
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow sends bodiless responses such as 304 straight away; other
+// headers wait until the compression decision is made
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided && bodyAllowed(w.Status()) {
+		return
+	}
+	if !w.decided {
+		w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush commits to compressing a streaming response, then pushes what the
+// encoder holds through to the client
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap lets http.ResponseController reach the connection's deadlines
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide fixes the response's encoding, compressing if asked to and the
+// response allows it, and writes out whatever was buffered
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	h := w.Header()
+	compressible := w.compressible()
+	if compressible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if compress && compressible && w.encoding != "" {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.encoding)
+		// The bytes differ from the identity response, so only weak comparison holds
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.enc = w.pool.Get(w.encoding, w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// compressible reports whether the response's status and Content-Type allow compression
+func (w *compressWriter) compressible() bool {
+	status := w.Status()
+	if !bodyAllowed(status) || status == http.StatusPartialContent {
+		return false
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	return err == nil && slices.Contains(w.config.ContentTypes, mediaType)
+}
+
+// close sends a response that stayed under MinSize and finishes the encoder
+func (w *compressWriter) close() error {
+	if !w.decided {
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.enc == nil {
+		return nil
+	}
+	err := w.enc.Close()
+	w.pool.Put(w.encoding, w.enc)
+	w.enc = nil
+	return err
+}
+
+// bodyAllowed reports whether a response with status may have a body
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/httpcompress"
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+func TestCompressionReload(t *testing.T) {
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, Options{})
+	router := gin.New()
+	router.Use(a.compressionMiddleware())
+	router.GET("/items", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"name": strings.Repeat("widget ", 500)})
+	})
+
+	encoding := func() string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		router.ServeHTTP(w, r)
+		return w.Header().Get("Content-Encoding")
+	}
+
+	if got := encoding(); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	a.Reload(Live{CompressionDisabled: true})
+	if got := encoding(); got != "" {
+		t.Fatalf("after disabling: Content-Encoding = %q, want none", got)
+	}
+	a.Reload(Live{})
+	if got := encoding(); got != "gzip" {
+		t.Fatalf("after enabling again: Content-Encoding = %q, want gzip", got)
+	}
+}
+
+// BenchmarkCompression measures what response compression costs and saves
+// on GET /items pages: for each coding and level it compresses one page
+// with a pooled encoder, as the server does, and reports the compressed
+// size and its ratio to the JSON body alongside the CPU time.
+func BenchmarkCompression(b *testing.B) {
+	for _, n := range []int{10, 100} {
+		payload, err := json.Marshal(benchmarkPage(n))
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, encoding := range httpcompress.Encodings {
+			for _, level := range httpcompress.Levels {
+				b.Run(fmt.Sprintf("items=%d/%s/%s", n, encoding, level), func(b *testing.B) {
+					pool := httpcompress.NewPool(level)
+					var out countingWriter
+					b.ReportAllocs()
+					b.SetBytes(int64(len(payload)))
+					for b.Loop() {
+						out = 0
+						enc := pool.Get(encoding, &out)
+						if _, err := enc.Write(payload); err != nil {
+							b.Fatal(err)
+						}
+						if err := enc.Close(); err != nil {
+							b.Fatal(err)
+						}
+						pool.Put(encoding, enc)
+					}
+					b.ReportMetric(float64(out), "bytes")
+					b.ReportMetric(float64(len(payload))/float64(out), "ratio")
+				})
+			}
+		}
+	}
+}
+
+// countingWriter discards what it is given, counting the bytes
+type countingWriter int
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+// benchmarkPage builds a page of n items shaped like typical API data:
+// short names, a sentence of description and a few metadata fields
+func benchmarkPage(n int) storage.ListItemsResponse {
+	statuses := []storage.ItemStatus{storage.StatusDraft, storage.StatusPublished, storage.StatusArchived}
+	colors := []string{"red", "green", "blue", "black"}
+	created := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	items := make([]storage.Item, n)
+	for i := range items {
+		description := fmt.Sprintf("Widget %d for the spring catalogue, assembled in batch %d and checked by QA", i, i/7)
+		items[i] = storage.Item{
+			ID:          uuid.New(),
+			Name:        fmt.Sprintf("Widget %d", i),
+			Description: &description,
+			Metadata: storage.Metadata{
+				"color": colors[i%len(colors)],
+				"size":  10 + i%5,
+				"tags":  []string{"catalogue", "spring"},
+				"dims":  map[string]any{"width": 3 + i%4, "height": 8},
+			},
+			Status:    statuses[i%len(statuses)],
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: created.Add(time.Duration(i) * time.Hour),
+		}
+	}
+
+	total := n * 5
+	return storage.ListItemsResponse{Items: items, Total: &total, Limit: n, HasMore: true}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const (
+	// eventBuffer is how many events a slow SSE client may fall behind before it is dropped
+	eventBuffer = 256
+	// replayBatch is how many logged events are read per query when resuming
+	replayBatch = 500
+	// keepAliveInterval keeps idle connections open through proxies
+	keepAliveInterval = 15 * time.Second
+)
+
+// itemEventsQuery holds the query parameters of GET /items/events
+type itemEventsQuery struct {
+	ItemID      string `form:"item_id"`
+	Tag         string `form:"tag"`
+	LastEventID string `form:"last_event_id"`
+}
+
+// handleStreamItemEvents streams item changes as Server-Sent Events.
+// Clients resume after a disconnect by sending the Last-Event-ID header
+// (or the last_event_id query parameter); missed events are replayed from
+// the item_events log before live events continue.
+func (a *API) handleStreamItemEvents(c *gin.Context) {
+	var query itemEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		a.logger.Error("Failed to bind query parameters", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+
+	filter := storage.ItemEventFilter{Tag: query.Tag}
+	if query.ItemID != "" {
+		id, err := uuid.Parse(query.ItemID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid item ID format",
+			})
+			return
+		}
+		filter.ItemID = &id
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.LastEventID
+	}
+	resumeFrom := int64(-1)
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid Last-Event-ID",
+			})
+			return
+		}
+		resumeFrom = id
+	}
+
+	// Subscribe before replaying so nothing committed in between is missed
+	sub := a.events.Subscribe(filter, eventBuffer)
+	defer a.events.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	replayed := make(map[int64]bool)
+	if resumeFrom >= 0 {
+		after := resumeFrom
+		for {
+			events, err := a.events.Replay(ctx, after, filter, replayBatch)
+			if err != nil {
+				a.logger.Error("Failed to replay item events", "after", after, "error", err)
+				return
+			}
+			for _, event := range events {
+				if !a.writeItemEvent(c, event) {
+					return
+				}
+				replayed[event.ID] = true
+				after = event.ID
+			}
+			if len(events) < replayBatch {
+				break
+			}
+		}
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind, or the server is shutting down
+				return
+			}
+			if replayed[event.ID] {
+				continue
+			}
+			if !a.writeItemEvent(c, event) {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeItemEvent writes one SSE frame and reports whether the client is still connected
+func (a *API) writeItemEvent(c *gin.Context, event storage.ItemEvent) bool {
+	err := sse.Encode(c.Writer, sse.Event{
+		Id:    strconv.FormatInt(event.ID, 10),
+		Event: string(event.Type),
+		Data:  event,
+	})
+	if err != nil {
+		a.logger.Debug("SSE client went away", "error", err)
+		return false
+	}
+	c.Writer.Flush()
+	return true
+}
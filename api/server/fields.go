@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// itemView is an item trimmed to a sparse fieldset. With no fields it
+// encodes exactly like storage.Item.
+type itemView struct {
+	item   storage.Item
+	fields []string
+}
+
+// MarshalJSON writes only the selected fields, in storage.ItemColumns order
+func (v itemView) MarshalJSON() ([]byte, error) {
+	if len(v.fields) == 0 {
+		return json.Marshal(v.item)
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range v.fields {
+		value, err := json.Marshal(storage.ItemField(v.item, field))
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Quote(field))
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// pageLinks are relative URLs for a page of a list and its neighbours;
+// Next is empty on the last page and Prev on the first
+type pageLinks struct {
+	Self string `json:"self" binding:"required"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// itemList is the GET /items body: storage.ListItemsResponse with items
+// trimmed to the requested fields and links to the neighbouring pages
+type itemList struct {
+	Items   []itemView `json:"items"`
+	Total   *int       `json:"total,omitempty"`
+	Limit   int        `json:"limit"`
+	Offset  int        `json:"offset"`
+	HasMore bool       `json:"has_more"`
+	Links   pageLinks  `json:"links"`
+
+	// fields is the sparse fieldset, which also picks the CSV columns
+	fields []string
+}
+
+// newItemList wraps a page for the request in c, which supplies the path and
+// query the links are built from
+func newItemList(c *gin.Context, page *storage.ListItemsResponse, fields []string) *itemList {
+	list := &itemList{
+		Items:   make([]itemView, len(page.Items)),
+		Total:   page.Total,
+		Limit:   page.Limit,
+		Offset:  page.Offset,
+		HasMore: page.HasMore,
+		fields:  fields,
+	}
+	for i, item := range page.Items {
+		list.Items[i] = itemView{item: item, fields: fields}
+	}
+
+	list.Links.Self = pageLink(c.Request.URL, page.Offset)
+	if page.HasMore {
+		list.Links.Next = pageLink(c.Request.URL, page.Offset+page.Limit)
+	}
+	if page.Offset > 0 {
+		list.Links.Prev = pageLink(c.Request.URL, max(page.Offset-page.Limit, 0))
+	}
+	return list
+}
+
+// pageLink returns u's path and query with offset replaced
+func pageLink(u *url.URL, offset int) string {
+	query := u.Query()
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	} else {
+		query.Del("offset")
+	}
+	link := url.URL{Path: u.Path, RawQuery: query.Encode()}
+	return link.String()
+}
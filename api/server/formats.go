@@ -0,0 +1,433 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v3"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// mediaFormat is a representation item endpoints can send and, unless
+// decode is nil, read. Every format is derived from the JSON encoding, so
+// field names, times and metadata look the same in each.
+type mediaFormat struct {
+	name      string
+	mediaType string
+	// aliases are other media types that select the format
+	aliases []string
+	encode  func(w http.ResponseWriter, body any) error
+	// decode converts a request body to JSON for binding
+	decode func(data []byte) ([]byte, error)
+}
+
+var (
+	formatJSON = &mediaFormat{
+		name:      "JSON",
+		mediaType: "application/json",
+		encode: func(w http.ResponseWriter, body any) error {
+			data, err := json.Marshal(body)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		},
+		decode: func(data []byte) ([]byte, error) { return data, nil },
+	}
+	formatYAML = &mediaFormat{
+		name:      "YAML",
+		mediaType: "application/yaml",
+		aliases:   []string{"application/x-yaml", "text/yaml", "text/x-yaml"},
+		encode:    encodeYAML,
+		decode:    decodeYAML,
+	}
+	formatMsgPack = &mediaFormat{
+		name:      "MessagePack",
+		mediaType: "application/msgpack",
+		aliases:   []string{"application/x-msgpack", "application/vnd.msgpack"},
+		encode:    encodeMsgPack,
+		decode:    decodeMsgPack,
+	}
+	formatCSV = &mediaFormat{
+		name:      "CSV",
+		mediaType: "text/csv",
+		encode:    encodeCSV,
+	}
+)
+
+// itemFormats are negotiated on the item endpoints. listFormats adds CSV,
+// which only has a form for GET /items.
+var (
+	itemFormats = []*mediaFormat{formatJSON, formatYAML, formatMsgPack}
+	listFormats = []*mediaFormat{formatJSON, formatYAML, formatMsgPack, formatCSV}
+)
+
+// Gin context keys holding the negotiated *mediaFormat of the response and request body
+const (
+	responseFormatKey = "response_format"
+	requestFormatKey  = "request_format"
+)
+
+// matches reports whether mediaType (without parameters) selects f
+func (f *mediaFormat) matches(mediaType string) bool {
+	return mediaType == f.mediaType || slices.Contains(f.aliases, mediaType)
+}
+
+// negotiateFormats picks the response format from Accept and the request
+// body format from Content-Type; a missing header means JSON. An Accept
+// header none of formats satisfy gets 406, and a body in another format 415.
+func (a *API) negotiateFormats(formats []*mediaFormat) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept")
+
+		response, ok := acceptFormat(c.GetHeader("Accept"), formats)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{
+				"error":   "Not acceptable",
+				"details": "Accept allows none of " + formatList(formats),
+			})
+			return
+		}
+		c.Set(responseFormatKey, response)
+
+		if hasBody(c.Request) {
+			request, ok := contentFormat(c.ContentType(), formats)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+					"error":   "Unsupported media type",
+					"details": fmt.Sprintf("Content-Type %s is not accepted (supported: %s)", c.ContentType(), formatList(decodable(formats))),
+				})
+				return
+			}
+			c.Set(requestFormatKey, request)
+		}
+		c.Next()
+	}
+}
+
+// acceptFormat returns the format in formats that an Accept header prefers:
+// the highest q, then the most specific range, then the order of formats
+func acceptFormat(accept string, formats []*mediaFormat) (*mediaFormat, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return formats[0], true
+	}
+
+	var best *mediaFormat
+	bestQ, bestSpecificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		specificity := 2
+		switch {
+		case mediaType == "*/*":
+			specificity = 0
+		case strings.HasSuffix(mediaType, "/*"):
+			specificity = 1
+		}
+		for _, f := range formats {
+			if !acceptMatches(mediaType, f) {
+				continue
+			}
+			if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+				best, bestQ, bestSpecificity = f, q, specificity
+			}
+			break
+		}
+	}
+	return best, best != nil
+}
+
+// acceptMatches reports whether an Accept media range covers f
+func acceptMatches(mediaRange string, f *mediaFormat) bool {
+	if mediaRange == "*/*" {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "*")
+	if !ok {
+		return f.matches(mediaRange)
+	}
+	if strings.HasPrefix(f.mediaType, prefix) {
+		return true
+	}
+	return slices.ContainsFunc(f.aliases, func(alias string) bool { return strings.HasPrefix(alias, prefix) })
+}
+
+// contentFormat returns the format in formats that reads a Content-Type;
+// an empty one is taken as JSON, as before negotiation existed
+func contentFormat(contentType string, formats []*mediaFormat) (*mediaFormat, bool) {
+	if contentType == "" {
+		return formatJSON, true
+	}
+	for _, f := range formats {
+		if f.decode != nil && f.matches(contentType) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || (r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody)
+}
+
+func decodable(formats []*mediaFormat) []*mediaFormat {
+	return slices.DeleteFunc(slices.Clone(formats), func(f *mediaFormat) bool { return f.decode == nil })
+}
+
+func formatList(formats []*mediaFormat) string {
+	types := make([]string, len(formats))
+	for i, f := range formats {
+		types[i] = f.mediaType
+	}
+	return strings.Join(types, ", ")
+}
+
+// lookupFormat returns the format a media type selects, if any
+func lookupFormat(mediaType string) *mediaFormat {
+	for _, f := range listFormats {
+		if f.matches(mediaType) {
+			return f
+		}
+	}
+	return nil
+}
+
+// bindBody decodes the request body in its negotiated format into obj and
+// applies obj's binding tags, like ShouldBindJSON does for JSON bodies
+func (a *API) bindBody(c *gin.Context, obj any) error {
+	f, ok := c.Get(requestFormatKey)
+	if !ok || f == formatJSON {
+		return c.ShouldBindJSON(obj)
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	data, err = f.(*mediaFormat).decode(data)
+	if err != nil {
+		return fmt.Errorf("request body must be a %s object: %w", f.(*mediaFormat).name, err)
+	}
+	return binding.JSON.BindBody(data, obj)
+}
+
+// formatRender writes a body in a negotiated format
+type formatRender struct {
+	format *mediaFormat
+	body   any
+}
+
+func (r formatRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return r.format.encode(w, r.body)
+}
+
+func (r formatRender) WriteContentType(w http.ResponseWriter) {
+	contentType := r.format.mediaType
+	if r.format != formatMsgPack {
+		contentType += "; charset=utf-8"
+	}
+	w.Header()["Content-Type"] = []string{contentType}
+}
+
+// encodeYAML converts the JSON encoding to YAML, keeping the JSON field order
+func encodeYAML(w http.ResponseWriter, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	// JSON parses as flow-style YAML with quoted strings; use block style
+	// and let the encoder quote only what needs it
+	clearYAMLStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+func decodeYAML(data []byte) ([]byte, error) {
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if _, ok := v.(map[string]any); !ok {
+		return nil, errors.New("expected a mapping")
+	}
+	return json.Marshal(v)
+}
+
+// msgpackHandle encodes maps with sorted keys and decodes strings as Go
+// strings and maps as map[string]any, so decoded bodies convert to JSON
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.RawToString = true
+	h.Canonical = true
+	h.MapType = reflect.TypeFor[map[string]any]()
+	return h
+}()
+
+// encodeMsgPack encodes the JSON form of body, so IDs and times are strings as in JSON
+func encodeMsgPack(w http.ResponseWriter, body any) error {
+	v, err := jsonValue(body)
+	if err != nil {
+		return err
+	}
+	return codec.NewEncoder(w, msgpackHandle).Encode(v)
+}
+
+func decodeMsgPack(data []byte) ([]byte, error) {
+	var v any
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, ok := v.(map[string]any); !ok {
+		return nil, errors.New("expected a map")
+	}
+	return json.Marshal(v)
+}
+
+// jsonValue returns body as decoded JSON, with integers kept as int64
+func jsonValue(body any) (any, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return convertNumbers(v), nil
+}
+
+func convertNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, value := range v {
+			v[key] = convertNumbers(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = convertNumbers(value)
+		}
+	}
+	return v
+}
+
+// itemCSVHeader names the columns of an item list in CSV; metadata is a JSON object
+var itemCSVHeader = []string{"id", "name", "description", "status", "metadata", "created_at", "updated_at"}
+
+// encodeCSV writes a page of items as CSV, one row per item, with a column
+// for each field of a sparse fieldset. The total goes in the X-Total-Count
+// header since CSV has nowhere else for it.
+func encodeCSV(w http.ResponseWriter, body any) error {
+	list, ok := body.(*itemList)
+	if !ok {
+		return fmt.Errorf("no CSV form for %T", body)
+	}
+	if list.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.Itoa(*list.Total))
+	}
+
+	header := itemCSVHeader
+	if len(list.fields) > 0 {
+		header = slices.DeleteFunc(slices.Clone(itemCSVHeader), func(column string) bool {
+			return !slices.Contains(list.fields, column)
+		})
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	row := make([]string, len(header))
+	for _, view := range list.Items {
+		for i, column := range header {
+			cell, err := csvCell(view.item, column)
+			if err != nil {
+				return err
+			}
+			row[i] = cell
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell formats one column of an item for CSV
+func csvCell(item storage.Item, column string) (string, error) {
+	switch column {
+	case "id":
+		return item.ID.String(), nil
+	case "name":
+		return item.Name, nil
+	case "description":
+		if item.Description == nil {
+			return "", nil
+		}
+		return *item.Description, nil
+	case "status":
+		return string(item.Status), nil
+	case "metadata":
+		metadata, err := json.Marshal(item.Metadata)
+		return string(metadata), err
+	case "created_at":
+		return item.CreatedAt.UTC().Format(time.RFC3339Nano), nil
+	case "updated_at":
+		return item.UpdatedAt.UTC().Format(time.RFC3339Nano), nil
+	}
+	return "", fmt.Errorf("unknown CSV column %q", column)
+}
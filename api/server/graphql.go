@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/api/graphql"
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/joel-thompson/my-go-service/validation"
+)
+
+// graphqlSchema is the SDL served at /graphql
+//
+//go:embed schema.graphql
+var graphqlSchema string
+
+// newGraphQLHandler binds the schema to the API's resolvers
+func (a *API) newGraphQLHandler() (*graphql.Handler, error) {
+	return graphql.NewHandler(a.logger, graphqlSchema, &graphqlResolver{a: a}, graphql.Options{
+		Limits: a.graphqlLimits,
+		Prepare: func(ctx context.Context) context.Context {
+			return context.WithValue(ctx, loadersKey{}, a.newLoaders(ctx))
+		},
+	})
+}
+
+// loaders batch the storage lookups made while resolving nested item
+// fields, so listing 50 items with their links costs one query for all
+// links rather than 50
+type loaders struct {
+	items       *graphql.Loader[uuid.UUID, *storage.Item]
+	links       *graphql.Loader[uuid.UUID, []storage.ItemNeighbor]
+	attachments *graphql.Loader[uuid.UUID, []storage.Attachment]
+	history     *graphql.Loader[uuid.UUID, []storage.ItemStatusEvent]
+}
+
+type loadersKey struct{}
+
+func (a *API) newLoaders(ctx context.Context) *loaders {
+	return &loaders{
+		items: graphql.NewLoader(ctx, func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*storage.Item, error) {
+			items, err := a.store.GetItemsByID(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[uuid.UUID]*storage.Item, len(items))
+			for i := range items {
+				byID[items[i].ID] = &items[i]
+			}
+			return byID, nil
+		}),
+		links:       graphql.NewLoader(ctx, a.store.ListItemNeighborsByItem),
+		attachments: graphql.NewLoader(ctx, a.store.ListAttachmentsByItem),
+		history:     graphql.NewLoader(ctx, a.store.ListItemStatusEventsByItem),
+	}
+}
+
+// loadersFrom returns the loaders prepared for the current operation
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// Error codes reported in the extensions of resolver errors
+const (
+	codeBadRequest       = "BAD_REQUEST"
+	codeNotFound         = "NOT_FOUND"
+	codeConflict         = "CONFLICT"
+	codeValidationFailed = "VALIDATION_FAILED"
+	codeInternal         = "INTERNAL"
+)
+
+// graphqlError is a resolver error carrying a code, and field errors when
+// validation failed, in its extensions
+type graphqlError struct {
+	message string
+	code    string
+	fields  validation.Errors
+}
+
+func (e *graphqlError) Error() string {
+	return e.message
+}
+
+// Extensions implements the extensions hook of graphql-go
+func (e *graphqlError) Extensions() map[string]any {
+	ext := map[string]any{"code": e.code}
+	if len(e.fields) > 0 {
+		ext["fields"] = e.fields
+	}
+	return ext
+}
+
+func badRequest(format string, args ...any) error {
+	return &graphqlError{message: fmt.Sprintf(format, args...), code: codeBadRequest}
+}
+
+func notFound(message string) error {
+	return &graphqlError{message: message, code: codeNotFound}
+}
+
+// validationFailed reports the field errors in err, as writeValidationError does
+func validationFailed(err error) error {
+	var fields validation.Errors
+	if !errors.As(err, &fields) {
+		fields = validation.Errors{{Rule: validation.RuleInvalid, Message: err.Error()}}
+	}
+	return &graphqlError{message: "Validation failed", code: codeValidationFailed, fields: fields}
+}
+
+// itemError maps a storage error to a resolver error the way the REST
+// handlers map it to a status code, logging anything unexpected
+func (a *API) itemError(err error, failure string, attrs ...any) error {
+	var transitionErr *storage.TransitionError
+	var cycleErr *storage.LinkCycleError
+	var constraintErr *storage.ConstraintError
+	switch {
+	case errors.As(err, &transitionErr):
+		return &graphqlError{message: transitionErr.Error(), code: codeConflict}
+	case errors.As(err, &cycleErr):
+		return &graphqlError{message: cycleErr.Error(), code: codeConflict}
+	case errors.Is(err, storage.ErrLinkExists):
+		return &graphqlError{message: "Link already exists", code: codeConflict}
+	case errors.As(err, &constraintErr):
+		a.logger.Warn("Database rejected a value that passed validation",
+			"column", constraintErr.Column, "constraint", constraintErr.Constraint, "code", constraintErr.Code)
+		return validationFailed(validation.Errors{{
+			Field:   constraintErr.Column,
+			Rule:    validation.RuleInvalid,
+			Message: constraintErr.Message,
+		}})
+	case strings.Contains(err.Error(), "not found"):
+		return notFound("Item not found")
+	}
+	a.logger.Error(failure, append(attrs, "error", err)...)
+	return &graphqlError{message: failure, code: codeInternal}
+}
+
+// parseGraphQLID parses an ID argument, naming what it identifies in the error
+func parseGraphQLID(id string, what string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, badRequest("Invalid %s ID format", what)
+	}
+	return parsed, nil
+}
+
+// jsonScalar is the JSON scalar, used for item metadata
+type jsonScalar storage.Metadata
+
+// ImplementsGraphQLType maps jsonScalar to the JSON scalar
+func (jsonScalar) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+// UnmarshalGraphQL accepts a JSON object
+func (j *jsonScalar) UnmarshalGraphQL(input any) error {
+	object, ok := input.(map[string]any)
+	if !ok {
+		return errors.New("JSON value must be an object")
+	}
+	*j = object
+	return nil
+}
+
+// graphqlEnum converts a storage value such as "depends_on" to its enum value DEPENDS_ON
+func graphqlEnum[T ~string](value T) string {
+	return strings.ToUpper(string(value))
+}
+
+// storageEnum converts an enum value back to its storage form
+func storageEnum[T ~string](value string) T {
+	return T(strings.ToLower(value))
+}
@@ -0,0 +1,584 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// maxPageSize caps the first argument of connection fields
+const maxPageSize = 100
+
+// graphqlResolver implements the Query, Mutation and Subscription fields of
+// schema.graphql. Mutations apply the same validation as the REST handlers.
+type graphqlResolver struct {
+	a *API
+}
+
+type itemFilterInput struct {
+	Status *[]string
+	Where  *[]string
+}
+
+type pageArgs struct {
+	First  int32
+	After  *string
+	Filter *itemFilterInput
+}
+
+// Item resolves Query.item, returning null for a missing item
+func (r *graphqlResolver) Item(ctx context.Context, args struct{ ID graphqlgo.ID }) (*itemResolver, error) {
+	id, err := parseGraphQLID(string(args.ID), "item")
+	if err != nil {
+		return nil, err
+	}
+	item, err := r.a.items.GetItem(ctx, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil
+		}
+		return nil, r.a.itemError(err, "Failed to retrieve item", "id", id)
+	}
+	return r.a.itemResolver(loadersFrom(ctx), *item), nil
+}
+
+// Items resolves Query.items
+func (r *graphqlResolver) Items(ctx context.Context, args pageArgs) (*itemConnectionResolver, error) {
+	return r.itemPage(ctx, args, "")
+}
+
+// Search resolves Query.search
+func (r *graphqlResolver) Search(ctx context.Context, args struct {
+	Text   string
+	First  int32
+	After  *string
+	Filter *itemFilterInput
+}) (*itemConnectionResolver, error) {
+	text := strings.TrimSpace(args.Text)
+	if text == "" {
+		return nil, badRequest("text must not be empty")
+	}
+	return r.itemPage(ctx, pageArgs{First: args.First, After: args.After, Filter: args.Filter}, text)
+}
+
+// itemPage fetches one page of a connection; the total is only counted when selected
+func (r *graphqlResolver) itemPage(ctx context.Context, args pageArgs, search string) (*itemConnectionResolver, error) {
+	if args.First < 1 || args.First > maxPageSize {
+		return nil, badRequest("first must be between 1 and %d", maxPageSize)
+	}
+	req := storage.ItemPageRequest{First: int(args.First), Search: search}
+	if args.After != nil {
+		cursor, err := storage.ParseItemCursor(*args.After)
+		if err != nil {
+			return nil, badRequest("Invalid after cursor")
+		}
+		req.After = &cursor
+	}
+	if args.Filter != nil {
+		if args.Filter.Status != nil {
+			for _, status := range *args.Filter.Status {
+				req.Status = append(req.Status, string(storageEnum[storage.ItemStatus](status)))
+			}
+		}
+		if args.Filter.Where != nil {
+			for _, expr := range *args.Filter.Where {
+				filter, err := storage.ParseMetadataExpr(expr)
+				if err != nil {
+					return nil, badRequest("%s", err.Error())
+				}
+				req.MetadataFilters = append(req.MetadataFilters, filter)
+			}
+		}
+	}
+
+	page, err := r.a.items.ListItemsPage(ctx, req)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to retrieve items")
+	}
+	return &itemConnectionResolver{a: r.a, l: loadersFrom(ctx), req: req, page: page}, nil
+}
+
+type createItemInput struct {
+	Name        string
+	Description *string
+	Metadata    *jsonScalar
+}
+
+// CreateItem resolves Mutation.createItem
+func (r *graphqlResolver) CreateItem(ctx context.Context, args struct{ Input createItemInput }) (*itemResolver, error) {
+	req := storage.CreateItemRequest{Name: args.Input.Name, Description: args.Input.Description}
+	if args.Input.Metadata != nil {
+		req.Metadata = storage.Metadata(*args.Input.Metadata)
+	}
+	if err := r.a.validator.CreateItem(&req); err != nil {
+		return nil, validationFailed(err)
+	}
+	if err := r.a.metadataSchema.Validate(req.Metadata); err != nil {
+		return nil, badRequest("Invalid metadata: %s", err.Error())
+	}
+
+	item, err := r.a.items.CreateItem(ctx, req)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to create item")
+	}
+	return r.a.itemResolver(loadersFrom(ctx), *item), nil
+}
+
+type updateItemInput struct {
+	Name        *string
+	Description *string
+	Metadata    *jsonScalar
+}
+
+// UpdateItem resolves Mutation.updateItem
+func (r *graphqlResolver) UpdateItem(ctx context.Context, args struct {
+	ID    graphqlgo.ID
+	Input updateItemInput
+}) (*itemResolver, error) {
+	id, err := parseGraphQLID(string(args.ID), "item")
+	if err != nil {
+		return nil, err
+	}
+	req := storage.UpdateItemRequest{Name: args.Input.Name, Description: args.Input.Description}
+	if args.Input.Metadata != nil {
+		metadata := storage.Metadata(*args.Input.Metadata)
+		req.Metadata = &metadata
+	}
+	if req.Name == nil && req.Description == nil && req.Metadata == nil {
+		return nil, badRequest("At least one field (name, description or metadata) must be provided")
+	}
+	if err := r.a.validator.UpdateItem(&req); err != nil {
+		return nil, validationFailed(err)
+	}
+	if req.Metadata != nil {
+		if err := r.a.metadataSchema.Validate(*req.Metadata); err != nil {
+			return nil, badRequest("Invalid metadata: %s", err.Error())
+		}
+	}
+
+	item, err := r.a.items.UpdateItem(ctx, id, req)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to update item", "id", id)
+	}
+	return r.a.itemResolver(loadersFrom(ctx), *item), nil
+}
+
+// DeleteItem resolves Mutation.deleteItem, returning the deleted item
+func (r *graphqlResolver) DeleteItem(ctx context.Context, args struct{ ID graphqlgo.ID }) (*itemResolver, error) {
+	id, err := parseGraphQLID(string(args.ID), "item")
+	if err != nil {
+		return nil, err
+	}
+
+	item, blobKeys, err := r.a.items.DeleteItem(ctx, id)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to delete item", "id", id)
+	}
+	r.a.deleteItemBlobs(ctx, blobKeys)
+	return r.a.itemResolver(loadersFrom(ctx), *item), nil
+}
+
+// PublishItem resolves Mutation.publishItem
+func (r *graphqlResolver) PublishItem(ctx context.Context, args struct{ ID graphqlgo.ID }) (*itemResolver, error) {
+	return r.transitionItem(ctx, args.ID, storage.TransitionPublish)
+}
+
+// ArchiveItem resolves Mutation.archiveItem
+func (r *graphqlResolver) ArchiveItem(ctx context.Context, args struct{ ID graphqlgo.ID }) (*itemResolver, error) {
+	return r.transitionItem(ctx, args.ID, storage.TransitionArchive)
+}
+
+// UnarchiveItem resolves Mutation.unarchiveItem
+func (r *graphqlResolver) UnarchiveItem(ctx context.Context, args struct{ ID graphqlgo.ID }) (*itemResolver, error) {
+	return r.transitionItem(ctx, args.ID, storage.TransitionUnarchive)
+}
+
+func (r *graphqlResolver) transitionItem(ctx context.Context, idArg graphqlgo.ID, transition storage.Transition) (*itemResolver, error) {
+	id, err := parseGraphQLID(string(idArg), "item")
+	if err != nil {
+		return nil, err
+	}
+	item, err := r.a.items.TransitionItem(ctx, id, transition)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to "+string(transition)+" item", "id", id, "transition", transition)
+	}
+	r.a.logger.Info("Item transitioned", "id", id, "transition", transition, "status", item.Status)
+	return r.a.itemResolver(loadersFrom(ctx), *item), nil
+}
+
+type linkItemsInput struct {
+	TargetID graphqlgo.ID
+	Relation string
+}
+
+// LinkItems resolves Mutation.linkItems
+func (r *graphqlResolver) LinkItems(ctx context.Context, args struct {
+	ID    graphqlgo.ID
+	Input linkItemsInput
+}) (*itemLinkResolver, error) {
+	id, err := parseGraphQLID(string(args.ID), "item")
+	if err != nil {
+		return nil, err
+	}
+	targetID, err := parseGraphQLID(string(args.Input.TargetID), "target item")
+	if err != nil {
+		return nil, err
+	}
+	if targetID == id {
+		return nil, badRequest("An item cannot be linked to itself")
+	}
+
+	req := storage.CreateLinkRequest{TargetID: targetID, Relation: storageEnum[storage.LinkRelation](args.Input.Relation)}
+	link, err := r.a.store.CreateItemLink(ctx, id, req)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to create link", "id", id, "target_id", targetID)
+	}
+	return &itemLinkResolver{a: r.a, l: loadersFrom(ctx), link: *link}, nil
+}
+
+// UnlinkItems resolves Mutation.unlinkItems, returning the removed link
+func (r *graphqlResolver) UnlinkItems(ctx context.Context, args struct {
+	ID     graphqlgo.ID
+	LinkID graphqlgo.ID
+}) (*itemLinkResolver, error) {
+	id, err := parseGraphQLID(string(args.ID), "item")
+	if err != nil {
+		return nil, err
+	}
+	linkID, err := parseGraphQLID(string(args.LinkID), "link")
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := r.a.store.DeleteItemLink(ctx, id, linkID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, notFound("Link not found")
+		}
+		return nil, r.a.itemError(err, "Failed to delete link", "id", id, "link_id", linkID)
+	}
+	return &itemLinkResolver{a: r.a, l: loadersFrom(ctx), link: *link}, nil
+}
+
+// ItemChanged resolves Subscription.itemChanged from the event broker. Each
+// change gets its own loaders so nested fields are read as of that change.
+func (r *graphqlResolver) ItemChanged(ctx context.Context, args struct {
+	ID  *graphqlgo.ID
+	Tag *string
+}) (<-chan *itemChangeResolver, error) {
+	var filter storage.ItemEventFilter
+	if args.ID != nil {
+		id, err := parseGraphQLID(string(*args.ID), "item")
+		if err != nil {
+			return nil, err
+		}
+		filter.ItemID = &id
+	}
+	if args.Tag != nil {
+		filter.Tag = *args.Tag
+	}
+
+	sub := r.a.events.Subscribe(filter, eventBuffer)
+	changes := make(chan *itemChangeResolver)
+	go func() {
+		defer close(changes)
+		defer r.a.events.Unsubscribe(sub)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-sub.Events():
+				if !ok {
+					// Dropped for falling behind, or the server is shutting down
+					return
+				}
+				change := &itemChangeResolver{event: event, item: r.a.itemResolver(r.a.newLoaders(ctx), event.Item)}
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// itemResolver resolves Item, loading nested fields through l
+type itemResolver struct {
+	a    *API
+	l    *loaders
+	item storage.Item
+}
+
+func (a *API) itemResolver(l *loaders, item storage.Item) *itemResolver {
+	return &itemResolver{a: a, l: l, item: item}
+}
+
+func (r *itemResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.item.ID.String())
+}
+
+func (r *itemResolver) Name() string {
+	return r.item.Name
+}
+
+func (r *itemResolver) Description() *string {
+	return r.item.Description
+}
+
+func (r *itemResolver) Metadata() jsonScalar {
+	if r.item.Metadata == nil {
+		return jsonScalar{}
+	}
+	return jsonScalar(r.item.Metadata)
+}
+
+func (r *itemResolver) Status() string {
+	return graphqlEnum(r.item.Status)
+}
+
+func (r *itemResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.item.CreatedAt}
+}
+
+func (r *itemResolver) UpdatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.item.UpdatedAt}
+}
+
+func (r *itemResolver) Links(ctx context.Context) ([]*linkedItemResolver, error) {
+	neighbors, err := r.l.links.Load(ctx, r.item.ID)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to retrieve links", "id", r.item.ID)
+	}
+	links := make([]*linkedItemResolver, len(neighbors))
+	for i, neighbor := range neighbors {
+		links[i] = &linkedItemResolver{a: r.a, l: r.l, neighbor: neighbor}
+	}
+	return links, nil
+}
+
+func (r *itemResolver) Attachments(ctx context.Context) ([]*attachmentResolver, error) {
+	attachments, err := r.l.attachments.Load(ctx, r.item.ID)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to retrieve attachments", "id", r.item.ID)
+	}
+	resolvers := make([]*attachmentResolver, len(attachments))
+	for i, attachment := range attachments {
+		resolvers[i] = &attachmentResolver{attachment: attachment}
+	}
+	return resolvers, nil
+}
+
+func (r *itemResolver) History(ctx context.Context) ([]*statusChangeResolver, error) {
+	events, err := r.l.history.Load(ctx, r.item.ID)
+	if err != nil {
+		return nil, r.a.itemError(err, "Failed to retrieve item history", "id", r.item.ID)
+	}
+	changes := make([]*statusChangeResolver, len(events))
+	for i, event := range events {
+		changes[i] = &statusChangeResolver{event: event}
+	}
+	return changes, nil
+}
+
+// loadItem resolves a related item through the item loader, or null when it no longer exists
+func (a *API) loadItem(ctx context.Context, l *loaders, id uuid.UUID) (*itemResolver, error) {
+	item, err := l.items.Load(ctx, id)
+	if err != nil {
+		return nil, a.itemError(err, "Failed to retrieve item", "id", id)
+	}
+	if item == nil {
+		return nil, nil
+	}
+	return a.itemResolver(l, *item), nil
+}
+
+type linkedItemResolver struct {
+	a        *API
+	l        *loaders
+	neighbor storage.ItemNeighbor
+}
+
+func (r *linkedItemResolver) LinkID() graphqlgo.ID {
+	return graphqlgo.ID(r.neighbor.LinkID.String())
+}
+
+func (r *linkedItemResolver) Relation() string {
+	return graphqlEnum(r.neighbor.Relation)
+}
+
+func (r *linkedItemResolver) Direction() string {
+	return graphqlEnum(r.neighbor.Direction)
+}
+
+func (r *linkedItemResolver) LinkedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.neighbor.LinkedAt}
+}
+
+func (r *linkedItemResolver) Item(ctx context.Context) (*itemResolver, error) {
+	return r.a.loadItem(ctx, r.l, r.neighbor.ItemID)
+}
+
+type itemLinkResolver struct {
+	a    *API
+	l    *loaders
+	link storage.ItemLink
+}
+
+func (r *itemLinkResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.link.ID.String())
+}
+
+func (r *itemLinkResolver) Relation() string {
+	return graphqlEnum(r.link.Relation)
+}
+
+func (r *itemLinkResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.link.CreatedAt}
+}
+
+func (r *itemLinkResolver) Source(ctx context.Context) (*itemResolver, error) {
+	return r.a.loadItem(ctx, r.l, r.link.SourceID)
+}
+
+func (r *itemLinkResolver) Target(ctx context.Context) (*itemResolver, error) {
+	return r.a.loadItem(ctx, r.l, r.link.TargetID)
+}
+
+type attachmentResolver struct {
+	attachment storage.Attachment
+}
+
+func (r *attachmentResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.attachment.ID.String())
+}
+
+func (r *attachmentResolver) Filename() string {
+	return r.attachment.Filename
+}
+
+func (r *attachmentResolver) ContentType() string {
+	return r.attachment.ContentType
+}
+
+func (r *attachmentResolver) Size() float64 {
+	return float64(r.attachment.SizeBytes)
+}
+
+func (r *attachmentResolver) SHA256() string {
+	return r.attachment.SHA256
+}
+
+func (r *attachmentResolver) CreatedAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.attachment.CreatedAt}
+}
+
+func (r *attachmentResolver) DownloadPath() string {
+	latest := apiVersions[len(apiVersions)-1]
+	return fmt.Sprintf("%s/items/%s/attachments/%s", latest.Prefix(), r.attachment.ItemID, r.attachment.ID)
+}
+
+type statusChangeResolver struct {
+	event storage.ItemStatusEvent
+}
+
+func (r *statusChangeResolver) Transition() string {
+	return graphqlEnum(r.event.Transition)
+}
+
+func (r *statusChangeResolver) From() string {
+	return graphqlEnum(r.event.FromStatus)
+}
+
+func (r *statusChangeResolver) To() string {
+	return graphqlEnum(r.event.ToStatus)
+}
+
+func (r *statusChangeResolver) OccurredAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.event.OccurredAt}
+}
+
+type itemConnectionResolver struct {
+	a    *API
+	l    *loaders
+	req  storage.ItemPageRequest
+	page *storage.ItemPage
+}
+
+func (r *itemConnectionResolver) Edges() []*itemEdgeResolver {
+	edges := make([]*itemEdgeResolver, len(r.page.Items))
+	for i, item := range r.page.Items {
+		edges[i] = &itemEdgeResolver{node: r.a.itemResolver(r.l, item)}
+	}
+	return edges
+}
+
+func (r *itemConnectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNextPage: r.page.HasNextPage}
+	if n := len(r.page.Items); n > 0 {
+		cursor := storage.CursorFor(r.page.Items[n-1]).String()
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+func (r *itemConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := r.a.store.CountItemsPage(ctx, r.req)
+	if err != nil {
+		return 0, r.a.itemError(err, "Failed to count items")
+	}
+	return int32(count), nil
+}
+
+type itemEdgeResolver struct {
+	node *itemResolver
+}
+
+func (r *itemEdgeResolver) Cursor() string {
+	return storage.CursorFor(r.node.item).String()
+}
+
+func (r *itemEdgeResolver) Node() *itemResolver {
+	return r.node
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+type itemChangeResolver struct {
+	event storage.ItemEvent
+	item  *itemResolver
+}
+
+func (r *itemChangeResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(strconv.FormatInt(r.event.ID, 10))
+}
+
+func (r *itemChangeResolver) Type() string {
+	return graphqlEnum(r.event.Type)
+}
+
+func (r *itemChangeResolver) Item() *itemResolver {
+	return r.item
+}
+
+func (r *itemChangeResolver) OccurredAt() graphqlgo.Time {
+	return graphqlgo.Time{Time: r.event.OccurredAt}
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	itemsv1 "github.com/joel-thompson/my-go-service/api/proto/items/v1"
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/joel-thompson/my-go-service/validation"
+)
+
+// requestIDKey is the metadata key that carries a call's request id, in both directions
+const requestIDKey = "x-request-id"
+
+// GRPCServer serves ItemsService alongside gRPC health checking and reflection
+type GRPCServer struct {
+	*grpc.Server
+	health *health.Server
+}
+
+// NewGRPCServer builds the gRPC server. Calls are authenticated against
+// apiKeys, given as "authorization: Bearer <key>" or "x-api-key: <key>";
+// with no keys configured every call is accepted.
+func (a *API) NewGRPCServer(apiKeys []string) *GRPCServer {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(a.grpcRequestIDUnary, a.grpcLoggingUnary, grpcAuthUnary(apiKeys)),
+		grpc.ChainStreamInterceptor(a.grpcRequestIDStream, a.grpcLoggingStream, grpcAuthStream(apiKeys)),
+	)
+	itemsv1.RegisterItemsServiceServer(srv, &itemsService{a: a})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(itemsv1.ItemsService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthServer)
+	reflection.Register(srv)
+
+	return &GRPCServer{Server: srv, health: healthServer}
+}
+
+// Shutdown reports NOT_SERVING to health checks and waits for in-flight
+// calls to finish, cancelling any still running when ctx is done
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+type requestIDCtxKey struct{}
+
+// grpcRequestID returns the request id assigned to the current call
+func grpcRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// withRequestID takes the caller's x-request-id, or generates one, and
+// echoes it back in the response header
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 && values[0] != "" {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = uuid.NewString()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return context.WithValue(ctx, requestIDCtxKey{}, id)
+}
+
+func (a *API) grpcRequestIDUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+func (a *API) grpcRequestIDStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func (a *API) grpcLoggingUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	a.logGRPCCall(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (a *API) grpcLoggingStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	a.logGRPCCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func (a *API) logGRPCCall(ctx context.Context, method string, start time.Time, err error) {
+	a.logger.Info("gRPC request",
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("request_id", grpcRequestID(ctx)),
+	)
+}
+
+// grpcAuthExempt reports whether a method may be called without an API key;
+// load balancers and tooling use health checks and reflection unauthenticated
+func grpcAuthExempt(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.") || strings.HasPrefix(method, "/grpc.reflection.")
+}
+
+// grpcAuthorize checks the call's API key against keys in constant time
+func grpcAuthorize(ctx context.Context, keys []string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if values := md.Get("authorization"); len(values) > 0 {
+		scheme, token, ok := strings.Cut(values[0], " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return status.Error(codes.Unauthenticated, "authorization must use the Bearer scheme")
+		}
+		key = token
+	} else if values := md.Get("x-api-key"); len(values) > 0 {
+		key = values[0]
+	}
+	if key == "" {
+		return status.Error(codes.Unauthenticated, "missing API key")
+	}
+
+	if !apiKeyValid(key, keys) {
+		return status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	return nil
+}
+
+// apiKeyValid reports whether key is one of keys. Every key is compared in
+// constant time so the response time does not reveal how much of a key matched.
+func apiKeyValid(key string, keys []string) bool {
+	valid := 0
+	for _, k := range keys {
+		valid |= subtle.ConstantTimeCompare([]byte(key), []byte(k))
+	}
+	return valid == 1
+}
+
+func grpcAuthUnary(keys []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if len(keys) > 0 && !grpcAuthExempt(info.FullMethod) {
+			if err := grpcAuthorize(ctx, keys); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func grpcAuthStream(keys []string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if len(keys) > 0 && !grpcAuthExempt(info.FullMethod) {
+			if err := grpcAuthorize(ss.Context(), keys); err != nil {
+				return err
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// grpcValidationFailed reports field errors as InvalidArgument with a
+// BadRequest detail, the gRPC counterpart of writeValidationError
+func grpcValidationFailed(err error) error {
+	var fields validation.Errors
+	if !errors.As(err, &fields) {
+		fields = validation.Errors{{Rule: validation.RuleInvalid, Message: err.Error()}}
+	}
+	st := status.New(codes.InvalidArgument, "Validation failed")
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+	for i, field := range fields {
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message, Reason: field.Rule}
+	}
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// grpcItemError maps a storage error to a status the way the REST handlers
+// map it to a status code, logging anything unexpected
+func (a *API) grpcItemError(err error, failure string, attrs ...any) error {
+	var transitionErr *storage.TransitionError
+	var constraintErr *storage.ConstraintError
+	switch {
+	case errors.As(err, &transitionErr):
+		return status.Error(codes.FailedPrecondition, transitionErr.Error())
+	case errors.As(err, &constraintErr):
+		a.logger.Warn("Database rejected a value that passed validation",
+			"column", constraintErr.Column, "constraint", constraintErr.Constraint, "code", constraintErr.Code)
+		return grpcValidationFailed(validation.Errors{{
+			Field:   constraintErr.Column,
+			Rule:    validation.RuleInvalid,
+			Message: constraintErr.Message,
+		}})
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "Request cancelled")
+	case strings.Contains(err.Error(), "not found"):
+		return status.Error(codes.NotFound, "Item not found")
+	}
+	a.logger.Error(failure, append(attrs, "error", err)...)
+	return status.Error(codes.Internal, failure)
+}
+
+// parseGRPCID parses an ID field, naming what it identifies in the error
+func parseGRPCID(id string, what string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "Invalid %s ID format", what)
+	}
+	return parsed, nil
+}
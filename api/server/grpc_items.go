@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	itemsv1 "github.com/joel-thompson/my-go-service/api/proto/items/v1"
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// defaultStreamPageSize is how many items ListItems reads per query when the request leaves it unset
+const defaultStreamPageSize = 50
+
+// itemsService implements ItemsService on the same stores, validation and
+// event broker as the REST handlers
+type itemsService struct {
+	itemsv1.UnimplementedItemsServiceServer
+	a *API
+}
+
+// CreateItem implements ItemsService.CreateItem
+func (s *itemsService) CreateItem(ctx context.Context, in *itemsv1.CreateItemRequest) (*itemsv1.Item, error) {
+	req := storage.CreateItemRequest{Name: in.GetName(), Description: in.Description}
+	if in.GetMetadata() != nil {
+		req.Metadata = in.GetMetadata().AsMap()
+	}
+	if err := s.a.validator.CreateItem(&req); err != nil {
+		return nil, grpcValidationFailed(err)
+	}
+	if err := s.a.metadataSchema.Validate(req.Metadata); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid metadata: %s", err.Error())
+	}
+
+	item, err := s.a.items.CreateItem(ctx, req)
+	if err != nil {
+		return nil, s.a.grpcItemError(err, "Failed to create item")
+	}
+	return itemToProto(*item)
+}
+
+// GetItem implements ItemsService.GetItem
+func (s *itemsService) GetItem(ctx context.Context, in *itemsv1.GetItemRequest) (*itemsv1.Item, error) {
+	id, err := parseGRPCID(in.GetId(), "item")
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.a.items.GetItem(ctx, id)
+	if err != nil {
+		return nil, s.a.grpcItemError(err, "Failed to retrieve item", "id", id)
+	}
+	return itemToProto(*item)
+}
+
+// ListItems implements ItemsService.ListItems, walking cursor pages until
+// every matching item has been sent
+func (s *itemsService) ListItems(in *itemsv1.ListItemsRequest, stream grpc.ServerStreamingServer[itemsv1.ListItemsResponse]) error {
+	ctx := stream.Context()
+
+	pageSize := int(in.GetPageSize())
+	if pageSize == 0 {
+		pageSize = defaultStreamPageSize
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		return status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", maxPageSize)
+	}
+	req := storage.ItemPageRequest{First: pageSize, Search: strings.TrimSpace(in.GetSearch())}
+	if in.GetAfter() != "" {
+		cursor, err := storage.ParseItemCursor(in.GetAfter())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "Invalid after cursor")
+		}
+		req.After = &cursor
+	}
+	for _, st := range in.GetStatus() {
+		itemStatus, ok := storageStatus(st)
+		if !ok {
+			return status.Errorf(codes.InvalidArgument, "Invalid status %s", st)
+		}
+		req.Status = append(req.Status, string(itemStatus))
+	}
+	for _, expr := range in.GetWhere() {
+		filter, err := storage.ParseMetadataExpr(expr)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		req.MetadataFilters = append(req.MetadataFilters, filter)
+	}
+
+	for {
+		page, err := s.a.items.ListItemsPage(ctx, req)
+		if err != nil {
+			return s.a.grpcItemError(err, "Failed to retrieve items")
+		}
+		for _, item := range page.Items {
+			msg, err := itemToProto(item)
+			if err != nil {
+				return err
+			}
+			cursor := storage.CursorFor(item)
+			if err := stream.Send(&itemsv1.ListItemsResponse{Item: msg, Cursor: cursor.String()}); err != nil {
+				return err
+			}
+			req.After = &cursor
+		}
+		if !page.HasNextPage {
+			return nil
+		}
+	}
+}
+
+// UpdateItem implements ItemsService.UpdateItem
+func (s *itemsService) UpdateItem(ctx context.Context, in *itemsv1.UpdateItemRequest) (*itemsv1.Item, error) {
+	id, err := parseGRPCID(in.GetId(), "item")
+	if err != nil {
+		return nil, err
+	}
+	req := storage.UpdateItemRequest{Name: in.Name, Description: in.Description}
+	if in.GetMetadata() != nil {
+		metadata := storage.Metadata(in.GetMetadata().AsMap())
+		req.Metadata = &metadata
+	}
+	if req.Name == nil && req.Description == nil && req.Metadata == nil {
+		return nil, status.Error(codes.InvalidArgument, "At least one field (name, description or metadata) must be provided")
+	}
+	if err := s.a.validator.UpdateItem(&req); err != nil {
+		return nil, grpcValidationFailed(err)
+	}
+	if req.Metadata != nil {
+		if err := s.a.metadataSchema.Validate(*req.Metadata); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid metadata: %s", err.Error())
+		}
+	}
+
+	item, err := s.a.items.UpdateItem(ctx, id, req)
+	if err != nil {
+		return nil, s.a.grpcItemError(err, "Failed to update item", "id", id)
+	}
+	return itemToProto(*item)
+}
+
+// DeleteItem implements ItemsService.DeleteItem
+func (s *itemsService) DeleteItem(ctx context.Context, in *itemsv1.DeleteItemRequest) (*itemsv1.Item, error) {
+	id, err := parseGRPCID(in.GetId(), "item")
+	if err != nil {
+		return nil, err
+	}
+
+	item, blobKeys, err := s.a.items.DeleteItem(ctx, id)
+	if err != nil {
+		return nil, s.a.grpcItemError(err, "Failed to delete item", "id", id)
+	}
+	s.a.deleteItemBlobs(ctx, blobKeys)
+	return itemToProto(*item)
+}
+
+// WatchItems implements ItemsService.WatchItems from the event broker. The
+// stream ends when the client falls too far behind or the server shuts down.
+func (s *itemsService) WatchItems(in *itemsv1.WatchItemsRequest, stream grpc.ServerStreamingServer[itemsv1.ItemEvent]) error {
+	var filter storage.ItemEventFilter
+	if in.GetItemId() != "" {
+		id, err := parseGRPCID(in.GetItemId(), "item")
+		if err != nil {
+			return err
+		}
+		filter.ItemID = &id
+	}
+	filter.Tag = in.GetTag()
+
+	sub := s.a.events.Subscribe(filter, eventBuffer)
+	defer s.a.events.Unsubscribe(sub)
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				return status.Error(codes.Unavailable, "Event stream closed; reconnect to resume")
+			}
+			item, err := itemToProto(event.Item)
+			if err != nil {
+				return err
+			}
+			msg := &itemsv1.ItemEvent{
+				Id:        event.ID,
+				Type:      itemsv1.ItemEvent_Type(itemsv1.ItemEvent_Type_value["TYPE_"+strings.ToUpper(string(event.Type))]),
+				Item:      item,
+				OccurTime: timestamppb.New(event.OccurredAt),
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// itemToProto converts a stored item to its protobuf message
+func itemToProto(item storage.Item) (*itemsv1.Item, error) {
+	metadata, err := structpb.NewStruct(item.Metadata)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to encode item metadata: %v", err)
+	}
+	return &itemsv1.Item{
+		Id:          item.ID.String(),
+		Name:        item.Name,
+		Description: item.Description,
+		Metadata:    metadata,
+		Status:      itemsv1.ItemStatus(itemsv1.ItemStatus_value["ITEM_STATUS_"+strings.ToUpper(string(item.Status))]),
+		CreateTime:  timestamppb.New(item.CreatedAt),
+		UpdateTime:  timestamppb.New(item.UpdatedAt),
+	}, nil
+}
+
+// storageStatus converts ITEM_STATUS_DRAFT to draft, rejecting values with no storage form
+func storageStatus(st itemsv1.ItemStatus) (storage.ItemStatus, bool) {
+	itemStatus := storage.ItemStatus(strings.ToLower(strings.TrimPrefix(st.String(), "ITEM_STATUS_")))
+	return itemStatus, itemStatus.Valid()
+}
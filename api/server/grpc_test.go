@@ -0,0 +1,455 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	itemsv1 "github.com/joel-thompson/my-go-service/api/proto/items/v1"
+	"github.com/joel-thompson/my-go-service/events"
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const testAPIKey = "grpc-test-key"
+
+// memoryItems is an ItemStore over a slice kept newest first, the order
+// ListItemsPage walks
+type memoryItems struct {
+	mu       sync.Mutex
+	items    []storage.Item
+	pages    int
+	versions int
+}
+
+func newMemoryItems(n int) *memoryItems {
+	m := &memoryItems{}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		m.items = append(m.items, storage.Item{
+			ID:        uuid.New(),
+			Name:      fmt.Sprintf("item %d", i),
+			Status:    storage.StatusDraft,
+			Metadata:  storage.Metadata{"index": float64(i)},
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	slices.Reverse(m.items)
+	return m
+}
+
+func (m *memoryItems) GetItem(ctx context.Context, id uuid.UUID) (*storage.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range m.items {
+		if item.ID == id {
+			return &item, nil
+		}
+	}
+	return nil, fmt.Errorf("item not found")
+}
+
+func (m *memoryItems) ListItemsPage(ctx context.Context, req storage.ItemPageRequest) (*storage.ItemPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pages++
+
+	start := 0
+	if req.After != nil {
+		start = slices.IndexFunc(m.items, func(item storage.Item) bool { return item.ID == req.After.ID }) + 1
+	}
+	end := min(start+req.First, len(m.items))
+	return &storage.ItemPage{
+		Items:       slices.Clone(m.items[start:end]),
+		HasNextPage: end < len(m.items),
+	}, nil
+}
+
+var errNotInFake = errors.New("not implemented by memoryItems")
+
+func (m *memoryItems) CreateItem(ctx context.Context, req storage.CreateItemRequest) (*storage.Item, error) {
+	return nil, errNotInFake
+}
+
+func (m *memoryItems) ListItems(ctx context.Context, req storage.ListItemsRequest) (*storage.ListItemsResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	start := min(req.Offset, len(m.items))
+	end := min(start+limit, len(m.items))
+	response := &storage.ListItemsResponse{
+		Items:   slices.Clone(m.items[start:end]),
+		Limit:   limit,
+		Offset:  req.Offset,
+		HasMore: end < len(m.items),
+	}
+	if req.CountTotal() {
+		version := m.version()
+		response.Total = &version.Count
+		response.Version = version
+	}
+	return response, nil
+}
+
+func (m *memoryItems) ListItemsVersion(ctx context.Context, req storage.ListItemsRequest) (*storage.ItemsVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.versions++
+	return m.version(), nil
+}
+
+// version summarises every item; callers hold mu
+func (m *memoryItems) version() *storage.ItemsVersion {
+	version := &storage.ItemsVersion{Count: len(m.items)}
+	for _, item := range m.items {
+		if version.LastModified == nil || item.UpdatedAt.After(*version.LastModified) {
+			version.LastModified = &item.UpdatedAt
+		}
+	}
+	return version
+}
+
+func (m *memoryItems) UpdateItem(ctx context.Context, id uuid.UUID, req storage.UpdateItemRequest) (*storage.Item, error) {
+	return nil, errNotInFake
+}
+
+func (m *memoryItems) DeleteItem(ctx context.Context, id uuid.UUID) (*storage.Item, []string, error) {
+	return nil, nil, errNotInFake
+}
+
+func (m *memoryItems) TransitionItem(ctx context.Context, id uuid.UUID, transition storage.Transition) (*storage.Item, error) {
+	return nil, errNotInFake
+}
+
+// grpcHarness is a GRPCServer on an in-memory listener with a client connected to it
+type grpcHarness struct {
+	items  *memoryItems
+	broker *events.Broker
+	conn   *grpc.ClientConn
+	client itemsv1.ItemsServiceClient
+}
+
+func newGRPCHarness(t *testing.T, apiKeys ...string) *grpcHarness {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	broker := events.NewBroker(logger, nil, "")
+	a := New(logger, nil, Options{Events: broker})
+	items := newMemoryItems(5)
+	a.items = items
+
+	listener := bufconn.Listen(1 << 20)
+	srv := a.NewGRPCServer(apiKeys)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &grpcHarness{
+		items:  items,
+		broker: broker,
+		conn:   conn,
+		client: itemsv1.NewItemsServiceClient(conn),
+	}
+}
+
+func withMetadata(kv ...string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), kv...)
+}
+
+func TestGRPCAuth(t *testing.T) {
+	h := newGRPCHarness(t, testAPIKey, "second-key")
+	id := h.items.items[0].ID.String()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"missing key", context.Background(), codes.Unauthenticated},
+		{"wrong key", withMetadata("x-api-key", "guess"), codes.Unauthenticated},
+		{"wrong bearer key", withMetadata("authorization", "Bearer guess"), codes.Unauthenticated},
+		{"other scheme", withMetadata("authorization", "Basic "+testAPIKey), codes.Unauthenticated},
+		{"bearer key", withMetadata("authorization", "Bearer "+testAPIKey), codes.OK},
+		{"lowercase bearer", withMetadata("authorization", "bearer "+testAPIKey), codes.OK},
+		{"x-api-key", withMetadata("x-api-key", testAPIKey), codes.OK},
+		{"second key", withMetadata("x-api-key", "second-key"), codes.OK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := h.client.GetItem(tc.ctx, &itemsv1.GetItemRequest{Id: id})
+			if got := status.Code(err); got != tc.want {
+				t.Fatalf("GetItem: code = %s, want %s (err %v)", got, tc.want, err)
+			}
+
+			// Streams are checked by the stream interceptor
+			stream, err := h.client.ListItems(tc.ctx, &itemsv1.ListItemsRequest{PageSize: 10})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if got := status.Code(err); got != tc.want {
+				t.Fatalf("ListItems: code = %s, want %s (err %v)", got, tc.want, err)
+			}
+		})
+	}
+
+	t.Run("health checks are exempt", func(t *testing.T) {
+		resp, err := healthpb.NewHealthClient(h.conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+			Service: itemsv1.ItemsService_ServiceDesc.ServiceName,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("health = %s, want SERVING", resp.GetStatus())
+		}
+	})
+
+	t.Run("no keys configured", func(t *testing.T) {
+		open := newGRPCHarness(t)
+		if _, err := open.client.GetItem(context.Background(), &itemsv1.GetItemRequest{Id: open.items.items[0].ID.String()}); err != nil {
+			t.Fatalf("GetItem without keys configured: %v", err)
+		}
+	})
+}
+
+func TestGRPCRequestID(t *testing.T) {
+	h := newGRPCHarness(t, testAPIKey)
+	id := h.items.items[0].ID.String()
+
+	var header metadata.MD
+	ctx := withMetadata("x-api-key", testAPIKey, "x-request-id", "caller-chosen-id")
+	if _, err := h.client.GetItem(ctx, &itemsv1.GetItemRequest{Id: id}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "caller-chosen-id" {
+		t.Errorf("echoed x-request-id = %v, want [caller-chosen-id]", got)
+	}
+
+	// Without one, an id is generated; failed calls carry it too
+	header = nil
+	_, err := h.client.GetItem(withMetadata("x-api-key", testAPIKey), &itemsv1.GetItemRequest{Id: uuid.NewString()}, grpc.Header(&header))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("GetItem unknown id: %v, want NotFound", err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || uuid.Validate(got[0]) != nil {
+		t.Errorf("generated x-request-id = %v, want a UUID", got)
+	}
+
+	// Rejected calls still get one, since the request id interceptor runs first
+	header = nil
+	_, err = h.client.GetItem(context.Background(), &itemsv1.GetItemRequest{Id: id}, grpc.Header(&header))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("GetItem without a key: %v, want Unauthenticated", err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 {
+		t.Errorf("rejected call x-request-id = %v, want one id", got)
+	}
+
+	stream, err := h.client.ListItems(withMetadata("x-api-key", testAPIKey, "x-request-id", "stream-id"), &itemsv1.ListItemsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamHeader, err := stream.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := streamHeader.Get("x-request-id"); len(got) != 1 || got[0] != "stream-id" {
+		t.Errorf("stream x-request-id = %v, want [stream-id]", got)
+	}
+}
+
+func TestGRPCGetItem(t *testing.T) {
+	h := newGRPCHarness(t)
+	want := h.items.items[2]
+
+	item, err := h.client.GetItem(context.Background(), &itemsv1.GetItemRequest{Id: want.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.GetId() != want.ID.String() || item.GetName() != want.Name {
+		t.Errorf("GetItem = %s %q, want %s %q", item.GetId(), item.GetName(), want.ID, want.Name)
+	}
+	if item.GetStatus() != itemsv1.ItemStatus_ITEM_STATUS_DRAFT {
+		t.Errorf("status = %s, want ITEM_STATUS_DRAFT", item.GetStatus())
+	}
+	if got := item.GetMetadata().AsMap()["index"]; got != want.Metadata["index"] {
+		t.Errorf("metadata index = %v, want %v", got, want.Metadata["index"])
+	}
+	if !item.GetCreateTime().AsTime().Equal(want.CreatedAt) {
+		t.Errorf("create_time = %s, want %s", item.GetCreateTime().AsTime(), want.CreatedAt)
+	}
+
+	for _, tc := range []struct {
+		id   string
+		want codes.Code
+	}{
+		{uuid.NewString(), codes.NotFound},
+		{"not-a-uuid", codes.InvalidArgument},
+	} {
+		if _, err := h.client.GetItem(context.Background(), &itemsv1.GetItemRequest{Id: tc.id}); status.Code(err) != tc.want {
+			t.Errorf("GetItem(%q): %v, want %s", tc.id, err, tc.want)
+		}
+	}
+}
+
+func TestGRPCListItems(t *testing.T) {
+	h := newGRPCHarness(t)
+
+	stream, err := h.client.ListItems(context.Background(), &itemsv1.ListItemsRequest{PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []*itemsv1.ListItemsResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, resp)
+	}
+
+	if len(got) != len(h.items.items) {
+		t.Fatalf("received %d items, want %d", len(got), len(h.items.items))
+	}
+	for i, resp := range got {
+		want := h.items.items[i]
+		if resp.GetItem().GetId() != want.ID.String() {
+			t.Errorf("item %d = %s, want %s (newest first)", i, resp.GetItem().GetId(), want.ID)
+		}
+		if resp.GetCursor() != storage.CursorFor(want).String() {
+			t.Errorf("item %d cursor = %s, want %s", i, resp.GetCursor(), storage.CursorFor(want))
+		}
+	}
+	if h.items.pages != 3 {
+		t.Errorf("read %d pages of 2 for 5 items, want 3", h.items.pages)
+	}
+
+	// Resuming from a cursor skips what was already received
+	stream, err = h.client.ListItems(context.Background(), &itemsv1.ListItemsRequest{After: got[2].GetCursor()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetItem().GetId() != got[3].GetItem().GetId() {
+		t.Errorf("after cursor 2: first item %s, want %s", resp.GetItem().GetId(), got[3].GetItem().GetId())
+	}
+
+	for _, req := range []*itemsv1.ListItemsRequest{
+		{PageSize: -1},
+		{PageSize: maxPageSize + 1},
+		{After: "not a cursor"},
+		{Where: []string{"="}},
+	} {
+		stream, err := h.client.ListItems(context.Background(), req)
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListItems(%v): %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestGRPCWatchItems(t *testing.T) {
+	h := newGRPCHarness(t)
+	watched, other := h.items.items[0], h.items.items[1]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := h.client.WatchItems(ctx, &itemsv1.WatchItemsRequest{ItemId: watched.ID.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscription starts asynchronously, so keep publishing fresh events
+	// until one arrives; the other item's events must never get through
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for id := int64(1); ; id += 2 {
+			h.broker.Publish(
+				storage.ItemEvent{ID: id, Type: storage.EventItemUpdated, ItemID: other.ID, Item: other, OccurredAt: time.Now()},
+				storage.ItemEvent{ID: id + 1, Type: storage.EventItemUpdated, ItemID: watched.ID, Item: watched, OccurredAt: time.Now()},
+			)
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	for range 3 {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.GetItem().GetId() != watched.ID.String() {
+			t.Fatalf("received an event for %s, want only %s", event.GetItem().GetId(), watched.ID)
+		}
+		if event.GetType() != itemsv1.ItemEvent_TYPE_UPDATED {
+			t.Errorf("type = %s, want TYPE_UPDATED", event.GetType())
+		}
+		if event.GetId()%2 != 0 {
+			t.Errorf("event id %d belongs to the other item", event.GetId())
+		}
+	}
+
+	// Shutting the broker down ends the stream with Unavailable
+	h.broker.Shutdown()
+	for {
+		_, err := stream.Recv()
+		if err == nil {
+			continue
+		}
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("after broker shutdown: %v, want Unavailable", err)
+		}
+		break
+	}
+
+	bad, err := h.client.WatchItems(context.Background(), &itemsv1.WatchItemsRequest{ItemId: "not-a-uuid"})
+	if err == nil {
+		_, err = bad.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("WatchItems with a bad id: %v, want InvalidArgument", err)
+	}
+}
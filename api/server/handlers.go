@@ -1,7 +1,10 @@
 package server
 
 import (
+	"errors"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -20,7 +23,7 @@ func (a *API) handleHealth(c *gin.Context) {
 
 // handleHello returns a simple hello world response
 func (a *API) handleHello(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	a.respond(c, http.StatusOK, gin.H{
 		"message": constants.MessageHello,
 	})
 }
@@ -28,16 +31,30 @@ func (a *API) handleHello(c *gin.Context) {
 // handleCreateItem creates a new item
 func (a *API) handleCreateItem(c *gin.Context) {
 	var req storage.CreateItemRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := a.bindBody(c, &req); err != nil {
 		a.logger.Error("Failed to bind request", "error", err)
+		a.writeBindError(c, err)
+		return
+	}
+
+	if err := a.validator.CreateItem(&req); err != nil {
+		a.writeValidationError(c, err)
+		return
+	}
+
+	if err := a.metadataSchema.Validate(req.Metadata); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
+			"error":   "Invalid metadata",
+			"details": err.Error(),
 		})
 		return
 	}
 
-	item, err := a.store.CreateItem(c.Request.Context(), req)
+	item, err := a.items.CreateItem(c.Request.Context(), req)
 	if err != nil {
+		if a.writeConstraintError(c, err) {
+			return
+		}
 		a.logger.Error("Failed to create item", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create item",
@@ -45,7 +62,7 @@ func (a *API) handleCreateItem(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, item)
+	a.respond(c, http.StatusCreated, item)
 }
 
 // handleListItems retrieves a paginated list of items
@@ -59,7 +76,59 @@ func (a *API) handleListItems(c *gin.Context) {
 		return
 	}
 
-	response, err := a.store.ListItems(c.Request.Context(), req)
+	statuses, err := storage.ParseStatusList(req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	req.Status = statuses
+
+	fields, err := storage.ParseItemFields(req.Fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	req.Fields = fields
+
+	// Walk the parameters in order, so the same query builds the same filters
+	query := c.Request.URL.Query()
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		if !strings.HasPrefix(key, storage.MetadataQueryPrefix) {
+			continue
+		}
+		for _, value := range query[key] {
+			filter, err := storage.ParseMetadataQuery(key, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			req.MetadataFilters = append(req.MetadataFilters, filter)
+		}
+	}
+
+	// A conditional request is checked with one aggregate query before the
+	// page is read. Lists without a total carry no validators, so they skip it.
+	if req.CountTotal() && conditional(c.Request) {
+		version, err := a.items.ListItemsVersion(c.Request.Context(), req)
+		if err != nil {
+			a.logger.Error("Failed to check items version", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to retrieve items",
+			})
+			return
+		}
+		if notModified(c, a.listValidators(c, version)) {
+			return
+		}
+	}
+
+	response, err := a.items.ListItems(c.Request.Context(), req)
 	if err != nil {
 		a.logger.Error("Failed to list items", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -68,7 +137,12 @@ func (a *API) handleListItems(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	// Validators come from the version read with the page, which may be a
+	// cached page older than the version checked above
+	if response.Version != nil {
+		writeValidators(c, a.listValidators(c, response.Version))
+	}
+	a.respond(c, http.StatusOK, newItemList(c, response, fields))
 }
 
 // handleGetItem retrieves a single item by ID
@@ -83,7 +157,15 @@ func (a *API) handleGetItem(c *gin.Context) {
 		return
 	}
 
-	item, err := a.store.GetItem(c.Request.Context(), id)
+	fields, err := storage.ParseItemFields(c.QueryArray("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	item, err := a.items.GetItem(c.Request.Context(), id)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -98,7 +180,12 @@ func (a *API) handleGetItem(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, item)
+	validators := a.itemValidators(c, item, fields)
+	if notModified(c, validators) {
+		return
+	}
+	writeValidators(c, validators)
+	a.respond(c, http.StatusOK, itemView{item: *item, fields: fields})
 }
 
 // handleUpdateItem updates an existing item
@@ -114,23 +201,36 @@ func (a *API) handleUpdateItem(c *gin.Context) {
 	}
 
 	var req storage.UpdateItemRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := a.bindBody(c, &req); err != nil {
 		a.logger.Error("Failed to bind request", "error", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		a.writeBindError(c, err)
 		return
 	}
 
 	// Ensure at least one field is provided
-	if req.Name == nil && req.Description == nil {
+	if req.Name == nil && req.Description == nil && req.Metadata == nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "At least one field (name or description) must be provided",
+			"error": "At least one field (name, description or metadata) must be provided",
 		})
 		return
 	}
 
-	item, err := a.store.UpdateItem(c.Request.Context(), id, req)
+	if err := a.validator.UpdateItem(&req); err != nil {
+		a.writeValidationError(c, err)
+		return
+	}
+
+	if req.Metadata != nil {
+		if err := a.metadataSchema.Validate(*req.Metadata); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid metadata",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	item, err := a.items.UpdateItem(c.Request.Context(), id, req)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -138,6 +238,9 @@ func (a *API) handleUpdateItem(c *gin.Context) {
 			})
 			return
 		}
+		if a.writeConstraintError(c, err) {
+			return
+		}
 		a.logger.Error("Failed to update item", "id", id, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update item",
@@ -145,7 +248,7 @@ func (a *API) handleUpdateItem(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, item)
+	a.respond(c, http.StatusOK, item)
 }
 
 // handleDeleteItem deletes an item by ID
@@ -160,7 +263,7 @@ func (a *API) handleDeleteItem(c *gin.Context) {
 		return
 	}
 
-	item, err := a.store.DeleteItem(c.Request.Context(), id)
+	item, blobKeys, err := a.items.DeleteItem(c.Request.Context(), id)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -175,8 +278,287 @@ func (a *API) handleDeleteItem(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	a.deleteItemBlobs(c.Request.Context(), blobKeys)
+
+	a.respond(c, http.StatusOK, gin.H{
 		"message": "Item deleted successfully",
 		"item":    item,
 	})
 }
+
+// handlePublishItem moves a draft item to published
+func (a *API) handlePublishItem(c *gin.Context) {
+	a.transitionItem(c, storage.TransitionPublish)
+}
+
+// handleArchiveItem moves a draft or published item to archived
+func (a *API) handleArchiveItem(c *gin.Context) {
+	a.transitionItem(c, storage.TransitionArchive)
+}
+
+// handleUnarchiveItem moves an archived item back to draft
+func (a *API) handleUnarchiveItem(c *gin.Context) {
+	a.transitionItem(c, storage.TransitionUnarchive)
+}
+
+// transitionItem applies a status transition and maps state machine errors to 409 Conflict
+func (a *API) transitionItem(c *gin.Context, transition storage.Transition) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return
+	}
+
+	item, err := a.items.TransitionItem(c.Request.Context(), id, transition)
+	if err != nil {
+		var transitionErr *storage.TransitionError
+		if errors.As(err, &transitionErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          transitionErr.Error(),
+				"current_status": transitionErr.From,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Item not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to transition item", "id", id, "transition", transition, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to " + string(transition) + " item",
+		})
+		return
+	}
+
+	a.logger.Info("Item transitioned", "id", id, "transition", transition, "status", item.Status)
+	a.respond(c, http.StatusOK, item)
+}
+
+// handleGetItemHistory returns the status transitions recorded for an item
+func (a *API) handleGetItemHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return
+	}
+
+	events, err := a.store.ListItemStatusEvents(c.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Item not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to get item history", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve item history",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"item_id": id,
+		"events":  events,
+	})
+}
+
+// handleCreateItemLink links an item to another item
+func (a *API) handleCreateItemLink(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return
+	}
+
+	var req storage.CreateLinkRequest
+	if err := a.bindBody(c, &req); err != nil {
+		a.logger.Error("Failed to bind request", "error", err)
+		a.writeBindError(c, err)
+		return
+	}
+
+	if !req.Relation.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid relation (expected depends_on, blocks, duplicates or relates_to)",
+		})
+		return
+	}
+	if req.TargetID == id {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "An item cannot be linked to itself",
+		})
+		return
+	}
+
+	link, err := a.store.CreateItemLink(c.Request.Context(), id, req)
+	if err != nil {
+		var cycleErr *storage.LinkCycleError
+		if errors.As(err, &cycleErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": cycleErr.Error(),
+			})
+			return
+		}
+		if errors.Is(err, storage.ErrLinkExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Link already exists",
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Item not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to create item link", "id", id, "target_id", req.TargetID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create link",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusCreated, link)
+}
+
+// handleListItemLinks lists the items directly linked to an item
+func (a *API) handleListItemLinks(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return
+	}
+
+	neighbors, err := a.store.ListItemNeighbors(c.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Item not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to list item links", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve links",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"item_id":   id,
+		"neighbors": neighbors,
+	})
+}
+
+// handleDeleteItemLink removes a link from an item
+func (a *API) handleDeleteItemLink(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return
+	}
+
+	linkIDStr := c.Param("linkId")
+	linkID, err := uuid.Parse(linkIDStr)
+	if err != nil {
+		a.logger.Error("Invalid link ID", "link_id", linkIDStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid link ID format",
+		})
+		return
+	}
+
+	link, err := a.store.DeleteItemLink(c.Request.Context(), id, linkID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Link not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to delete item link", "id", id, "link_id", linkID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete link",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"message": "Link deleted successfully",
+		"link":    link,
+	})
+}
+
+// itemGraphQuery holds the query parameters of GET /items/:id/graph
+type itemGraphQuery struct {
+	Direction storage.GraphDirection `form:"direction"`
+	Depth     int                    `form:"depth"`
+}
+
+// handleGetItemGraph returns the transitive dependency graph of an item
+func (a *API) handleGetItemGraph(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid item ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid item ID format",
+		})
+		return
+	}
+
+	var query itemGraphQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		a.logger.Error("Failed to bind query parameters", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if query.Direction != "" && query.Direction != storage.GraphDependencies && query.Direction != storage.GraphDependents {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid direction (expected dependencies or dependents)",
+		})
+		return
+	}
+
+	graph, err := a.store.GetDependencyGraph(c.Request.Context(), id, query.Direction, query.Depth)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Item not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to build dependency graph", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to build dependency graph",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, graph)
+}
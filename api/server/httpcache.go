@@ -0,0 +1,136 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// DefaultCacheControl lets clients store item responses but makes them
+// revalidate before every reuse
+const DefaultCacheControl = "private, no-cache"
+
+// CacheControl sets the Cache-Control header of the cacheable item routes;
+// an empty value falls back to DefaultCacheControl
+type CacheControl struct {
+	// Item is sent with GET /items/:id
+	Item string
+	// List is sent with GET /items
+	List string
+}
+
+// validators identify one representation of a resource for conditional
+// requests. A zero lastModified leaves Last-Modified out.
+type validators struct {
+	etag         string
+	lastModified time.Time
+	cacheControl string
+}
+
+// itemValidators derives validators for an item from its updated_at; fields
+// picks the representation along with the negotiated format and version
+func (a *API) itemValidators(c *gin.Context, item *storage.Item, fields []string) validators {
+	return validators{
+		etag:         representationETag(c, item.ID.String(), item.UpdatedAt.UTC().Format(time.RFC3339Nano), strings.Join(fields, ",")),
+		lastModified: item.UpdatedAt,
+		cacheControl: a.settings().CacheControl.Item,
+	}
+}
+
+// listValidators derives validators for a page of items from the version
+// of everything the request matches and the query that selects the page
+func (a *API) listValidators(c *gin.Context, version *storage.ItemsVersion) validators {
+	v := validators{cacheControl: a.settings().CacheControl.List}
+	lastModified := "none"
+	if version.LastModified != nil {
+		v.lastModified = *version.LastModified
+		lastModified = version.LastModified.UTC().Format(time.RFC3339Nano)
+	}
+	// Encode sorts the parameters, so their order does not change the tag
+	v.etag = representationETag(c, lastModified, fmt.Sprint(version.Count), c.Request.URL.Query().Encode())
+	return v
+}
+
+// representationETag hashes parts with the request's version and format,
+// since each of those is a different representation. Tags are weak because
+// encodings such as compression may change the bytes sent.
+func representationETag(c *gin.Context, parts ...string) string {
+	h := sha256.New()
+	if v, ok := c.Get(apiVersionKey); ok {
+		fmt.Fprintf(h, "v%d\n", v.(*APIVersion).Number)
+	}
+	if f, ok := c.Get(responseFormatKey); ok {
+		fmt.Fprintf(h, "%s\n", f.(*mediaFormat).name)
+	}
+	for _, part := range parts {
+		fmt.Fprintf(h, "%s\n", part)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// notModified answers 304 Not Modified, with the caching headers, when the
+// request's If-None-Match or If-Modified-Since shows the client already
+// holds this representation. It reports whether it did.
+func notModified(c *gin.Context, v validators) bool {
+	if !fresh(c.Request, v) {
+		return false
+	}
+	writeValidators(c, v)
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+	return true
+}
+
+// writeValidators sets ETag, Last-Modified and Cache-Control for a
+// successful response
+func writeValidators(c *gin.Context, v validators) {
+	c.Header("ETag", v.etag)
+	c.Header("Cache-Control", v.cacheControl)
+	if !v.lastModified.IsZero() {
+		c.Header("Last-Modified", v.lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// conditional reports whether r carries a validator that fresh would check
+func conditional(r *http.Request) bool {
+	return r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != ""
+}
+
+// fresh evaluates the conditional headers as RFC 9110 section 13.2.2
+// orders them: If-None-Match decides when present, and If-Modified-Since
+// is only consulted without it
+func fresh(r *http.Request, v validators) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, v.etag)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || v.lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have whole seconds
+	return !v.lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches compares an If-None-Match list with etag using the weak
+// comparison function
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = textproto.TrimString(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
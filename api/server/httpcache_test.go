@@ -0,0 +1,71 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListValidators(t *testing.T) {
+	a := New(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, Options{})
+	items := newMemoryItems(3)
+	a.items = items
+
+	list := func(target, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		if ifNoneMatch != "" {
+			c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		a.handleListItems(c)
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	w := list("/v1/items", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("plain list: status %d, ETag %q; want 200 with an ETag", w.Code, etag)
+	}
+	if items.versions != 0 {
+		t.Errorf("plain list ran %d version queries, want 0", items.versions)
+	}
+
+	if w := list("/v1/items", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("matching If-None-Match: status %d, want 304", w.Code)
+	}
+	if items.versions != 1 {
+		t.Errorf("conditional list ran %d version queries, want 1", items.versions)
+	}
+
+	w = list("/v1/items?include_total=false", "*")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != "" {
+		t.Errorf("include_total=false: status %d, ETag %q; want 200 without an ETag", w.Code, w.Header().Get("ETag"))
+	}
+	if items.versions != 1 {
+		t.Errorf("include_total=false ran a version query")
+	}
+
+	// Once an item changes, the old tag is stale and the new one matches
+	// what ListItems read with the page
+	items.mu.Lock()
+	items.items[2].UpdatedAt = time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	items.mu.Unlock()
+	w = list("/v1/items", etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("stale If-None-Match: status %d, want 200", w.Code)
+	}
+	fresh := w.Header().Get("ETag")
+	if fresh == "" || fresh == etag {
+		t.Fatalf("ETag after an update = %q, want a new tag", fresh)
+	}
+	if w := list("/v1/items", fresh); w.Code != http.StatusNotModified {
+		t.Errorf("new ETag: status %d, want 304", w.Code)
+	}
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// handleEnqueueJob queues a background job of a registered kind
+func (a *API) handleEnqueueJob(c *gin.Context) {
+	var req storage.EnqueueJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		a.logger.Error("Failed to bind request", "error", err)
+		a.writeBindError(c, err)
+		return
+	}
+
+	if a.jobs == nil || !a.jobs.Handles(req.Kind) {
+		var kinds []string
+		if a.jobs != nil {
+			kinds = a.jobs.Kinds()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown job kind (expected " + strings.Join(kinds, ", ") + ")",
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	job, err := a.store.EnqueueJob(c.Request.Context(), req)
+	if err != nil {
+		a.logger.Error("Failed to enqueue job", "kind", req.Kind, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to enqueue job",
+		})
+		return
+	}
+
+	a.logger.Info("Job enqueued", "id", job.ID, "kind", job.Kind)
+	a.respond(c, http.StatusAccepted, job)
+}
+
+// handleListJobs lists jobs, newest first
+func (a *API) handleListJobs(c *gin.Context) {
+	var req storage.ListJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		a.logger.Error("Failed to bind query parameters", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	if req.Status != "" && !storage.JobStatus(req.Status).Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status (expected queued, running, succeeded, failed or cancelled)",
+		})
+		return
+	}
+
+	response, err := a.store.ListJobs(c.Request.Context(), req)
+	if err != nil {
+		a.logger.Error("Failed to list jobs", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list jobs",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, response)
+}
+
+// handleGetJob retrieves a job's status and result
+func (a *API) handleGetJob(c *gin.Context) {
+	id, ok := a.parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := a.store.GetJob(c.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to get job", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get job",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, job)
+}
+
+// handleCancelJob cancels a queued or running job
+func (a *API) handleCancelJob(c *gin.Context) {
+	id, ok := a.parseJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := a.store.CancelJob(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrJobFinished) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Job has already finished",
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Job not found",
+			})
+			return
+		}
+		a.logger.Error("Failed to cancel job", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to cancel job",
+		})
+		return
+	}
+
+	a.logger.Info("Job cancelled", "id", id)
+	a.respond(c, http.StatusOK, job)
+}
+
+func (a *API) parseJobID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid job ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID format",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultMaxBodyBytes caps request bodies on routes without a limit of their own
+	DefaultMaxBodyBytes int64 = 1 << 20
+	// DefaultHandlerTimeout bounds how long a handler may run, storage calls included
+	DefaultHandlerTimeout = 30 * time.Second
+)
+
+// longRunningRoutes stream or transfer for as long as the client wants, so
+// they are exempt from the handler timeout and the server's write timeout.
+// GraphQL subscriptions are caught by their Upgrade header instead.
+var longRunningRoutes = map[string]bool{
+	"GET /items/events":                        true,
+	"POST /items/:id/attachments":              true,
+	"GET /items/:id/attachments/:attachmentId": true,
+}
+
+// ownBodyLimitRoutes enforce a body limit of their own, such as
+// MaxAttachmentBytes, in place of MaxBodyBytes
+var ownBodyLimitRoutes = map[string]bool{
+	"POST /items/:id/attachments": true,
+}
+
+// bodyLimitMiddleware refuses bodies larger than MaxBodyBytes: at once with
+// 413 when Content-Length says so, otherwise when reading passes the limit
+func (a *API) bodyLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ownBodyLimitRoutes[c.Request.Method+" "+unversionedPath(c.FullPath())] {
+			c.Next()
+			return
+		}
+
+		limit := a.settings().MaxBodyBytes
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("Request body exceeds the %d byte limit", limit),
+			})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// timeoutMiddleware cancels the request context after HandlerTimeout, which
+// ends any storage call in progress. A handler that fails because of it is
+// answered with 503 rather than its own 500. Long-running routes are exempt
+// and have the server's read and write deadlines lifted instead.
+func (a *API) timeoutMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Upgrade") != "" || longRunningRoutes[c.Request.Method+" "+unversionedPath(c.FullPath())] {
+			// Not every writer supports deadlines; those that do not have none to lift
+			rc := http.NewResponseController(c.Writer)
+			_ = rc.SetReadDeadline(time.Time{})
+			_ = rc.SetWriteDeadline(time.Time{})
+			c.Next()
+			return
+		}
+
+		timeout := a.settings().HandlerTimeout
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		w := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.timedOut {
+			a.logger.Warn("Request timed out", "method", c.Request.Method, "path", c.Request.URL.Path, "timeout", timeout)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Request timed out",
+			})
+		}
+	}
+}
+
+// timeoutWriter discards an error response written after the request's
+// deadline passed, so timeoutMiddleware can send 503 in its place
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && !w.Written() && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.timedOut = true
+	}
+	if w.timedOut {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if w.timedOut {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	if w.timedOut {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.timedOut {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,631 @@
+package server
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/api/graphql"
+	"github.com/joel-thompson/my-go-service/api/openapi"
+	"github.com/joel-thompson/my-go-service/retention"
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/joel-thompson/my-go-service/validation"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// routeDoc documents one route registered in SetupRoutes. Request and
+// response schemas are derived from the Go types by reflection, so only the
+// route-level details live here.
+type routeDoc struct {
+	method  string
+	path    string
+	id      string
+	summary string
+	tag     string
+	// query is a struct whose form-tagged fields are the query parameters
+	query any
+	// body is the JSON request body
+	body any
+	// status and response describe the success response; a nil response has no body
+	status   int
+	response any
+	// errors lists the error statuses the handler returns besides 429
+	errors []int
+	// formats lists the media types the route negotiates besides JSON; nil
+	// means JSON only
+	formats []*mediaFormat
+	// customize adjusts what the table cannot express, such as non-JSON bodies
+	customize func(op *openapi.Operation)
+	// unversioned routes are mounted at the root rather than under /v1
+	unversioned bool
+}
+
+// Response envelopes built with gin.H in the handlers, declared here so the
+// document can describe them
+type (
+	healthResponse struct {
+		Status string `json:"status" binding:"required"`
+	}
+	messageResponse struct {
+		Message string `json:"message" binding:"required"`
+	}
+	listItemsResponse struct {
+		Items   []storage.Item `json:"items" binding:"required"`
+		Total   *int           `json:"total,omitempty"`
+		Limit   int            `json:"limit" binding:"required"`
+		Offset  int            `json:"offset" binding:"required"`
+		HasMore bool           `json:"has_more" binding:"required"`
+		Links   pageLinks      `json:"links" binding:"required"`
+	}
+	itemFieldsQuery struct {
+		Fields []string `form:"fields"`
+	}
+	deleteItemResponse struct {
+		Message string       `json:"message" binding:"required"`
+		Item    storage.Item `json:"item" binding:"required"`
+	}
+	itemHistoryResponse struct {
+		ItemID uuid.UUID                 `json:"item_id" binding:"required"`
+		Events []storage.ItemStatusEvent `json:"events" binding:"required"`
+	}
+	itemLinksResponse struct {
+		ItemID    uuid.UUID              `json:"item_id" binding:"required"`
+		Neighbors []storage.ItemNeighbor `json:"neighbors" binding:"required"`
+	}
+	deleteItemLinkResponse struct {
+		Message string           `json:"message" binding:"required"`
+		Link    storage.ItemLink `json:"link" binding:"required"`
+	}
+	attachmentsResponse struct {
+		ItemID      uuid.UUID            `json:"item_id" binding:"required"`
+		Attachments []storage.Attachment `json:"attachments" binding:"required"`
+	}
+	deleteAttachmentResponse struct {
+		Message    string             `json:"message" binding:"required"`
+		Attachment storage.Attachment `json:"attachment" binding:"required"`
+	}
+	retentionRulesResponse struct {
+		Rules []retention.RuleInfo `json:"rules" binding:"required"`
+		Total int                  `json:"total" binding:"required"`
+	}
+	retentionRunsResponse struct {
+		Runs  []storage.RetentionRun `json:"runs" binding:"required"`
+		Total int                    `json:"total" binding:"required"`
+	}
+	webhooksResponse struct {
+		Webhooks []storage.WebhookSubscription `json:"webhooks" binding:"required"`
+		Total    int                           `json:"total" binding:"required"`
+	}
+	deliveriesResponse struct {
+		Deliveries []storage.WebhookDelivery `json:"deliveries" binding:"required"`
+		Total      int                       `json:"total" binding:"required"`
+	}
+	deliveryResponse struct {
+		Delivery storage.WebhookDelivery          `json:"delivery" binding:"required"`
+		Attempts []storage.WebhookDeliveryAttempt `json:"attempts" binding:"required"`
+	}
+	outboxStatsResponse struct {
+		Pending              int        `json:"pending" binding:"required"`
+		Retrying             int        `json:"retrying" binding:"required"`
+		OldestPendingAt      *time.Time `json:"oldest_pending_at"`
+		OldestPendingSeconds float64    `json:"oldest_pending_seconds" binding:"required"`
+		LastPublishedAt      *time.Time `json:"last_published_at"`
+		PublishedTotal       int64      `json:"published_total" binding:"required"`
+		FailedTotal          int64      `json:"failed_total" binding:"required"`
+		LagSeconds           float64    `json:"lag_seconds" binding:"required"`
+	}
+	cacheStatsResponse struct {
+		Enabled       bool  `json:"enabled" binding:"required"`
+		Items         int   `json:"items,omitempty"`
+		Lists         int   `json:"lists,omitempty"`
+		Remote        bool  `json:"remote,omitempty"`
+		HitsLocal     int64 `json:"hits_local,omitempty"`
+		HitsRemote    int64 `json:"hits_remote,omitempty"`
+		Misses        int64 `json:"misses,omitempty"`
+		Coalesced     int64 `json:"coalesced,omitempty"`
+		Invalidations int64 `json:"invalidations,omitempty"`
+		Evictions     int64 `json:"evictions,omitempty"`
+		RemoteErrors  int64 `json:"remote_errors,omitempty"`
+	}
+	databaseStatsResponse struct {
+		MaxOpenConnections int   `json:"max_open_connections" binding:"required"`
+		OpenConnections    int   `json:"open_connections" binding:"required"`
+		InUse              int   `json:"in_use" binding:"required"`
+		Idle               int   `json:"idle" binding:"required"`
+		WaitCount          int64 `json:"wait_count" binding:"required"`
+		WaitDurationMS     int64 `json:"wait_duration_ms" binding:"required"`
+		MaxIdleClosed      int64 `json:"max_idle_closed" binding:"required"`
+		MaxIdleTimeClosed  int64 `json:"max_idle_time_closed" binding:"required"`
+		MaxLifetimeClosed  int64 `json:"max_lifetime_closed" binding:"required"`
+		Retries            int64 `json:"retries" binding:"required"`
+	}
+	graphqlQuery struct {
+		Query         string `form:"query" binding:"required"`
+		OperationName string `form:"operationName"`
+		Variables     string `form:"variables"`
+	}
+	graphqlRequest  graphql.Request
+	graphqlResponse struct {
+		Data   map[string]any         `json:"data"`
+		Errors []graphqlResponseError `json:"errors,omitempty"`
+	}
+	graphqlResponseError struct {
+		Message    string         `json:"message" binding:"required"`
+		Path       []any          `json:"path,omitempty"`
+		Extensions map[string]any `json:"extensions,omitempty"`
+	}
+	retentionPreviewQuery struct {
+		Limit int `form:"limit" binding:"min=1,max=100"`
+	}
+	errorResponse struct {
+		Error   string                  `json:"error" binding:"required"`
+		Details string                  `json:"details,omitempty"`
+		Fields  []validation.FieldError `json:"fields,omitempty"`
+	}
+	problemResponse struct {
+		Type       string `json:"type" binding:"required"`
+		Title      string `json:"title" binding:"required"`
+		Status     int    `json:"status" binding:"required"`
+		Detail     string `json:"detail" binding:"required"`
+		Instance   string `json:"instance" binding:"required"`
+		Error      string `json:"error,omitempty"`
+		RetryAfter int    `json:"retry_after,omitempty"`
+	}
+)
+
+// routeDocs documents every route in SetupRoutes and registerRoutes, in the
+// same order. Paths are given without the version prefix; routes that are not
+// marked unversioned are served under each version in apiVersions.
+var routeDocs = []routeDoc{
+	{method: "GET", path: "/health", unversioned: true, id: "getHealth", summary: "Health check", tag: "service",
+		status: http.StatusOK, response: healthResponse{}},
+	{method: "GET", path: "/openapi.json", unversioned: true, id: "getOpenAPI", summary: "This OpenAPI document", tag: "service",
+		status: http.StatusOK, response: map[string]any{}},
+	{method: "GET", path: "/docs", unversioned: true, id: "getDocs", summary: "API reference page", tag: "service",
+		status: http.StatusOK, customize: func(op *openapi.Operation) {
+			op.Responses["200"].Content = map[string]*openapi.MediaType{
+				"text/html": {Schema: &openapi.Schema{Type: openapi.Types{"string"}}},
+			}
+		}},
+
+	{method: "GET", path: "/graphql", unversioned: true, id: "getGraphQL", summary: "Run a GraphQL query", tag: "graphql",
+		query: graphqlQuery{}, status: http.StatusOK, response: graphqlResponse{},
+		errors: []int{400}},
+	{method: "POST", path: "/graphql", unversioned: true, id: "postGraphQL", summary: "Run a GraphQL query or mutation", tag: "graphql",
+		body: graphqlRequest{}, status: http.StatusOK, response: graphqlResponse{},
+		errors: []int{400}},
+
+	{method: "GET", path: "/hello", id: "getHello", summary: "Hello world", tag: "service",
+		status: http.StatusOK, response: messageResponse{}},
+
+	{method: "POST", path: "/items", id: "createItem", formats: itemFormats, summary: "Create an item", tag: "items",
+		body: storage.CreateItemRequest{}, status: http.StatusCreated, response: storage.Item{},
+		errors: []int{400, 500}},
+	{method: "GET", path: "/items", id: "listItems", formats: listFormats, summary: "List items, newest first", tag: "items",
+		query: storage.ListItemsRequest{}, status: http.StatusOK, response: listItemsResponse{},
+		errors: []int{400, 500}, customize: func(op *openapi.Operation) {
+			op.Description = "Filter on metadata with `meta.` query parameters such as `meta.color=red`, " +
+				"`meta.size>10` or `meta.dims.width<=3` (operators =, !=, >, >=, <, <=). " +
+				"`fields=id,name` returns only those item fields and reads only those columns; " +
+				"`include_total=false` skips counting matches and omits `total`."
+			op.Parameter("query", "status").Schema.Items = openapi.Ref("ItemStatus")
+			op.Parameter("query", "include_total").Schema.Type = openapi.Types{"boolean"}
+			fieldsParameter(op)
+			conditionalGet(op)
+		}},
+	{method: "GET", path: "/items/events", id: "streamItemEvents", summary: "Stream item changes as Server-Sent Events", tag: "items",
+		query: itemEventsQuery{}, status: http.StatusOK, errors: []int{400}, customize: func(op *openapi.Operation) {
+			op.Description = "Each event's id is its position in the item event log. Reconnect with " +
+				"Last-Event-ID (or last_event_id) to replay missed events before live ones."
+			op.Parameter("query", "item_id").Schema.Format = "uuid"
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:        "Last-Event-ID",
+				In:          "header",
+				Description: "Resume after this event id",
+				Schema:      &openapi.Schema{Type: openapi.Types{"string"}},
+			})
+			op.Responses["200"].Content = map[string]*openapi.MediaType{
+				"text/event-stream": {Schema: &openapi.Schema{
+					Type:        openapi.Types{"string"},
+					Description: "Frames whose event is the change type and whose data is an ItemEvent",
+				}},
+			}
+		}},
+	{method: "GET", path: "/items/:id", id: "getItem", formats: itemFormats, summary: "Get an item", tag: "items",
+		query: itemFieldsQuery{}, status: http.StatusOK, response: storage.Item{}, errors: []int{400, 404, 500},
+		customize: func(op *openapi.Operation) {
+			op.Description = "`fields=id,name` returns only those item fields."
+			fieldsParameter(op)
+			conditionalGet(op)
+		}},
+	{method: "PUT", path: "/items/:id", id: "updateItem", formats: itemFormats, summary: "Update an item", tag: "items",
+		body: storage.UpdateItemRequest{}, status: http.StatusOK, response: storage.Item{},
+		errors: []int{400, 404, 500}},
+	{method: "DELETE", path: "/items/:id", id: "deleteItem", formats: itemFormats, summary: "Delete an item and its attachments", tag: "items",
+		status: http.StatusOK, response: deleteItemResponse{}, errors: []int{400, 404, 500}},
+
+	{method: "POST", path: "/items/:id/publish", id: "publishItem", formats: itemFormats, summary: "Move a draft item to published", tag: "items",
+		status: http.StatusOK, response: storage.Item{}, errors: []int{400, 404, 409, 500}},
+	{method: "POST", path: "/items/:id/archive", id: "archiveItem", formats: itemFormats, summary: "Archive an item", tag: "items",
+		status: http.StatusOK, response: storage.Item{}, errors: []int{400, 404, 409, 500}},
+	{method: "POST", path: "/items/:id/unarchive", id: "unarchiveItem", formats: itemFormats, summary: "Return an archived item to draft", tag: "items",
+		status: http.StatusOK, response: storage.Item{}, errors: []int{400, 404, 409, 500}},
+	{method: "GET", path: "/items/:id/history", id: "getItemHistory", formats: itemFormats, summary: "List an item's status transitions", tag: "items",
+		status: http.StatusOK, response: itemHistoryResponse{}, errors: []int{400, 404, 500}},
+
+	{method: "POST", path: "/items/:id/links", id: "createItemLink", formats: itemFormats, summary: "Link an item to another item", tag: "links",
+		body: storage.CreateLinkRequest{}, status: http.StatusCreated, response: storage.ItemLink{},
+		errors: []int{400, 404, 409, 500}},
+	{method: "GET", path: "/items/:id/links", id: "listItemLinks", formats: itemFormats, summary: "List an item's links in both directions", tag: "links",
+		status: http.StatusOK, response: itemLinksResponse{}, errors: []int{400, 404, 500}},
+	{method: "DELETE", path: "/items/:id/links/:linkId", id: "deleteItemLink", formats: itemFormats, summary: "Remove a link from an item", tag: "links",
+		status: http.StatusOK, response: deleteItemLinkResponse{}, errors: []int{400, 404, 500}},
+	{method: "GET", path: "/items/:id/graph", id: "getItemGraph", formats: itemFormats, summary: "Get an item's transitive dependency graph", tag: "links",
+		query: itemGraphQuery{}, status: http.StatusOK, response: storage.DependencyGraph{},
+		errors: []int{400, 404, 500}},
+
+	{method: "POST", path: "/items/:id/attachments", id: "uploadAttachment", summary: "Upload an attachment", tag: "attachments",
+		status: http.StatusCreated, response: storage.Attachment{}, errors: []int{400, 404, 413, 500},
+		customize: func(op *openapi.Operation) {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content: map[string]*openapi.MediaType{
+					"multipart/form-data": {Schema: &openapi.Schema{
+						Type:     openapi.Types{"object"},
+						Required: []string{"file"},
+						Properties: map[string]*openapi.Schema{
+							"file": {Type: openapi.Types{"string"}, Format: "binary"},
+						},
+					}},
+				},
+			}
+		}},
+	{method: "GET", path: "/items/:id/attachments", id: "listAttachments", formats: itemFormats, summary: "List an item's attachments", tag: "attachments",
+		status: http.StatusOK, response: attachmentsResponse{}, errors: []int{400, 404, 500}},
+	{method: "GET", path: "/items/:id/attachments/:attachmentId", id: "downloadAttachment", summary: "Download an attachment", tag: "attachments",
+		status: http.StatusOK, errors: []int{400, 404, 500}, customize: func(op *openapi.Operation) {
+			ok := op.Responses["200"]
+			ok.Content = map[string]*openapi.MediaType{
+				"application/octet-stream": {Schema: &openapi.Schema{Type: openapi.Types{"string"}, Format: "binary"}},
+			}
+			ok.Headers = map[string]*openapi.Header{
+				"Content-Disposition": {Description: "attachment; filename=...", Schema: &openapi.Schema{Type: openapi.Types{"string"}}},
+				"ETag":                {Description: "Quoted SHA-256 of the contents", Schema: &openapi.Schema{Type: openapi.Types{"string"}}},
+				"Digest":              {Description: "sha-256=<hex digest>", Schema: &openapi.Schema{Type: openapi.Types{"string"}}},
+			}
+			ok.Description = "The attachment contents, served with the content type it was uploaded with"
+		}},
+	{method: "DELETE", path: "/items/:id/attachments/:attachmentId", id: "deleteAttachment", formats: itemFormats, summary: "Delete an attachment", tag: "attachments",
+		status: http.StatusOK, response: deleteAttachmentResponse{}, errors: []int{400, 404, 500}},
+
+	{method: "POST", path: "/jobs", id: "enqueueJob", summary: "Queue a background job", tag: "jobs",
+		body: storage.EnqueueJobRequest{}, status: http.StatusAccepted, response: storage.Job{},
+		errors: []int{400, 500}},
+	{method: "GET", path: "/jobs", id: "listJobs", summary: "List jobs, newest first", tag: "jobs",
+		query: storage.ListJobsRequest{}, status: http.StatusOK, response: storage.ListJobsResponse{},
+		errors: []int{400, 500}, customize: func(op *openapi.Operation) {
+			op.Parameter("query", "status").Schema = openapi.Ref("JobStatus")
+		}},
+	{method: "GET", path: "/jobs/:id", id: "getJob", summary: "Get a job", tag: "jobs",
+		status: http.StatusOK, response: storage.Job{}, errors: []int{400, 404, 500}},
+	{method: "POST", path: "/jobs/:id/cancel", id: "cancelJob", summary: "Cancel a queued job", tag: "jobs",
+		status: http.StatusOK, response: storage.Job{}, errors: []int{400, 404, 409, 500}},
+
+	{method: "GET", path: "/retention/rules", id: "listRetentionRules", summary: "List retention rules and their next run", tag: "retention",
+		status: http.StatusOK, response: retentionRulesResponse{}},
+	{method: "GET", path: "/retention/rules/:name/preview", id: "previewRetentionRule", summary: "Show which items a rule would affect now", tag: "retention",
+		query: retentionPreviewQuery{}, status: http.StatusOK, response: retention.Preview{},
+		errors: []int{400, 404, 409, 500}},
+	{method: "POST", path: "/retention/rules/:name/run", id: "runRetentionRule", summary: "Run a retention rule now", tag: "retention",
+		status: http.StatusOK, response: storage.RetentionRun{}, errors: []int{404, 409, 500}},
+	{method: "GET", path: "/retention/runs", id: "listRetentionRuns", summary: "List recent retention runs", tag: "retention",
+		query: storage.ListRetentionRunsRequest{}, status: http.StatusOK, response: retentionRunsResponse{},
+		errors: []int{400, 500}},
+
+	{method: "GET", path: "/outbox/stats", id: "getOutboxStats", summary: "Get the outbox backlog and relay lag", tag: "service",
+		status: http.StatusOK, response: outboxStatsResponse{}, errors: []int{500}},
+	{method: "GET", path: "/cache/stats", id: "getCacheStats", summary: "Get item cache sizes and hit counters", tag: "service",
+		status: http.StatusOK, response: cacheStatsResponse{}},
+	{method: "GET", path: "/admin/config", id: "getConfigStatus", summary: "Get the configuration generation and settings awaiting a restart", tag: "service",
+		status: http.StatusOK, response: ConfigStatus{}},
+	{method: "GET", path: "/admin/db", id: "getDatabaseStats", summary: "Get database connection pool usage and storage retries", tag: "service",
+		status: http.StatusOK, response: databaseStatsResponse{}},
+
+	{method: "POST", path: "/webhooks", id: "createWebhook", summary: "Subscribe a URL to item events", tag: "webhooks",
+		body: storage.CreateWebhookRequest{}, status: http.StatusCreated, response: storage.CreatedWebhook{},
+		errors: []int{400, 500}},
+	{method: "GET", path: "/webhooks", id: "listWebhooks", summary: "List webhook subscriptions", tag: "webhooks",
+		status: http.StatusOK, response: webhooksResponse{}, errors: []int{500}},
+	{method: "GET", path: "/webhooks/:id", id: "getWebhook", summary: "Get a webhook subscription", tag: "webhooks",
+		status: http.StatusOK, response: storage.WebhookSubscription{}, errors: []int{400, 404, 500}},
+	{method: "DELETE", path: "/webhooks/:id", id: "deleteWebhook", summary: "Delete a webhook subscription", tag: "webhooks",
+		status: http.StatusOK, response: messageResponse{}, errors: []int{400, 404, 500}},
+	{method: "POST", path: "/webhooks/:id/test", id: "testWebhook", summary: "Send a test delivery", tag: "webhooks",
+		status: http.StatusOK, response: storage.WebhookDelivery{}, errors: []int{400, 404, 500, 503}},
+	{method: "GET", path: "/webhooks/:id/deliveries", id: "listWebhookDeliveries", summary: "List a subscription's recent deliveries", tag: "webhooks",
+		query: storage.ListDeliveriesRequest{}, status: http.StatusOK, response: deliveriesResponse{},
+		errors: []int{400, 404, 500}, customize: func(op *openapi.Operation) {
+			op.Parameter("query", "status").Schema.Enum = deliveryStatuses
+		}},
+	{method: "GET", path: "/webhooks/deliveries/:deliveryId", id: "getWebhookDelivery", summary: "Get a delivery with its attempt log", tag: "webhooks",
+		status: http.StatusOK, response: deliveryResponse{}, errors: []int{400, 404, 500}},
+	{method: "POST", path: "/webhooks/deliveries/:deliveryId/redeliver", id: "redeliverWebhookDelivery", summary: "Queue a delivery to be sent again", tag: "webhooks",
+		status: http.StatusAccepted, response: storage.WebhookDelivery{}, errors: []int{400, 404, 500}},
+}
+
+var apiTags = []openapi.Tag{
+	{Name: "service", Description: "Health, metrics and documentation"},
+	{Name: "items", Description: "Items and their lifecycle"},
+	{Name: "links", Description: "Relations and dependencies between items"},
+	{Name: "attachments", Description: "Files attached to items"},
+	{Name: "jobs", Description: "Background jobs"},
+	{Name: "retention", Description: "Scheduled archiving and purging of old items"},
+	{Name: "webhooks", Description: "Webhook subscriptions and deliveries"},
+	{Name: "graphql", Description: "GraphQL queries, mutations and subscriptions over items"},
+}
+
+var deliveryStatuses = []any{storage.DeliveryPending, storage.DeliverySucceeded, storage.DeliveryDead}
+
+// errorResponses are the shared responses routes refer to by status code
+var errorResponses = map[int]string{
+	http.StatusBadRequest:            "BadRequest",
+	http.StatusNotFound:              "NotFound",
+	http.StatusNotAcceptable:         "NotAcceptable",
+	http.StatusConflict:              "Conflict",
+	http.StatusRequestEntityTooLarge: "PayloadTooLarge",
+	http.StatusUnsupportedMediaType:  "UnsupportedMediaType",
+	http.StatusTooManyRequests:       "TooManyRequests",
+	http.StatusInternalServerError:   "InternalError",
+	http.StatusServiceUnavailable:    "ServiceUnavailable",
+}
+
+// OpenAPI builds the OpenAPI 3.1 document for the routes in SetupRoutes.
+// Versioned routes are described under the latest version's prefix.
+func (a *API) OpenAPI() *openapi.Document {
+	g := openapi.NewGenerator()
+	g.Define(reflect.TypeFor[uuid.UUID](), &openapi.Schema{Type: openapi.Types{"string"}, Format: "uuid"})
+	g.Define(reflect.TypeFor[storage.Metadata](), &openapi.Schema{
+		Type:        openapi.Types{"object"},
+		Description: "Arbitrary JSON fields, validated against the metadata schema when one is configured",
+	})
+	g.Define(reflect.TypeFor[retention.Age](), &openapi.Schema{
+		Type:        openapi.Types{"string"},
+		Description: "Whole days such as 30d, or a Go duration such as 12h",
+	})
+	openapi.Enum(g, "Item lifecycle status", storage.StatusDraft, storage.StatusPublished, storage.StatusArchived)
+	openapi.Enum(g, "Item lifecycle transition", storage.TransitionPublish, storage.TransitionArchive, storage.TransitionUnarchive)
+	openapi.Enum(g, "Item change type", storage.EventItemCreated, storage.EventItemUpdated, storage.EventItemDeleted)
+	openapi.Enum(g, "Link relation; depends_on and blocks form the dependency graph",
+		storage.RelationDependsOn, storage.RelationBlocks, storage.RelationDuplicates, storage.RelationRelatesTo)
+	openapi.Enum(g, "Dependency graph direction", storage.GraphDependencies, storage.GraphDependents)
+	openapi.Enum(g, "Job status", storage.JobQueued, storage.JobRunning, storage.JobSucceeded, storage.JobFailed, storage.JobCancelled)
+	openapi.Enum(g, "What a retention rule does to matching items", storage.RetentionArchive, storage.RetentionPurge)
+
+	// The data of each /items/events frame; text/event-stream bodies cannot refer to it
+	g.Schema(storage.ItemEvent{})
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title: "my-go-service API",
+			Description: "Items with metadata, links, attachments and lifecycle, plus the jobs, retention rules and webhooks around them. " +
+				"The unversioned aliases of these paths (such as /items for /v1/items) are deprecated and announce their Sunset date.",
+			Version: "1.0.0",
+		},
+		Tags:  apiTags,
+		Paths: map[string]*openapi.PathItem{},
+		Components: openapi.Components{
+			Responses: map[string]*openapi.Response{},
+		},
+	}
+
+	errorSchema := g.Schema(errorResponse{})
+	for status, name := range errorResponses {
+		doc.Components.Responses[name] = &openapi.Response{
+			Description: http.StatusText(status),
+			Content:     openapi.JSONContent(errorSchema),
+		}
+	}
+	doc.Components.Responses["TooManyRequests"] = &openapi.Response{
+		Description: "Rate limit exceeded (only when rate limiting is enabled)",
+		Headers: map[string]*openapi.Header{
+			"Retry-After": {Description: "Seconds until a request will be accepted", Schema: &openapi.Schema{Type: openapi.Types{"integer"}}},
+		},
+		Content: map[string]*openapi.MediaType{
+			"application/problem+json": {Schema: g.Schema(problemResponse{})},
+		},
+	}
+
+	latest := apiVersions[len(apiVersions)-1]
+	for _, route := range routeDocs {
+		path := openapiPath(route.path)
+		if !route.unversioned {
+			path = latest.Prefix() + path
+		}
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.method)] = route.operation(g)
+	}
+
+	// Item limits are enforced by package validation rather than binding tags
+	limits := a.validator.Limits()
+	schemas := g.Schemas()
+	for _, name := range []string{"CreateItemRequest", "UpdateItemRequest"} {
+		schemas[name].Properties["name"].MaxLength = &limits.NameMaxLength
+		schemas[name].Properties["description"].MaxLength = &limits.DescriptionMaxLength
+	}
+
+	doc.Components.Schemas = schemas
+	return doc
+}
+
+func (r routeDoc) operation(g *openapi.Generator) *openapi.Operation {
+	op := &openapi.Operation{
+		OperationID: r.id,
+		Summary:     r.summary,
+		Tags:        []string{r.tag},
+		Responses:   map[string]*openapi.Response{},
+	}
+
+	for _, name := range pathParams(r.path) {
+		schema := &openapi.Schema{Type: openapi.Types{"string"}, Format: "uuid"}
+		if name == "name" {
+			schema.Format = ""
+		}
+		op.Parameters = append(op.Parameters, &openapi.Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	if r.query != nil {
+		op.Parameters = append(op.Parameters, g.QueryParameters(r.query)...)
+	}
+	if r.body != nil {
+		op.RequestBody = &openapi.RequestBody{Required: true, Content: r.content(g.Schema(r.body), decodable(r.formats))}
+	}
+
+	ok := &openapi.Response{Description: http.StatusText(r.status)}
+	if r.response != nil {
+		ok.Content = r.content(g.Schema(r.response), r.formats)
+	}
+	op.Responses[strconv.Itoa(r.status)] = ok
+
+	errors := slices.Clone(r.errors)
+	if !r.unversioned {
+		errors = append(errors, http.StatusNotAcceptable)
+	}
+	if r.body != nil && r.formats != nil {
+		errors = append(errors, http.StatusUnsupportedMediaType)
+	}
+	if !unlimitedRoutes[r.method+" "+r.path] {
+		errors = append(errors, http.StatusTooManyRequests)
+	}
+	for _, status := range errors {
+		op.Responses[strconv.Itoa(status)] = openapi.ResponseRef(errorResponses[status])
+	}
+
+	if r.customize != nil {
+		r.customize(op)
+	}
+	return op
+}
+
+// content describes a body with schema in each of formats. CSV has its own
+// shape, a row per item, so it is described as text rather than by schema.
+func (r routeDoc) content(schema *openapi.Schema, formats []*mediaFormat) map[string]*openapi.MediaType {
+	content := openapi.JSONContent(schema)
+	for _, f := range formats {
+		switch f {
+		case formatJSON:
+		case formatCSV:
+			content[f.mediaType] = &openapi.MediaType{Schema: &openapi.Schema{
+				Type: openapi.Types{"string"},
+				Description: "A header row (" + strings.Join(itemCSVHeader, ",") + ") and a row per item, " +
+					"limited to the columns in fields; X-Total-Count holds the total unless include_total=false",
+			}}
+		default:
+			content[f.mediaType] = &openapi.MediaType{Schema: schema}
+		}
+	}
+	return content
+}
+
+// fieldsParameter describes the fields query parameter. Values may be
+// comma-separated, so they are not restricted to an enum.
+func fieldsParameter(op *openapi.Operation) {
+	op.Parameter("query", "fields").Description = "Item fields to return, repeated or comma-separated: " +
+		strings.Join(storage.ItemColumns, ", ")
+}
+
+// conditionalGet describes the validators a cacheable GET sends and the
+// conditional request headers it answers with 304
+func conditionalGet(op *openapi.Operation) {
+	str := func() *openapi.Schema { return &openapi.Schema{Type: openapi.Types{"string"}} }
+	op.Parameters = append(op.Parameters,
+		&openapi.Parameter{Name: "If-None-Match", In: "header", Description: "ETags the client holds", Schema: str()},
+		&openapi.Parameter{Name: "If-Modified-Since", In: "header", Description: "Last-Modified of the copy the client holds", Schema: str()},
+	)
+	headers := map[string]*openapi.Header{
+		"ETag":          {Description: "Weak validator for this representation", Schema: str()},
+		"Last-Modified": {Description: "When the newest item in the response changed", Schema: str()},
+		"Cache-Control": {Description: "Configured per route", Schema: str()},
+	}
+	for status, response := range op.Responses {
+		if strings.HasPrefix(status, "2") {
+			response.Headers = headers
+		}
+	}
+	op.Responses["304"] = &openapi.Response{Description: "The client's copy is current", Headers: headers}
+}
+
+var pathParamPattern = regexp.MustCompile(`:(\w+)`)
+
+// openapiPath converts a gin route pattern such as /items/:id to /items/{id}
+func openapiPath(path string) string {
+	return pathParamPattern.ReplaceAllString(path, "{$1}")
+}
+
+func pathParams(path string) []string {
+	var names []string
+	for _, m := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// CheckRoutes reports routes registered on router that the OpenAPI document
+// does not describe, and documented routes that are no longer registered
+// under every version (and as a legacy alias, unless those are disabled)
+func (a *API) CheckRoutes(router *gin.Engine) error {
+	documented := map[string]bool{}
+	for _, route := range routeDocs {
+		if route.unversioned {
+			documented[route.method+" "+route.path] = true
+			continue
+		}
+		for _, v := range apiVersions {
+			documented[route.method+" "+v.Prefix()+route.path] = true
+		}
+		if !a.legacyRoutes.Disabled {
+			documented[route.method+" "+route.path] = true
+		}
+	}
+
+	var problems []string
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		if !documented[key] {
+			problems = append(problems, "undocumented route "+key)
+		}
+		delete(documented, key)
+	}
+	for key := range documented {
+		problems = append(problems, "documented route not registered: "+key)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	slices.Sort(problems)
+	return fmt.Errorf("routes and OpenAPI document differ: %s", strings.Join(problems, "; "))
+}
+
+// handleGetOpenAPI serves the OpenAPI document
+func (a *API) handleGetOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, a.OpenAPI())
+}
+
+// handleGetDocs serves a Redoc page that renders /openapi.json
+func (a *API) handleGetDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
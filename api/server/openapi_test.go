@@ -0,0 +1,37 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// TestOpenAPICoversRoutes fails when a route is registered without a
+// routeDocs entry, or an entry outlives its route
+func TestOpenAPICoversRoutes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		name   string
+		legacy LegacyRoutes
+	}{
+		{"with legacy routes", LegacyRoutes{}},
+		{"without legacy routes", LegacyRoutes{Disabled: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Routes and the document do not depend on the database
+			a := New(logger, nil, Options{LegacyRoutes: tc.legacy})
+			router, err := a.SetupRoutes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := a.CheckRoutes(router); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := json.Marshal(a.OpenAPI()); err != nil {
+				t.Fatalf("encode document: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joel-thompson/my-go-service/outbox"
+)
+
+// handleGetOutboxStats reports the outbox backlog from the database and the
+// relay counters of this process
+func (a *API) handleGetOutboxStats(c *gin.Context) {
+	stats, err := a.store.GetOutboxStats(c.Request.Context())
+	if err != nil {
+		a.logger.Error("Failed to get outbox stats", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get outbox stats",
+		})
+		return
+	}
+
+	var oldestPendingSeconds float64
+	if stats.OldestPendingAt != nil {
+		oldestPendingSeconds = time.Since(*stats.OldestPendingAt).Seconds()
+	}
+
+	metrics := outbox.CurrentMetrics()
+	a.respond(c, http.StatusOK, gin.H{
+		"pending":                stats.Pending,
+		"retrying":               stats.Retrying,
+		"oldest_pending_at":      stats.OldestPendingAt,
+		"oldest_pending_seconds": oldestPendingSeconds,
+		"last_published_at":      stats.LastPublishedAt,
+		"published_total":        metrics.PublishedTotal,
+		"failed_total":           metrics.FailedTotal,
+		"lag_seconds":            metrics.Lag.Seconds(),
+	})
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joel-thompson/my-go-service/ratelimit"
+)
+
+// DefaultAPIKeyHeader identifies the caller for rate limiting when present
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// RateLimits holds the token bucket applied to each route group.
+// A zero Limit leaves its group unlimited.
+type RateLimits struct {
+	// Read covers GET requests
+	Read ratelimit.Limit
+	// Write covers creates, updates and deletes
+	Write ratelimit.Limit
+	// Bulk covers requests that start expensive background work
+	Bulk ratelimit.Limit
+}
+
+// bulkRoutes are the routes limited by RateLimits.Bulk, keyed by method and
+// route pattern without the version prefix
+var bulkRoutes = map[string]bool{
+	"POST /jobs":                                      true,
+	"POST /retention/rules/:name/run":                 true,
+	"POST /items/:id/attachments":                     true,
+	"POST /webhooks/:id/test":                         true,
+	"POST /webhooks/deliveries/:deliveryId/redeliver": true,
+}
+
+// unlimitedRoutes are never rate limited so probes keep working under load
+var unlimitedRoutes = map[string]bool{
+	"GET /health": true,
+}
+
+// rateLimitMiddleware takes a token from the caller's bucket for the route's
+// group and rejects the request with 429 when the bucket is empty. Callers
+// are identified by rateLimitKey.
+func (a *API) rateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + unversionedPath(c.FullPath())
+		if unlimitedRoutes[route] {
+			c.Next()
+			return
+		}
+
+		group, limit := a.rateLimitGroup(c.Request.Method, route)
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		key := group + ":" + a.rateLimitKey(c)
+		result, err := a.limiter.Take(c.Request.Context(), key, limit)
+		if err != nil {
+			// Fail open: a limiter outage should not take the API down with it
+			a.logger.Error("Failed to check rate limit", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.Window())))
+
+		if !result.Allowed {
+			retryAfter := max(ceilSeconds(result.RetryAfter), 1)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			a.logger.Warn("Rate limit exceeded", "group", group, "key", key, "retry_after", retryAfter)
+			writeProblem(c, http.StatusTooManyRequests, fmt.Sprintf("Rate limit exceeded, retry in %d second(s)", retryAfter), gin.H{
+				"error":       "Rate limit exceeded",
+				"retry_after": retryAfter,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitGroup returns the group name and limit for a request
+func (a *API) rateLimitGroup(method, route string) (string, ratelimit.Limit) {
+	switch {
+	case bulkRoutes[route]:
+		return "bulk", a.settings().RateLimits.Bulk
+	case method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions:
+		return "read", a.settings().RateLimits.Read
+	default:
+		return "write", a.settings().RateLimits.Write
+	}
+}
+
+// rateLimitKey identifies the caller: by client certificate identity, then
+// API key, then IP. Only configured API keys count; any other value falls back
+// to the IP so callers cannot mint fresh buckets by varying the header. Keys
+// are hashed so raw keys never reach logs or the shared bucket table.
+func (a *API) rateLimitKey(c *gin.Context) string {
+	if identity := clientIdentity(c); identity != "" {
+		return "id:" + identity
+	}
+	if apiKey := c.GetHeader(a.apiKeyHeader); apiKey != "" && apiKeyValid(apiKey, a.apiKeys) {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:16])
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// writeProblem aborts the request with an RFC 9457 problem details body.
+// extra adds extension members alongside type, title, status and detail.
+func writeProblem(c *gin.Context, status int, detail string, extra gin.H) {
+	problem := gin.H{
+		"type":     "about:blank",
+		"title":    http.StatusText(status),
+		"status":   status,
+		"detail":   detail,
+		"instance": c.Request.URL.Path,
+	}
+	for k, v := range extra {
+		problem[k] = v
+	}
+	c.Render(status, problemJSON{problem})
+	c.Abort()
+}
+
+// problemJSON renders JSON with the application/problem+json content type
+type problemJSON struct {
+	data any
+}
+
+func (p problemJSON) Render(w http.ResponseWriter) error {
+	p.WriteContentType(w)
+	return json.NewEncoder(w).Encode(p.data)
+}
+
+func (p problemJSON) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/problem+json")
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
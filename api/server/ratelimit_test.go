@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	a := &API{apiKeyHeader: DefaultAPIKeyHeader, apiKeys: []string{"key-one", "key-two"}}
+
+	key := func(apiKey, identity string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/v1/items", nil)
+		c.Request.RemoteAddr = "203.0.113.7:4321"
+		if apiKey != "" {
+			c.Request.Header.Set(DefaultAPIKeyHeader, apiKey)
+		}
+		if identity != "" {
+			c.Set(identityKey, identity)
+		}
+		return a.rateLimitKey(c)
+	}
+
+	if got := key("", ""); got != "ip:203.0.113.7" {
+		t.Errorf("no key: %s, want ip:203.0.113.7", got)
+	}
+	one, two := key("key-one", ""), key("key-two", "")
+	if !strings.HasPrefix(one, "key:") || !strings.HasPrefix(two, "key:") || one == two {
+		t.Errorf("configured keys: %s and %s, want distinct key buckets", one, two)
+	}
+	if strings.Contains(one, "key-one") {
+		t.Errorf("bucket %s contains the raw key", one)
+	}
+	for _, unknown := range []string{"made-up", "key-on", "key-one "} {
+		if got := key(unknown, ""); got != "ip:203.0.113.7" {
+			t.Errorf("unknown key %q: %s, want the IP bucket", unknown, got)
+		}
+	}
+	if got := key("key-one", "CN=worker"); got != "id:CN=worker" {
+		t.Errorf("client certificate: %s, want id:CN=worker", got)
+	}
+
+	a.apiKeys = nil
+	if got := key("key-one", ""); got != "ip:203.0.113.7" {
+		t.Errorf("no keys configured: %s, want the IP bucket", got)
+	}
+}
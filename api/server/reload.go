@@ -0,0 +1,83 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Live holds the settings that may change while the server runs. New takes
+// them from Options and Reload swaps them whole, so a request never sees
+// half of one generation and half of the next.
+type Live struct {
+	// RateLimits sets the token bucket for each route group
+	RateLimits RateLimits
+
+	// CacheControl sets Cache-Control on GET /items and GET /items/:id
+	CacheControl CacheControl
+
+	// MaxBodyBytes caps request bodies on routes without a limit of their own
+	MaxBodyBytes int64
+
+	// HandlerTimeout cancels a request's context once exceeded
+	HandlerTimeout time.Duration
+
+	// ClientIdentities maps verified client certificate subjects to caller identities
+	ClientIdentities map[string]string
+
+	// CompressionDisabled sends every response uncompressed; gzip request bodies are still accepted
+	CompressionDisabled bool
+}
+
+// withDefaults fills in zero settings the way New documents them
+func (l Live) withDefaults() *Live {
+	if l.CacheControl.Item == "" {
+		l.CacheControl.Item = DefaultCacheControl
+	}
+	if l.CacheControl.List == "" {
+		l.CacheControl.List = DefaultCacheControl
+	}
+	if l.MaxBodyBytes <= 0 {
+		l.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if l.HandlerTimeout <= 0 {
+		l.HandlerTimeout = DefaultHandlerTimeout
+	}
+	return &l
+}
+
+// Reload applies new live settings; requests already running finish with
+// the settings they started with
+func (a *API) Reload(live Live) {
+	a.live.Store(live.withDefaults())
+}
+
+// settings returns the live settings for the current request
+func (a *API) settings() *Live {
+	return a.live.Load()
+}
+
+// ConfigStatus describes the configuration generation the server runs with
+type ConfigStatus struct {
+	// Generation starts at 1 and counts successful reloads
+	Generation int       `json:"generation" binding:"required"`
+	LoadedAt   time.Time `json:"loaded_at" binding:"required"`
+
+	// LastReloadAt and LastError record the most recent reload attempt, successful or not
+	LastReloadAt *time.Time `json:"last_reload_at"`
+	LastError    string     `json:"last_error,omitempty"`
+
+	// PendingRestart lists changed settings that only take effect after a restart
+	PendingRestart []string `json:"pending_restart" binding:"required"`
+}
+
+// handleGetConfigStatus reports the configuration generation and any
+// changes waiting for a restart
+func (a *API) handleGetConfigStatus(c *gin.Context) {
+	if a.configStatus == nil {
+		a.respond(c, http.StatusOK, ConfigStatus{Generation: 1, PendingRestart: []string{}})
+		return
+	}
+	a.respond(c, http.StatusOK, a.configStatus())
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetConfigStatus(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	get := func(a *API) ConfigStatus {
+		t.Helper()
+		router, err := a.SetupRoutes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/admin/config", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		var status ConfigStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	// Without a reloader the server runs its first generation
+	if status := get(New(logger, nil, Options{})); status.Generation != 1 || status.PendingRestart == nil {
+		t.Errorf("status without a reloader = %+v, want generation 1", status)
+	}
+
+	reloadedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := ConfigStatus{
+		Generation:     3,
+		LoadedAt:       reloadedAt.Add(-time.Hour),
+		LastReloadAt:   &reloadedAt,
+		LastError:      `invalid LOG_LEVEL "loud"`,
+		PendingRestart: []string{"SERVER_ADDR"},
+	}
+	got := get(New(logger, nil, Options{ConfigStatus: func() ConfigStatus { return want }}))
+	if got.Generation != want.Generation || !got.LoadedAt.Equal(want.LoadedAt) ||
+		got.LastReloadAt == nil || !got.LastReloadAt.Equal(reloadedAt) ||
+		got.LastError != want.LastError || !slices.Equal(got.PendingRestart, want.PendingRestart) {
+		t.Errorf("status = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joel-thompson/my-go-service/retention"
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// handleListRetentionRules lists configured retention rules and their next run
+func (a *API) handleListRetentionRules(c *gin.Context) {
+	rules := []retention.RuleInfo{}
+	if a.retention != nil {
+		rules = a.retention.Rules()
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"rules": rules,
+		"total": len(rules),
+	})
+}
+
+// handlePreviewRetentionRule shows which items a rule would affect if it ran now
+func (a *API) handlePreviewRetentionRule(c *gin.Context) {
+	name := c.Param("name")
+
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid limit (expected 1 to 100)",
+			})
+			return
+		}
+		limit = n
+	}
+
+	if a.retention == nil {
+		a.retentionError(c, name, retention.ErrUnknownRule)
+		return
+	}
+	preview, err := a.retention.Preview(c.Request.Context(), name, limit)
+	if err != nil {
+		a.retentionError(c, name, err)
+		return
+	}
+
+	a.respond(c, http.StatusOK, preview)
+}
+
+// handleRunRetentionRule runs a rule immediately and returns the recorded run
+func (a *API) handleRunRetentionRule(c *gin.Context) {
+	name := c.Param("name")
+
+	if a.retention == nil {
+		a.retentionError(c, name, retention.ErrUnknownRule)
+		return
+	}
+	run, err := a.retention.RunRule(c.Request.Context(), name, retention.TriggerManual)
+	if err != nil {
+		a.retentionError(c, name, err)
+		return
+	}
+
+	a.respond(c, http.StatusOK, run)
+}
+
+// handleListRetentionRuns lists recent retention runs
+func (a *API) handleListRetentionRuns(c *gin.Context) {
+	var req storage.ListRetentionRunsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		a.logger.Error("Failed to bind query parameters", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+
+	runs, err := a.store.ListRetentionRuns(c.Request.Context(), req)
+	if err != nil {
+		a.logger.Error("Failed to list retention runs", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list retention runs",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"runs":  runs,
+		"total": len(runs),
+	})
+}
+
+// retentionError maps retention service errors to responses
+func (a *API) retentionError(c *gin.Context, name string, err error) {
+	switch {
+	case errors.Is(err, retention.ErrUnknownRule):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Retention rule not found",
+		})
+	case errors.Is(err, retention.ErrRunInProgress):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Retention rule is already running",
+		})
+	default:
+		a.logger.Error("Retention request failed", "rule", name, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Retention request failed",
+		})
+	}
+}
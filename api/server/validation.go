@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/joel-thompson/my-go-service/validation"
+)
+
+func init() {
+	// Report binding failures under JSON field names rather than Go ones
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// writeBindError responds 400 to a JSON body that could not be decoded or
+// failed its binding tags, listing the fields at fault, or 413 to one that
+// exceeded a size limit
+func (a *API) writeBindError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Request body exceeds the %d byte limit", maxBytesErr.Limit),
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "Invalid request format",
+		"fields": bindingErrors(err),
+	})
+}
+
+// writeValidationError responds 400 with the field errors in err
+func (a *API) writeValidationError(c *gin.Context, err error) {
+	var fields validation.Errors
+	if !errors.As(err, &fields) {
+		fields = validation.Errors{{Rule: validation.RuleInvalid, Message: err.Error()}}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "Validation failed",
+		"fields": fields,
+	})
+}
+
+// writeConstraintError responds 400 when the database refused a value and
+// reports whether it did; the rules in package validation should catch these first
+func (a *API) writeConstraintError(c *gin.Context, err error) bool {
+	var constraintErr *storage.ConstraintError
+	if !errors.As(err, &constraintErr) {
+		return false
+	}
+	a.logger.Warn("Database rejected a value that passed validation",
+		"column", constraintErr.Column, "constraint", constraintErr.Constraint, "code", constraintErr.Code)
+	a.writeValidationError(c, validation.Errors{{
+		Field:   constraintErr.Column,
+		Rule:    validation.RuleInvalid,
+		Message: constraintErr.Message,
+	}})
+	return true
+}
+
+// bindingErrors converts errors from ShouldBindJSON into field errors
+func bindingErrors(err error) validation.Errors {
+	var fieldErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+
+	switch {
+	case errors.As(err, &fieldErrs):
+		errs := make(validation.Errors, 0, len(fieldErrs))
+		for _, fe := range fieldErrs {
+			errs = append(errs, bindingFieldError(fe))
+		}
+		return errs
+	case errors.As(err, &typeErr):
+		return validation.Errors{{
+			Field:   typeErr.Field,
+			Rule:    validation.RuleType,
+			Message: "must be " + jsonKind(typeErr.Type),
+		}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return validation.Errors{{Rule: validation.RuleInvalid, Message: "request body must be a JSON object"}}
+	default:
+		return validation.Errors{{Rule: validation.RuleInvalid, Message: err.Error()}}
+	}
+}
+
+func bindingFieldError(fe validator.FieldError) validation.FieldError {
+	// Namespace is Struct.field.nested; drop the struct name
+	_, field, _ := strings.Cut(fe.Namespace(), ".")
+
+	switch fe.Tag() {
+	case "required":
+		return validation.FieldError{Field: field, Rule: validation.RuleRequired, Message: "is required"}
+	case "max":
+		return validation.FieldError{Field: field, Rule: validation.RuleMaxLength, Message: "must be at most " + fe.Param()}
+	case "oneof":
+		return validation.FieldError{Field: field, Rule: validation.RuleInvalid, Message: "must be one of " + fe.Param()}
+	default:
+		return validation.FieldError{Field: field, Rule: fe.Tag(), Message: fmt.Sprintf("failed the %q rule", fe.Tag())}
+	}
+}
+
+// jsonKind names the JSON type a Go type decodes from
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	default:
+		return "a " + t.String()
+	}
+}
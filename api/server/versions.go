@@ -0,0 +1,177 @@
+package server
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion is one versioned route group, mounted at /v<Number>
+type APIVersion struct {
+	Number int
+
+	// Deprecated and Sunset are set once a newer version replaces this one;
+	// requests to a deprecated version carry Deprecation and Sunset headers
+	Deprecated time.Time
+	Sunset     time.Time
+
+	// Adapt converts a response body from the shared handler types into this
+	// version's DTOs. Nil sends the handler's body unchanged.
+	Adapt func(body any) any
+}
+
+// Prefix returns the path the version is mounted at, such as /v1
+func (v APIVersion) Prefix() string {
+	return "/v" + strconv.Itoa(v.Number)
+}
+
+// apiVersions lists the mounted versions, oldest first; the last one is the
+// latest. To add v2, append it here with an Adapt func if its response shapes
+// differ, and set Deprecated and Sunset on v1; every route in registerRoutes
+// is served under both prefixes by the same handlers.
+var apiVersions = []APIVersion{
+	{Number: 1},
+}
+
+// legacyDeprecated is when the unversioned routes were deprecated in favour of /v1
+var legacyDeprecated = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+// DefaultLegacySunset is when the unversioned routes are due to be removed
+var DefaultLegacySunset = time.Date(2027, 4, 18, 0, 0, 0, 0, time.UTC)
+
+// LegacyRoutes controls the unversioned aliases of the API routes, such as
+// /items for /v1/items, kept so existing clients keep working
+type LegacyRoutes struct {
+	// Disabled stops serving the aliases
+	Disabled bool
+
+	// Sunset is announced in the Sunset header (default DefaultLegacySunset)
+	Sunset time.Time
+}
+
+// apiVersionKey is the gin context key holding the request's *APIVersion
+const apiVersionKey = "api_version"
+
+// versionMiddleware serves a request made under v's prefix. An Accept
+// version parameter naming another version is refused with 406.
+func (a *API) versionMiddleware(v *APIVersion) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requested, ok := acceptVersion(c.GetHeader("Accept")); ok && requested != v.Number {
+			writeNotAcceptable(c, fmt.Sprintf("Accept asks for version %d but the path is %s", requested, v.Prefix()))
+			return
+		}
+		c.Set(apiVersionKey, v)
+		c.Header("API-Version", strconv.Itoa(v.Number))
+		if !v.Deprecated.IsZero() {
+			setDeprecationHeaders(c, v.Deprecated, v.Sunset, apiVersions[len(apiVersions)-1].Prefix()+strings.TrimPrefix(c.Request.URL.Path, v.Prefix()))
+		}
+		c.Next()
+	}
+}
+
+// legacyMiddleware serves an unversioned alias. The version comes from the
+// Accept header's version parameter, defaulting to v1 since that is what
+// clients written before versioning expect. Every response announces the
+// deprecation and links to the versioned path.
+func (a *API) legacyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		number := apiVersions[0].Number
+		if requested, ok := acceptVersion(c.GetHeader("Accept")); ok {
+			number = requested
+		}
+		v := findVersion(number)
+		if v == nil {
+			writeNotAcceptable(c, fmt.Sprintf("API version %d is not available", number))
+			return
+		}
+		c.Set(apiVersionKey, v)
+		c.Header("API-Version", strconv.Itoa(v.Number))
+		setDeprecationHeaders(c, legacyDeprecated, a.legacyRoutes.Sunset, v.Prefix()+c.Request.URL.Path)
+		c.Next()
+	}
+}
+
+// setDeprecationHeaders sets the Deprecation (RFC 9745), Sunset (RFC 8594)
+// and successor-version Link headers
+func setDeprecationHeaders(c *gin.Context, deprecated, sunset time.Time, successor string) {
+	c.Header("Deprecation", "@"+strconv.FormatInt(deprecated.Unix(), 10))
+	if !sunset.IsZero() {
+		c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+}
+
+// respond writes body in the negotiated format (JSON unless the route
+// negotiates formats) after adapting it to the request's API version
+func (a *API) respond(c *gin.Context, status int, body any) {
+	if v, ok := c.Get(apiVersionKey); ok {
+		if adapt := v.(*APIVersion).Adapt; adapt != nil {
+			body = adapt(body)
+		}
+	}
+	if f, ok := c.Get(responseFormatKey); ok {
+		c.Render(status, formatRender{format: f.(*mediaFormat), body: body})
+		return
+	}
+	c.JSON(status, body)
+}
+
+// acceptVersion reads the version parameter of a media range in an Accept
+// header, as in "application/json; version=1" or "application/yaml;
+// version=1". A value that is not a version number yields -1 so it matches
+// nothing.
+func acceptVersion(accept string) (int, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil || (mediaType != "*/*" && lookupFormat(mediaType) == nil) {
+			continue
+		}
+		value, ok := params["version"]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(value, "v"))
+		if err != nil || n < 1 {
+			return -1, true
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+func findVersion(number int) *APIVersion {
+	for i := range apiVersions {
+		if apiVersions[i].Number == number {
+			return &apiVersions[i]
+		}
+	}
+	return nil
+}
+
+func writeNotAcceptable(c *gin.Context, details string) {
+	supported := make([]string, len(apiVersions))
+	for i, v := range apiVersions {
+		supported[i] = strconv.Itoa(v.Number)
+	}
+	c.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{
+		"error":   "Unsupported API version",
+		"details": details + " (supported: " + strings.Join(supported, ", ") + ")",
+	})
+}
+
+var versionPrefixPattern = regexp.MustCompile(`^/v\d+(/|$)`)
+
+// unversionedPath strips a leading /v<N> from a route pattern, so /v1/items
+// and its legacy alias /items share rate limit groups and documentation
+func unversionedPath(path string) string {
+	if loc := versionPrefixPattern.FindStringIndex(path); loc != nil {
+		return "/" + path[loc[1]:]
+	}
+	return path
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// handleCreateWebhook registers a webhook subscription.
+// The signing secret is only returned in this response.
+func (a *API) handleCreateWebhook(c *gin.Context) {
+	var req storage.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		a.logger.Error("Failed to bind request", "error", err)
+		a.writeBindError(c, err)
+		return
+	}
+
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid URL (expected an absolute http or https URL)",
+		})
+		return
+	}
+	for _, eventType := range req.EventTypes {
+		if !storage.ValidWebhookEventType(eventType) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid event type " + eventType + " (expected " + strings.Join(storage.WebhookEventTypes, ", ") + ")",
+			})
+			return
+		}
+	}
+
+	webhook, err := a.store.CreateWebhook(c.Request.Context(), req)
+	if err != nil {
+		a.logger.Error("Failed to create webhook", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create webhook",
+		})
+		return
+	}
+
+	a.logger.Info("Webhook created", "id", webhook.ID, "url", webhook.URL)
+	a.respond(c, http.StatusCreated, storage.CreatedWebhook{
+		WebhookSubscription: *webhook,
+		Secret:              webhook.Secret,
+	})
+}
+
+// handleListWebhooks lists webhook subscriptions without their secrets
+func (a *API) handleListWebhooks(c *gin.Context) {
+	webhooks, err := a.store.ListWebhooks(c.Request.Context())
+	if err != nil {
+		a.logger.Error("Failed to list webhooks", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list webhooks",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"webhooks": webhooks,
+		"total":    len(webhooks),
+	})
+}
+
+// handleGetWebhook retrieves a webhook subscription without its secret
+func (a *API) handleGetWebhook(c *gin.Context) {
+	id, ok := a.parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := a.store.GetWebhook(c.Request.Context(), id)
+	if err != nil {
+		a.webhookStoreError(c, "Failed to get webhook", err)
+		return
+	}
+
+	a.respond(c, http.StatusOK, webhook)
+}
+
+// handleDeleteWebhook deletes a webhook subscription and its delivery log
+func (a *API) handleDeleteWebhook(c *gin.Context) {
+	id, ok := a.parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if _, err := a.store.DeleteWebhook(c.Request.Context(), id); err != nil {
+		a.webhookStoreError(c, "Failed to delete webhook", err)
+		return
+	}
+
+	a.logger.Info("Webhook deleted", "id", id)
+	a.respond(c, http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// handleTestWebhook queues a webhook.test delivery and sends it immediately
+func (a *API) handleTestWebhook(c *gin.Context) {
+	id, ok := a.parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if a.webhooks == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Webhook delivery is not enabled",
+		})
+		return
+	}
+
+	if _, err := a.store.GetWebhook(c.Request.Context(), id); err != nil {
+		a.webhookStoreError(c, "Failed to get webhook", err)
+		return
+	}
+
+	payload, err := json.Marshal(gin.H{
+		"type":        storage.WebhookEventTest,
+		"occurred_at": time.Now().UTC(),
+		"data": gin.H{
+			"message": "This is a test delivery",
+		},
+	})
+	if err != nil {
+		a.logger.Error("Failed to encode test payload", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send test delivery",
+		})
+		return
+	}
+
+	delivery, err := a.store.CreateTestDelivery(c.Request.Context(), id, payload)
+	if err != nil {
+		a.logger.Error("Failed to queue test delivery", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send test delivery",
+		})
+		return
+	}
+
+	// A failed test is retried like any other delivery, so report its state rather than an error
+	delivery, err = a.webhooks.Deliver(c.Request.Context(), delivery.ID)
+	if err != nil {
+		a.logger.Error("Failed to send test delivery", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send test delivery",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, delivery)
+}
+
+// handleListWebhookDeliveries lists a subscription's recent deliveries
+func (a *API) handleListWebhookDeliveries(c *gin.Context) {
+	id, ok := a.parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var req storage.ListDeliveriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		a.logger.Error("Failed to bind query parameters", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters",
+		})
+		return
+	}
+	switch req.Status {
+	case "", storage.DeliveryPending, storage.DeliverySucceeded, storage.DeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status (expected pending, succeeded or dead)",
+		})
+		return
+	}
+
+	if _, err := a.store.GetWebhook(c.Request.Context(), id); err != nil {
+		a.webhookStoreError(c, "Failed to get webhook", err)
+		return
+	}
+
+	deliveries, err := a.store.ListDeliveries(c.Request.Context(), id, req)
+	if err != nil {
+		a.logger.Error("Failed to list deliveries", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list deliveries",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      len(deliveries),
+	})
+}
+
+// handleGetWebhookDelivery retrieves a delivery with its attempt log
+func (a *API) handleGetWebhookDelivery(c *gin.Context) {
+	id, ok := a.parseDeliveryID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := a.store.GetDelivery(c.Request.Context(), id)
+	if err != nil {
+		a.webhookStoreError(c, "Failed to get delivery", err)
+		return
+	}
+
+	attempts, err := a.store.ListDeliveryAttempts(c.Request.Context(), id)
+	if err != nil {
+		a.logger.Error("Failed to list delivery attempts", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get delivery",
+		})
+		return
+	}
+
+	a.respond(c, http.StatusOK, gin.H{
+		"delivery": delivery,
+		"attempts": attempts,
+	})
+}
+
+// handleRedeliverWebhookDelivery requeues a delivery with a fresh retry budget
+func (a *API) handleRedeliverWebhookDelivery(c *gin.Context) {
+	id, ok := a.parseDeliveryID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := a.store.Redeliver(c.Request.Context(), id)
+	if err != nil {
+		a.webhookStoreError(c, "Failed to redeliver", err)
+		return
+	}
+
+	a.logger.Info("Webhook delivery requeued", "id", id)
+	a.respond(c, http.StatusAccepted, delivery)
+}
+
+func (a *API) parseWebhookID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid webhook ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID format",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (a *API) parseDeliveryID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("deliveryId")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		a.logger.Error("Invalid delivery ID", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid delivery ID format",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// webhookStoreError maps "webhook not found" and "delivery not found" to 404
+func (a *API) webhookStoreError(c *gin.Context, message string, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		msg := err.Error()
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": strings.ToUpper(msg[:1]) + msg[1:],
+		})
+		return
+	}
+	a.logger.Error(message, "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": message,
+	})
+}
@@ -0,0 +1,311 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a RESP2 server that understands the commands Redis sends.
+// Keys named "wrongtype:*" answer GET with an error reply, and "drop:*"
+// makes the server hang up without replying.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu    sync.Mutex
+	data  map[string][]byte
+	ttls  map[string]time.Duration
+	conns []net.Conn
+	dials int
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{
+		listener: listener,
+		password: password,
+		data:     map[string][]byte{},
+		ttls:     map[string]time.Duration{},
+	}
+	go f.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		f.dropConnections()
+	})
+	return f
+}
+
+func (f *fakeRedis) addr() string { return f.listener.Addr().String() }
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns = append(f.conns, conn)
+		f.dials++
+		f.mu.Unlock()
+		go f.handle(conn)
+	}
+}
+
+// dropConnections closes every open connection, as a server restart would
+func (f *fakeRedis) dropConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeRedis) ttl(key string) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ttls[key]
+}
+
+func (f *fakeRedis) dialCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dials
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		if cmd == "AUTH" {
+			if len(args) != 2 || args[1] != f.password {
+				fmt.Fprint(conn, "-WRONGPASS invalid username-password pair\r\n")
+				continue
+			}
+			authed = true
+			fmt.Fprint(conn, "+OK\r\n")
+			continue
+		}
+		if !authed {
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		if len(args) > 1 && strings.HasPrefix(args[1], "drop:") {
+			return
+		}
+		f.exec(conn, cmd, args[1:])
+	}
+}
+
+func (f *fakeRedis) exec(w io.Writer, cmd string, args []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch cmd {
+	case "PING":
+		fmt.Fprint(w, "+PONG\r\n")
+	case "SELECT":
+		fmt.Fprint(w, "+OK\r\n")
+	case "GET":
+		if strings.HasPrefix(args[0], "wrongtype:") {
+			fmt.Fprint(w, "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n")
+			return
+		}
+		value, ok := f.data[args[0]]
+		if !ok {
+			fmt.Fprint(w, "$-1\r\n")
+			return
+		}
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		f.data[args[0]] = []byte(args[1])
+		delete(f.ttls, args[0])
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			ms, _ := strconv.Atoi(args[3])
+			f.ttls[args[0]] = time.Duration(ms) * time.Millisecond
+		}
+		fmt.Fprint(w, "+OK\r\n")
+	case "DEL":
+		deleted := 0
+		for _, key := range args {
+			if _, ok := f.data[key]; ok {
+				delete(f.data, key)
+				deleted++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", deleted)
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", cmd)
+	}
+}
+
+// readCommand parses one RESP array of bulk strings
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	reply, err := readReply(rd)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("not a command: %v", reply)
+	}
+	args := make([]string, len(items))
+	for i, item := range items {
+		b, ok := item.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("argument %d is %T", i, item)
+		}
+		args[i] = string(b)
+	}
+	return args, nil
+}
+
+func TestRedisGetSetDelete(t *testing.T) {
+	fake := newFakeRedis(t, "")
+	client := NewRedis(RedisConfig{Addr: fake.addr(), DB: 2})
+	defer client.Close()
+	ctx := context.Background()
+
+	if _, ok, err := client.Get(ctx, "items:v1:a"); err != nil || ok {
+		t.Fatalf("Get missing key: ok = %v, err = %v", ok, err)
+	}
+
+	value := []byte("{\"name\":\"Widget\"}\r\nwith a CRLF inside")
+	if err := client.Set(ctx, "items:v1:a", value, 90*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := client.Get(ctx, "items:v1:a")
+	if err != nil || !ok || string(got) != string(value) {
+		t.Fatalf("Get = %q, %v, %v; want %q", got, ok, err, value)
+	}
+	if ttl := fake.ttl("items:v1:a"); ttl != 90*time.Second {
+		t.Errorf("TTL sent as %s, want 1m30s", ttl)
+	}
+
+	if err := client.Set(ctx, "items:v1:b", []byte("b"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Delete(ctx, "items:v1:a", "items:v1:b", "items:v1:missing"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"items:v1:a", "items:v1:b"} {
+		if _, ok, err := client.Get(ctx, key); err != nil || ok {
+			t.Errorf("Get %s after Delete: ok = %v, err = %v", key, ok, err)
+		}
+	}
+	if err := client.Delete(ctx); err != nil {
+		t.Errorf("Delete with no keys: %v", err)
+	}
+
+	if err := client.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.dialCount(); n != 1 {
+		t.Errorf("client dialed %d times, want 1 pooled connection", n)
+	}
+}
+
+func TestRedisAuth(t *testing.T) {
+	fake := newFakeRedis(t, "s3cret")
+	ctx := context.Background()
+
+	client := NewRedis(RedisConfig{Addr: fake.addr(), Password: "s3cret"})
+	defer client.Close()
+	if err := client.Set(ctx, "k", []byte("v"), 0); err != nil {
+		t.Fatalf("Set with the right password: %v", err)
+	}
+
+	var replyErr redisError
+	wrong := NewRedis(RedisConfig{Addr: fake.addr(), Password: "guess"})
+	defer wrong.Close()
+	if err := wrong.Ping(ctx); !errors.As(err, &replyErr) || !strings.HasPrefix(string(replyErr), "WRONGPASS") {
+		t.Fatalf("Ping with the wrong password: err = %v, want WRONGPASS", err)
+	}
+
+	none := NewRedis(RedisConfig{Addr: fake.addr()})
+	defer none.Close()
+	if _, _, err := none.Get(ctx, "k"); !errors.As(err, &replyErr) || !strings.HasPrefix(string(replyErr), "NOAUTH") {
+		t.Fatalf("Get without a password: err = %v, want NOAUTH", err)
+	}
+}
+
+func TestRedisErrorReplyKeepsConnection(t *testing.T) {
+	fake := newFakeRedis(t, "")
+	client := NewRedis(RedisConfig{Addr: fake.addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	var replyErr redisError
+	if _, _, err := client.Get(ctx, "wrongtype:list"); !errors.As(err, &replyErr) {
+		t.Fatalf("Get: err = %v, want an error reply", err)
+	}
+	if err := client.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.dialCount(); n != 1 {
+		t.Errorf("client dialed %d times, want the connection reused after an error reply", n)
+	}
+}
+
+func TestRedisConnectionLoss(t *testing.T) {
+	fake := newFakeRedis(t, "")
+	client := NewRedis(RedisConfig{Addr: fake.addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := client.Set(ctx, "k", []byte("v"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pooled connection is dead; the failing command discards it
+	fake.dropConnections()
+	if _, _, err := client.Get(ctx, "k"); err == nil {
+		t.Fatal("Get on a dropped connection succeeded")
+	}
+	if _, ok, err := client.Get(ctx, "k"); err != nil || !ok {
+		t.Fatalf("Get after reconnecting: ok = %v, err = %v", ok, err)
+	}
+
+	// The server hangs up mid-command
+	if _, _, err := client.Get(ctx, "drop:k"); err == nil {
+		t.Fatal("Get succeeded although the server hung up")
+	}
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping after a hang-up: %v", err)
+	}
+	if n := fake.dialCount(); n != 3 {
+		t.Errorf("client dialed %d times, want 3", n)
+	}
+
+	// Nothing is listening any more
+	fake.listener.Close()
+	fake.dropConnections()
+	for range 2 { // the pooled connection fails first, then the dial
+		if err := client.Ping(ctx); err == nil {
+			t.Fatal("Ping succeeded with the server gone")
+		}
+	}
+
+	client.Close()
+	if err := client.Ping(ctx); !errors.Is(err, errRedisClosed) {
+		t.Fatalf("Ping after Close: err = %v, want errRedisClosed", err)
+	}
+}
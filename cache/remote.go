@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Remote is a cache shared between server instances, such as Redis.
+// Errors are treated as misses by the caching store, so a remote outage
+// only costs latency.
+type Remote interface {
+	// Get returns the value for key; ok is false when the key does not exist
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys; missing keys are not an error
+	Delete(ctx context.Context, keys ...string) error
+	// Close releases connections
+	Close() error
+}
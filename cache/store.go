@@ -0,0 +1,440 @@
+// Package cache adds read-through caching in front of item reads.
+//
+// Store wraps a *storage.Store and serves GetItem from an in-process LRU,
+// then an optional shared Remote (such as Redis), then Postgres. ListItems
+// pages are cached locally for a shorter TTL. Concurrent misses for the same
+// key are coalesced so a hot item costs one query.
+//
+// Writes through the Store invalidate the local and remote entries as soon as
+// they commit. Changes made by other instances (or by retention and other
+// paths that bypass the Store) arrive through the item event broker, which is
+// fed by Postgres LISTEN/NOTIFY, so every instance drops its copy within the
+// broker's delivery latency.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/joel-thompson/my-go-service/events"
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const (
+	// remoteTimeout bounds each remote cache call so a slow cache never costs more than a query
+	remoteTimeout = 250 * time.Millisecond
+	// invalidationBuffer is how many item events may queue before the broker drops the subscription
+	invalidationBuffer = 1024
+	// resubscribeDelay is the wait before resubscribing after the broker dropped us
+	resubscribeDelay = time.Second
+	// itemKeyPrefix namespaces item entries in the remote cache
+	itemKeyPrefix = "items:v1:"
+)
+
+// Cache counters for this process, reported by Stats and GET /cache/stats
+var (
+	hitsLocal     atomic.Int64
+	hitsRemote    atomic.Int64
+	misses        atomic.Int64
+	coalesced     atomic.Int64
+	invalidations atomic.Int64
+	evictions     atomic.Int64
+	remoteErrors  atomic.Int64
+)
+
+// Config holds cache settings
+type Config struct {
+	// Size is the maximum number of items (and, separately, list pages) kept in memory
+	Size int
+	// TTL is how long an item stays in the local cache
+	TTL time.Duration
+	// ListTTL is how long a list page stays in the local cache
+	ListTTL time.Duration
+	// Remote is an optional cache shared between instances
+	Remote Remote
+	// RemoteTTL is how long an item stays in the remote cache
+	RemoteTTL time.Duration
+}
+
+// Stats is a point-in-time view of the cache
+type Stats struct {
+	Items         int   `json:"items"`
+	Lists         int   `json:"lists"`
+	Remote        bool  `json:"remote"`
+	HitsLocal     int64 `json:"hits_local"`
+	HitsRemote    int64 `json:"hits_remote"`
+	Misses        int64 `json:"misses"`
+	Coalesced     int64 `json:"coalesced"`
+	Invalidations int64 `json:"invalidations"`
+	Evictions     int64 `json:"evictions"`
+	RemoteErrors  int64 `json:"remote_errors"`
+}
+
+// Store is a caching decorator around *storage.Store. Methods it does not
+// override go straight to the database.
+type Store struct {
+	*storage.Store
+
+	logger *slog.Logger
+	broker *events.Broker
+	config Config
+
+	items  *LRU[uuid.UUID, []byte]
+	lists  *LRU[string, []byte]
+	flight singleflight.Group
+
+	// generation is bumped on every invalidation; a fill that started before
+	// the bump is discarded so a slow read cannot cache a value older than
+	// the change that invalidated it
+	generation atomic.Uint64
+}
+
+// NewStore creates a caching store. broker may be nil, in which case only
+// writes made through this Store invalidate entries.
+func NewStore(logger *slog.Logger, store *storage.Store, broker *events.Broker, config Config) *Store {
+	if config.Size <= 0 {
+		config.Size = 10000
+	}
+	if config.TTL <= 0 {
+		config.TTL = 30 * time.Second
+	}
+	if config.ListTTL <= 0 {
+		config.ListTTL = 5 * time.Second
+	}
+	if config.RemoteTTL <= 0 {
+		config.RemoteTTL = 5 * time.Minute
+	}
+
+	s := &Store{
+		Store:  store,
+		logger: logger,
+		broker: broker,
+		config: config,
+		items:  NewLRU[uuid.UUID, []byte](config.Size),
+		lists:  NewLRU[string, []byte](config.Size),
+	}
+	s.items.onEvict = func() { evictions.Add(1) }
+	s.lists.onEvict = func() { evictions.Add(1) }
+	return s
+}
+
+// GetItem returns an item from the local cache, the remote cache or the database, in that order
+func (s *Store) GetItem(ctx context.Context, id uuid.UUID) (*storage.Item, error) {
+	if data, ok := s.items.Get(id); ok {
+		hitsLocal.Add(1)
+		return decode[storage.Item](data)
+	}
+
+	data, err := s.coalesce(ctx, "item:"+id.String(), func(ctx context.Context) ([]byte, error) {
+		generation := s.generation.Load()
+
+		if data, ok := s.getRemote(ctx, id); ok {
+			hitsRemote.Add(1)
+			s.fillItem(generation, id, data)
+			return data, nil
+		}
+
+		misses.Add(1)
+		item, err := s.Store.GetItem(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		if s.fillItem(generation, id, data) {
+			s.setRemote(ctx, generation, id, data)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decode[storage.Item](data)
+}
+
+// ListItems returns a page of items, cached locally for ListTTL
+func (s *Store) ListItems(ctx context.Context, req storage.ListItemsRequest) (*storage.ListItemsResponse, error) {
+	key := listKey(req)
+	if data, ok := s.lists.Get(key); ok {
+		hitsLocal.Add(1)
+		return decode[storage.ListItemsResponse](data)
+	}
+
+	data, err := s.coalesce(ctx, "list:"+key, func(ctx context.Context) ([]byte, error) {
+		generation := s.generation.Load()
+
+		misses.Add(1)
+		response, err := s.Store.ListItems(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(response)
+		if err != nil {
+			return nil, err
+		}
+		if s.generation.Load() == generation {
+			s.lists.Set(key, data, s.config.ListTTL)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decode[storage.ListItemsResponse](data)
+}
+
+// CreateItem creates an item and drops cached list pages
+func (s *Store) CreateItem(ctx context.Context, req storage.CreateItemRequest) (*storage.Item, error) {
+	item, err := s.Store.CreateItem(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.Invalidate(ctx, item.ID)
+	return item, nil
+}
+
+// UpdateItem updates an item and drops its cached copies
+func (s *Store) UpdateItem(ctx context.Context, id uuid.UUID, req storage.UpdateItemRequest) (*storage.Item, error) {
+	item, err := s.Store.UpdateItem(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	s.Invalidate(ctx, id)
+	return item, nil
+}
+
+// DeleteItem deletes an item and drops its cached copies
+func (s *Store) DeleteItem(ctx context.Context, id uuid.UUID) (*storage.Item, []string, error) {
+	item, blobKeys, err := s.Store.DeleteItem(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Invalidate(ctx, id)
+	return item, blobKeys, nil
+}
+
+// TransitionItem changes an item's status and drops its cached copies
+func (s *Store) TransitionItem(ctx context.Context, id uuid.UUID, transition storage.Transition) (*storage.Item, error) {
+	item, err := s.Store.TransitionItem(ctx, id, transition)
+	if err != nil {
+		return nil, err
+	}
+	s.Invalidate(ctx, id)
+	return item, nil
+}
+
+// Invalidate drops an item from the local and remote caches along with every cached list page
+func (s *Store) Invalidate(ctx context.Context, id uuid.UUID) {
+	s.generation.Add(1)
+	invalidations.Add(1)
+	s.items.Delete(id)
+	s.lists.Purge()
+
+	if s.config.Remote == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), remoteTimeout)
+	defer cancel()
+	if err := s.config.Remote.Delete(ctx, itemKeyPrefix+id.String()); err != nil {
+		remoteErrors.Add(1)
+		s.logger.Warn("Failed to invalidate remote cache entry", "item_id", id, "error", err)
+	}
+}
+
+// Purge drops every locally cached entry
+func (s *Store) Purge() {
+	s.generation.Add(1)
+	s.items.Purge()
+	s.lists.Purge()
+}
+
+// Stats returns entry counts and the process-wide hit/miss counters
+func (s *Store) Stats() Stats {
+	return Stats{
+		Items:         s.items.Len(),
+		Lists:         s.lists.Len(),
+		Remote:        s.config.Remote != nil,
+		HitsLocal:     hitsLocal.Load(),
+		HitsRemote:    hitsRemote.Load(),
+		Misses:        misses.Load(),
+		Coalesced:     coalesced.Load(),
+		Invalidations: invalidations.Load(),
+		Evictions:     evictions.Load(),
+		RemoteErrors:  remoteErrors.Load(),
+	}
+}
+
+// Run invalidates entries for item changes seen by the broker until ctx is
+// cancelled. If the broker drops the subscription (because this loop fell
+// behind), the local cache is flushed before resubscribing since events may
+// have been missed.
+func (s *Store) Run(ctx context.Context) {
+	if s.broker == nil {
+		<-ctx.Done()
+		return
+	}
+
+	for {
+		sub := s.broker.Subscribe(storage.ItemEventFilter{}, invalidationBuffer)
+		s.consume(ctx, sub)
+		s.broker.Unsubscribe(sub)
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.logger.Warn("Cache invalidation subscription dropped, flushing local cache")
+		s.Purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// consume applies events until the subscription closes or ctx is cancelled
+func (s *Store) consume(ctx context.Context, sub *events.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			s.Invalidate(ctx, event.ItemID)
+		}
+	}
+}
+
+// coalesce runs fill once per key across concurrent callers. The shared call
+// is detached from any one caller's cancellation; each caller still stops
+// waiting when its own context ends.
+func (s *Store) coalesce(ctx context.Context, key string, fill func(context.Context) ([]byte, error)) ([]byte, error) {
+	detached := context.WithoutCancel(ctx)
+	ch := s.flight.DoChan(key, func() (any, error) {
+		return fill(detached)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		if result.Shared {
+			coalesced.Add(1)
+		}
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.([]byte), nil
+	}
+}
+
+// fillItem caches data locally unless an invalidation happened since generation was read
+func (s *Store) fillItem(generation uint64, id uuid.UUID, data []byte) bool {
+	if s.generation.Load() != generation {
+		return false
+	}
+	s.items.Set(id, data, s.config.TTL)
+	return true
+}
+
+func (s *Store) getRemote(ctx context.Context, id uuid.UUID) ([]byte, bool) {
+	if s.config.Remote == nil {
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+
+	data, ok, err := s.config.Remote.Get(ctx, itemKeyPrefix+id.String())
+	if err != nil {
+		remoteErrors.Add(1)
+		s.logger.Warn("Failed to read remote cache", "item_id", id, "error", err)
+		return nil, false
+	}
+	return data, ok
+}
+
+// setRemote shares a value read from the database with other instances. An
+// invalidation that lands while the write is in flight may have deleted the
+// key before the write reached the remote, so the write is taken back when
+// generation moved; otherwise a stale value would outlive the change for
+// every instance until RemoteTTL.
+func (s *Store) setRemote(ctx context.Context, generation uint64, id uuid.UUID, data []byte) {
+	if s.config.Remote == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+
+	key := itemKeyPrefix + id.String()
+	if err := s.config.Remote.Set(ctx, key, data, s.config.RemoteTTL); err != nil {
+		remoteErrors.Add(1)
+		s.logger.Warn("Failed to write remote cache", "item_id", id, "error", err)
+		return
+	}
+	if s.generation.Load() == generation {
+		return
+	}
+	if err := s.config.Remote.Delete(ctx, key); err != nil {
+		remoteErrors.Add(1)
+		s.logger.Warn("Failed to withdraw remote cache entry", "item_id", id, "error", err)
+	}
+}
+
+// listKey renders a list request canonically, applying the store's paging
+// defaults. It is JSON, so no value can run into the next part, and the
+// statuses and metadata filters are sorted since their order does not
+// change the result.
+func listKey(req storage.ListItemsRequest) string {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	limit = min(limit, 100)
+
+	filters := make([]string, len(req.MetadataFilters))
+	for i, filter := range req.MetadataFilters {
+		// Values are parsed from query strings, so they always encode
+		data, _ := json.Marshal(filter)
+		filters[i] = string(data)
+	}
+	slices.Sort(filters)
+
+	key, _ := json.Marshal(struct {
+		Limit      int      `json:"limit"`
+		Offset     int      `json:"offset"`
+		Status     []string `json:"status"`
+		Fields     []string `json:"fields"`
+		CountTotal bool     `json:"count_total"`
+		Filters    []string `json:"filters"`
+	}{
+		Limit:      limit,
+		Offset:     max(req.Offset, 0),
+		Status:     slices.Sorted(slices.Values(req.Status)),
+		Fields:     req.Fields,
+		CountTotal: req.CountTotal(),
+		Filters:    filters,
+	})
+	return string(key)
+}
+
+// decode returns a fresh copy of a cached value so callers may modify it
+func decode[T any](data []byte) (*T, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode cached value: %w", err)
+	}
+	return &v, nil
+}
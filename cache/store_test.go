@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// memoryRemote is a Remote backed by a map; beforeSet runs while a Set is in
+// flight, before its value lands
+type memoryRemote struct {
+	mu        sync.Mutex
+	data      map[string][]byte
+	beforeSet func()
+}
+
+func (m *memoryRemote) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.data[key]
+	return value, ok, nil
+}
+
+func (m *memoryRemote) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if m.beforeSet != nil {
+		m.beforeSet()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func (m *memoryRemote) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.data, key)
+	}
+	return nil
+}
+
+func (m *memoryRemote) Close() error { return nil }
+
+func newTestStore(remote Remote) *Store {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewStore(logger, nil, nil, Config{Remote: remote})
+}
+
+func TestSetRemote(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	key := itemKeyPrefix + id.String()
+
+	t.Run("Shares the fill", func(t *testing.T) {
+		remote := &memoryRemote{data: map[string][]byte{}}
+		s := newTestStore(remote)
+		s.setRemote(ctx, s.generation.Load(), id, []byte(`{"name":"old"}`))
+		if _, ok, _ := remote.Get(ctx, key); !ok {
+			t.Fatal("remote entry missing after setRemote")
+		}
+	})
+
+	t.Run("Withdraws after an invalidation during the write", func(t *testing.T) {
+		remote := &memoryRemote{data: map[string][]byte{}}
+		s := newTestStore(remote)
+		// Another replica changes the item and its event arrives, deleting
+		// the key, while the stale value is still on its way to the remote
+		remote.beforeSet = func() {
+			remote.beforeSet = nil
+			s.Invalidate(ctx, id)
+		}
+		s.setRemote(ctx, s.generation.Load(), id, []byte(`{"name":"old"}`))
+		if value, ok, _ := remote.Get(ctx, key); ok {
+			t.Fatalf("stale remote entry %s survived the invalidation", value)
+		}
+	})
+
+	t.Run("Remote hit fills locally", func(t *testing.T) {
+		remote := &memoryRemote{data: map[string][]byte{key: []byte(`{"name":"shared"}`)}}
+		s := newTestStore(remote)
+		item, err := s.GetItem(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Name != "shared" {
+			t.Fatalf("name = %q, want shared", item.Name)
+		}
+		if _, ok := s.items.Get(id); !ok {
+			t.Fatal("remote hit was not cached locally")
+		}
+	})
+}
+
+func TestListKey(t *testing.T) {
+	filters := func(t *testing.T, pairs ...string) []storage.MetadataFilter {
+		t.Helper()
+		var out []storage.MetadataFilter
+		for i := 0; i < len(pairs); i += 2 {
+			filter, err := storage.ParseMetadataQuery(pairs[i], pairs[i+1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, filter)
+		}
+		return out
+	}
+
+	// A value holding the separator must not pass for a second filter
+	one := listKey(storage.ListItemsRequest{MetadataFilters: filters(t, "meta.color", "red|x=1")})
+	two := listKey(storage.ListItemsRequest{MetadataFilters: filters(t, "meta.color", "red", "meta.x", "1")})
+	if one == two {
+		t.Errorf("meta.color=red|x=1 and meta.color=red&meta.x=1 share the key %s", one)
+	}
+
+	// Nor may a status or field list absorb the next part
+	if listKey(storage.ListItemsRequest{Status: []string{"draft|1"}}) == listKey(storage.ListItemsRequest{Status: []string{"draft"}, Limit: 1}) {
+		t.Error("a status containing | collides with another request")
+	}
+
+	// Order does not change the result, so it does not change the key
+	ordered := listKey(storage.ListItemsRequest{
+		Status:          []string{"draft", "published"},
+		MetadataFilters: filters(t, "meta.color", "red", "meta.size>", "10"),
+	})
+	reversed := listKey(storage.ListItemsRequest{
+		Status:          []string{"published", "draft"},
+		MetadataFilters: filters(t, "meta.size>", "10", "meta.color", "red"),
+	})
+	if ordered != reversed {
+		t.Errorf("reordered request keys differ:\n%s\n%s", ordered, reversed)
+	}
+
+	// Defaults are applied, and values of different types stay apart
+	if listKey(storage.ListItemsRequest{}) != listKey(storage.ListItemsRequest{Limit: 10}) {
+		t.Error("the default limit gives a different key from limit=10")
+	}
+	if listKey(storage.ListItemsRequest{MetadataFilters: filters(t, "meta.size", "10")}) ==
+		listKey(storage.ListItemsRequest{MetadataFilters: filters(t, "meta.size", `"10"`)}) {
+		t.Error("a number and a string share a key")
+	}
+}
@@ -0,0 +1,213 @@
+package apiclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache stores GET responses so they can be revalidated with If-None-Match
+// and If-Modified-Since instead of downloaded again. Implementations must be
+// safe for concurrent use; failures are treated as misses.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// WithCache keeps successful GET responses in cache. An entry is reused
+// without asking the server while its Cache-Control max-age lasts, and
+// otherwise revalidated, so a 304 Not Modified answer returns the stored
+// body. Responses marked no-store or without an ETag or Last-Modified are
+// not kept, and a successful PUT, PATCH, POST or DELETE drops the cached
+// GET of its URL.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// DiskCache is a Cache with one file per entry under a directory
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates dir if needed and returns a cache that keeps its
+// entries there. Entries may hold API responses, so the directory and files
+// are readable by the current user only.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get reads the entry stored under key
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(filepath.Join(d.dir, key))
+	return data, err == nil
+}
+
+// Set replaces the entry under key. It writes a temporary file and renames
+// it so concurrent readers never see a partial entry.
+func (d *DiskCache) Set(key string, value []byte) {
+	tmp, err := os.CreateTemp(d.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(value)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(d.dir, key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Delete removes the entry under key
+func (d *DiskCache) Delete(key string) {
+	os.Remove(filepath.Join(d.dir, key))
+}
+
+// Clear removes every entry
+func (d *DiskCache) Clear() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Remove(filepath.Join(d.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheEntry is a stored response with the validators needed to revalidate it
+type cacheEntry struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	// MaxAge is how long the entry may be reused without revalidating
+	MaxAge time.Duration `json:"max_age"`
+	Body   []byte        `json:"body"`
+}
+
+// fresh reports whether the entry may be used without asking the server
+func (e *cacheEntry) fresh() bool {
+	return e.MaxAge > 0 && time.Since(e.StoredAt) < e.MaxAge
+}
+
+// cacheKey identifies a GET by its URL, the representation asked for and
+// the credentials sent, so callers with different keys never share entries
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	for _, part := range []string{
+		req.URL.String(),
+		req.Header.Get("Accept"),
+		req.Header.Get("Authorization"),
+		req.Header.Get("X-API-Key"),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadCached returns the entry stored for key, if it can be decoded
+func (c *Client) loadCached(key string) (*cacheEntry, bool) {
+	data, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		c.cache.Delete(key)
+		return nil, false
+	}
+	return &entry, true
+}
+
+// storeCached keeps body under key when resp allows it, or drops any
+// existing entry when it does not
+func (c *Client) storeCached(key string, resp *http.Response, body []byte) {
+	maxAge, store := cacheLifetime(resp.Header.Get("Cache-Control"))
+	entry := cacheEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StoredAt:     time.Now(),
+		MaxAge:       maxAge,
+		Body:         body,
+	}
+	if !store || (entry.ETag == "" && entry.LastModified == "") {
+		c.cache.Delete(key)
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.cache.Set(key, data)
+}
+
+// invalidateCached drops the cached GETs of the URL a successful unsafe
+// request changed, in every representation, so an update or delete is not
+// hidden by a copy still within its max-age
+func (c *Client) invalidateCached(req *http.Request) {
+	get := req.Clone(req.Context())
+	for _, mediaType := range []string{MediaTypeJSON, MediaTypeYAML, MediaTypeMsgPack, MediaTypeCSV} {
+		get.Header.Set("Accept", mediaType)
+		c.cache.Delete(cacheKey(get))
+	}
+}
+
+// refreshCached records a 304 answer: the entry is current again, under any
+// validators and max-age the server sent with it
+func (c *Client) refreshCached(key string, entry *cacheEntry, resp *http.Response) {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		entry.ETag = etag
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		entry.LastModified = lastModified
+	}
+	if cacheControl := resp.Header.Get("Cache-Control"); cacheControl != "" {
+		entry.MaxAge, _ = cacheLifetime(cacheControl)
+	}
+	entry.StoredAt = time.Now()
+	if data, err := json.Marshal(entry); err == nil {
+		c.cache.Set(key, data)
+	}
+}
+
+// cacheLifetime reads a Cache-Control header: how long a response may be
+// reused without revalidation, and whether it may be stored at all
+func cacheLifetime(header string) (time.Duration, bool) {
+	var maxAge time.Duration
+	revalidate := false
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			return 0, false
+		case "no-cache":
+			revalidate = true
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil {
+				maxAge = time.Duration(max(seconds, 0)) * time.Second
+			}
+		}
+	}
+	if revalidate {
+		return 0, true
+	}
+	return maxAge, true
+}
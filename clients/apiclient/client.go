@@ -0,0 +1,368 @@
+// Package apiclient is a typed Go client for the my-go-service HTTP API.
+//
+// It depends only on the standard library and google/uuid, so other services
+// can import it without pulling in the server's database drivers.
+//
+//	client, err := apiclient.New("http://localhost:8080",
+//		apiclient.WithAPIVersion("v1"),
+//		apiclient.WithAPIKey(os.Getenv("API_KEY")),
+//		apiclient.WithTimeout(5*time.Second),
+//	)
+//	item, err := client.GetItem(ctx, id)
+//	if errors.Is(err, apiclient.ErrNotFound) { ... }
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds each attempt of a request
+	DefaultTimeout = 30 * time.Second
+	// DefaultRetries is how many times a failed request is retried
+	DefaultRetries = 3
+	// maxRetryWait caps a single wait between attempts, including Retry-After
+	maxRetryWait = time.Minute
+	// baseRetryWait is the first backoff when the server gives no Retry-After
+	baseRetryWait = 500 * time.Millisecond
+)
+
+// Media types DoFormat can ask for
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeYAML    = "application/yaml"
+	MediaTypeMsgPack = "application/msgpack"
+	MediaTypeCSV     = "text/csv"
+)
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	retries    int
+	userAgent  string
+	auth       []func(*http.Request) error
+	logger     *slog.Logger
+	apiVersion string
+	cache      Cache
+
+	onDeprecation   func(Deprecation)
+	deprecationOnce sync.Once
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient uses hc instead of a client built from http.DefaultTransport.
+// WithTimeout still applies and overrides hc.Timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		clone := *hc
+		clone.Timeout = c.httpClient.Timeout
+		c.httpClient = &clone
+	}
+}
+
+// WithTimeout bounds each attempt of a request; zero disables the timeout
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = d
+	}
+}
+
+// WithRetries sets how many times a request is retried after a retryable
+// failure; zero disables retries
+func WithRetries(n int) Option {
+	return func(c *Client) {
+		c.retries = max(n, 0)
+	}
+}
+
+// WithAPIKey sends key in the X-API-Key header
+func WithAPIKey(key string) Option {
+	return WithAuth(func(req *http.Request) error {
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		return nil
+	})
+}
+
+// WithBearerToken sends token in the Authorization header
+func WithBearerToken(token string) Option {
+	return WithAuth(func(req *http.Request) error {
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return nil
+	})
+}
+
+// WithAuth adds a function that decorates every outgoing request, for
+// example to sign it or attach a freshly refreshed token. It runs before
+// each attempt.
+func WithAuth(fn func(*http.Request) error) Option {
+	return func(c *Client) {
+		c.auth = append(c.auth, fn)
+	}
+}
+
+// WithUserAgent overrides the User-Agent header
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// WithLogger logs each attempt at debug level
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// New creates a client for the API at baseURL, such as "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		retries:    DefaultRetries,
+		userAgent:  "my-go-service-apiclient",
+		apiVersion: DefaultAPIVersion,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := validAPIVersion(c.apiVersion); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// BaseURL returns the API address the client was created with
+func (c *Client) BaseURL() string {
+	return c.baseURL.String()
+}
+
+// Do sends a request to path, under the client's API version, with in
+// encoded as the JSON body (if non-nil) and decodes a successful response
+// into out (if non-nil). A non-2xx response is returned as an *APIError. It
+// is the escape hatch for endpoints without a typed method.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	respBody, err := c.DoFormat(ctx, method, path, query, in, MediaTypeJSON)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if raw, ok := out.(*json.RawMessage); ok {
+		*raw = respBody
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// DoFormat is Do for responses in another representation: it asks for
+// mediaType, such as MediaTypeYAML, and returns the response body as sent.
+// Item endpoints serve JSON, YAML and MessagePack, and GET /items also CSV;
+// others answer 406 Not Acceptable to anything but JSON. Request bodies are
+// still sent as JSON.
+func (c *Client) DoFormat(ctx context.Context, method, path string, query url.Values, in any, mediaType string) ([]byte, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	u := c.baseURL.JoinPath(c.apiVersion, path)
+	u.RawQuery = query.Encode()
+
+	return c.send(ctx, method, u.String(), body, mediaType)
+}
+
+// send performs the request, retrying retryable failures, and returns the body of a 2xx response
+func (c *Client) send(ctx context.Context, method, target string, body []byte, accept string) ([]byte, error) {
+	wait := baseRetryWait
+	for attempt := 0; ; attempt++ {
+		respBody, retryAfter, err := c.attempt(ctx, method, target, body, accept)
+		if err == nil {
+			return respBody, nil
+		}
+		if attempt >= c.retries || !retryable(method, err) {
+			return nil, err
+		}
+
+		delay := retryAfter
+		if delay <= 0 {
+			// Jitter (±50%) keeps a crowd of clients from retrying in lockstep
+			delay = time.Duration(rand.Int64N(int64(wait))) + wait/2
+			wait = min(wait*2, maxRetryWait)
+		}
+		delay = min(delay, maxRetryWait)
+		c.log(ctx, "Retrying request", "method", method, "url", target, "attempt", attempt+1, "wait", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// attempt sends one request. It returns the server's Retry-After along with any error.
+func (c *Client) attempt(ctx context.Context, method, target string, body []byte, accept string) ([]byte, time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", MediaTypeJSON)
+	}
+	for _, auth := range c.auth {
+		if err := auth(req); err != nil {
+			return nil, 0, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+
+	// Reuse or revalidate a cached copy of a GET
+	var (
+		key    string
+		cached *cacheEntry
+	)
+	if c.cache != nil && method == http.MethodGet {
+		key = cacheKey(req)
+		if entry, ok := c.loadCached(key); ok {
+			if entry.fresh() {
+				c.log(ctx, "Served from cache", "method", method, "url", target)
+				return entry.Body, 0, nil
+			}
+			cached = entry
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.log(ctx, "Request failed", "method", method, "url", target, "error", err)
+		return nil, 0, &TransportError{Err: err}
+	}
+	defer resp.Body.Close()
+	c.notifyDeprecation(resp)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, &TransportError{Err: err}
+	}
+	c.log(ctx, "Request finished", "method", method, "url", target, "status", resp.StatusCode, "duration", time.Since(start))
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		c.refreshCached(key, cached, resp)
+		return cached.Body, 0, nil
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if key != "" {
+			c.storeCached(key, resp, respBody)
+		} else if c.cache != nil && !safe(method) {
+			c.invalidateCached(req)
+		}
+		return respBody, 0, nil
+	}
+	apiErr := newAPIError(resp, respBody)
+	return nil, apiErr.RetryAfter, apiErr
+}
+
+func (c *Client) log(ctx context.Context, msg string, args ...any) {
+	if c.logger != nil {
+		c.logger.DebugContext(ctx, msg, args...)
+	}
+}
+
+// retryable reports whether a failed request may be sent again. Rate-limited
+// requests were never processed, so they are always safe to retry; other
+// failures are retried only for idempotent methods, since a POST may have
+// taken effect before the connection dropped.
+func retryable(method string, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return true
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return idempotent(method)
+		}
+		return false
+	}
+	var transportErr *TransportError
+	return errors.As(err, &transportErr) && idempotent(method)
+}
+
+// safe reports whether method leaves the server's resources unchanged
+func safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
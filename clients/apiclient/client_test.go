@@ -0,0 +1,395 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newTestClient creates a client for a server running handler
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := New(srv.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// statusSequence answers with each status in turn, then with 200 and an
+// empty JSON object, counting the requests it gets
+func statusSequence(calls *atomic.Int32, retryAfter string, statuses ...int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statuses[n-1])
+			fmt.Fprint(w, `{"error":"try again"}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+func TestRetryAfterRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, statusSequence(&calls, "1", http.StatusTooManyRequests))
+
+	// Rate-limited requests were never processed, so even a POST is retried
+	start := time.Now()
+	if err := client.Do(context.Background(), http.MethodPost, "/items", nil, map[string]string{"name": "a"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("server got %d requests, want 2", calls.Load())
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, want the 1s Retry-After", waited)
+	}
+}
+
+func TestRetryServerErrors(t *testing.T) {
+	for _, status := range []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		for _, tc := range []struct {
+			method string
+			calls  int32
+		}{
+			{http.MethodGet, 2},
+			{http.MethodPut, 2},
+			{http.MethodDelete, 2},
+			{http.MethodPost, 1},
+		} {
+			t.Run(fmt.Sprintf("%d %s", status, tc.method), func(t *testing.T) {
+				t.Parallel()
+				var calls atomic.Int32
+				client := newTestClient(t, statusSequence(&calls, "0", status), WithRetries(1))
+
+				err := client.Do(context.Background(), tc.method, "/items/x", nil, nil, nil)
+				if calls.Load() != tc.calls {
+					t.Errorf("server got %d requests, want %d", calls.Load(), tc.calls)
+				}
+				if tc.calls == 1 && !errors.Is(err, ErrServer) {
+					t.Errorf("err = %v, want the server error", err)
+				}
+				if tc.calls == 2 && err != nil {
+					t.Errorf("err = %v after the retry succeeded", err)
+				}
+			})
+		}
+	}
+
+	// Other errors are final
+	var calls atomic.Int32
+	client := newTestClient(t, statusSequence(&calls, "", http.StatusInternalServerError))
+	if err := client.Do(context.Background(), http.MethodGet, "/items", nil, nil, nil); !errors.Is(err, ErrServer) {
+		t.Errorf("err = %v, want ErrServer", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("a 500 was retried: %d requests", calls.Load())
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		sentinel    error
+		want        APIError
+	}{
+		{
+			name: "handler error", status: http.StatusNotFound,
+			body: `{"error":"Item not found"}`, sentinel: ErrNotFound,
+			want: APIError{StatusCode: 404, Message: "Item not found"},
+		},
+		{
+			name: "validation fields", status: http.StatusBadRequest,
+			body:     `{"error":"Invalid item","fields":[{"field":"name","rule":"required","message":"is required"}]}`,
+			sentinel: ErrBadRequest,
+			want: APIError{StatusCode: 400, Message: "Invalid item",
+				Fields: []FieldError{{Field: "name", Rule: "required", Message: "is required"}}},
+		},
+		{
+			name: "problem details", status: http.StatusConflict, contentType: "application/problem+json",
+			body: `{"title":"Conflict","detail":"link exists"}`, sentinel: ErrConflict,
+			want: APIError{StatusCode: 409, Message: "Conflict", Details: "link exists"},
+		},
+		{
+			name: "no JSON body", status: http.StatusBadGateway,
+			body: `upstream down`, sentinel: ErrServer,
+			want: APIError{StatusCode: 502, Message: "Bad Gateway"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if tc.contentType != "" {
+					w.Header().Set("Content-Type", tc.contentType)
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}, WithRetries(0))
+
+			err := client.Do(context.Background(), http.MethodGet, "/items", nil, nil, nil)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an *APIError", err)
+			}
+			if !errors.Is(err, tc.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tc.sentinel)
+			}
+			if apiErr.StatusCode != tc.want.StatusCode || apiErr.Message != tc.want.Message ||
+				apiErr.Details != tc.want.Details || fmt.Sprint(apiErr.Fields) != fmt.Sprint(tc.want.Fields) {
+				t.Errorf("APIError = %+v, want %+v", apiErr, tc.want)
+			}
+			if string(apiErr.Body) != tc.body {
+				t.Errorf("Body = %q, want %q", apiErr.Body, tc.body)
+			}
+		})
+	}
+}
+
+func TestAllItems(t *testing.T) {
+	const total = 7
+	var offsets []int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		offsets = append(offsets, offset)
+
+		page := ListItemsResponse{Limit: limit, Offset: offset, HasMore: offset+limit < total}
+		for i := offset; i < min(offset+limit, total); i++ {
+			page.Items = append(page.Items, Item{ID: uuid.New(), Name: fmt.Sprintf("item %d", i)})
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+
+	var names []string
+	for item, err := range client.AllItems(context.Background(), ListItemsOptions{Limit: 3}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, item.Name)
+	}
+	if len(names) != total || names[0] != "item 0" || names[total-1] != "item 6" {
+		t.Errorf("items = %v, want item 0 to item 6", names)
+	}
+	if fmt.Sprint(offsets) != "[0 3 6]" {
+		t.Errorf("pages requested at offsets %v, want [0 3 6]", offsets)
+	}
+}
+
+func TestAuthHeaders(t *testing.T) {
+	var got http.Header
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		fmt.Fprint(w, `{}`)
+	},
+		WithAPIKey("key-1"),
+		WithBearerToken("token-1"),
+		WithAuth(func(req *http.Request) error {
+			req.Header.Set("X-Signature", "signed")
+			return nil
+		}),
+	)
+
+	if err := client.Do(context.Background(), http.MethodGet, "/hello", nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get("X-API-Key") != "key-1" || got.Get("Authorization") != "Bearer token-1" || got.Get("X-Signature") != "signed" {
+		t.Errorf("headers = %v, want the API key, bearer token and signature", got)
+	}
+
+	// A failing hook stops the request before it is sent
+	failing := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request sent although authentication failed")
+	}, WithAuth(func(*http.Request) error { return errors.New("no token") }))
+	if err := failing.Do(context.Background(), http.MethodGet, "/hello", nil, nil, nil); err == nil {
+		t.Error("Do succeeded although authentication failed")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}, WithTimeout(50*time.Millisecond), WithRetries(0))
+
+	start := time.Now()
+	err := client.Do(context.Background(), http.MethodGet, "/hello", nil, nil, nil)
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) {
+		t.Fatalf("err = %v, want a *TransportError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %s with a 50ms timeout", elapsed)
+	}
+}
+
+// mapCache is a Cache held in memory
+type mapCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func newMapCache() *mapCache { return &mapCache{entries: map[string][]byte{}} }
+
+func (m *mapCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.entries[key]
+	return value, ok
+}
+
+func (m *mapCache) Set(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = value
+}
+
+func (m *mapCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+}
+
+func (m *mapCache) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// cachingServer serves one item under an ETag and Cache-Control the test
+// sets, answering If-None-Match with 304, and counts what it is sent
+type cachingServer struct {
+	mu           sync.Mutex
+	etag         string
+	cacheControl string
+	name         string
+	requests     int
+	notModified  int
+}
+
+func (s *cachingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if r.Method != http.MethodGet {
+		s.name = "updated"
+		s.etag = `W/"2"`
+		fmt.Fprintf(w, `{"name":%q}`, s.name)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Header().Set("Cache-Control", s.cacheControl)
+	if r.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	fmt.Fprintf(w, `{"name":%q}`, s.name)
+}
+
+func (s *cachingServer) set(cacheControl string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cacheControl = cacheControl
+}
+
+func (s *cachingServer) counts() (requests, notModified int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests, s.notModified
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	srv := &cachingServer{etag: `W/"1"`, cacheControl: "max-age=60", name: "original"}
+	cache := newMapCache()
+	client := newTestClient(t, srv.ServeHTTP, WithCache(cache))
+
+	get := func() string {
+		t.Helper()
+		item, err := client.GetItem(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return item.Name
+	}
+
+	// A fresh entry is served without asking the server
+	get()
+	if name := get(); name != "original" {
+		t.Fatalf("name = %q, want original", name)
+	}
+	if requests, _ := srv.counts(); requests != 1 {
+		t.Fatalf("server got %d requests, want 1 while the entry is fresh", requests)
+	}
+
+	// A successful update drops the cached GET, so the change is seen at once
+	if _, err := client.UpdateItem(ctx, id, UpdateItemRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if cache.len() != 0 {
+		t.Fatalf("cache holds %d entries after an update, want 0", cache.len())
+	}
+	srv.set("no-cache")
+	if name := get(); name != "updated" {
+		t.Fatalf("name after update = %q, want updated", name)
+	}
+
+	// A stale entry is revalidated, and a 304 returns the stored body
+	if name := get(); name != "updated" {
+		t.Fatalf("name after 304 = %q, want updated", name)
+	}
+	if _, notModified := srv.counts(); notModified != 1 {
+		t.Errorf("server answered %d requests with 304, want 1", notModified)
+	}
+
+	// A 304 carrying a max-age makes the entry fresh again
+	srv.set("max-age=60")
+	get()
+	requests, _ := srv.counts()
+	get()
+	if after, _ := srv.counts(); after != requests {
+		t.Errorf("server asked again after a 304 with max-age")
+	}
+
+	// no-store responses are not kept, and replace what was
+	cache.mu.Lock()
+	clear(cache.entries)
+	cache.mu.Unlock()
+	srv.set("no-cache")
+	get()
+	if cache.len() != 1 {
+		t.Fatalf("cache holds %d entries, want 1", cache.len())
+	}
+	srv.set("no-store")
+	srv.mu.Lock()
+	srv.etag = `W/"3"`
+	srv.mu.Unlock()
+	get()
+	if cache.len() != 0 {
+		t.Errorf("cache holds %d entries after a no-store response, want 0", cache.len())
+	}
+}
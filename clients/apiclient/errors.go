@@ -0,0 +1,129 @@
+package apiclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched by *APIError through errors.Is
+var (
+	ErrBadRequest  = errors.New("bad request")
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrRateLimited = errors.New("rate limited")
+	ErrServer      = errors.New("server error")
+)
+
+// APIError is a non-2xx response from the API
+type APIError struct {
+	// StatusCode is the HTTP status, e.g. 404
+	StatusCode int
+	// Message is the API's "error" field, or the problem title for problem+json responses
+	Message string
+	// Details is the API's "details" or problem "detail" field, when present
+	Details string
+	// Fields lists the invalid request fields of a 400 response
+	Fields []FieldError
+	// RetryAfter is the server's Retry-After hint, zero when absent
+	RetryAfter time.Duration
+	// Body is the raw response body
+	Body []byte
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		Body:       body,
+	}
+
+	// Handlers answer {"error": ..., "details": ...}; middleware answers with
+	// RFC 9457 problem details, which may carry an "error" member as well
+	var decoded struct {
+		Error   string       `json:"error"`
+		Details string       `json:"details"`
+		Fields  []FieldError `json:"fields"`
+		Title   string       `json:"title"`
+		Detail  string       `json:"detail"`
+	}
+	if json.Unmarshal(body, &decoded) == nil {
+		apiErr.Message = decoded.Error
+		if apiErr.Message == "" {
+			apiErr.Message = decoded.Title
+		}
+		apiErr.Details = decoded.Details
+		if apiErr.Details == "" {
+			apiErr.Details = decoded.Detail
+		}
+		apiErr.Fields = decoded.Fields
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.Details != "":
+		return fmt.Sprintf("api: %d %s: %s", e.StatusCode, e.Message, e.Details)
+	case len(e.Fields) > 0:
+		fields := make([]string, len(e.Fields))
+		for i, f := range e.Fields {
+			fields[i] = f.String()
+		}
+		return fmt.Sprintf("api: %d %s: %s", e.StatusCode, e.Message, strings.Join(fields, "; "))
+	}
+	return fmt.Sprintf("api: %d %s", e.StatusCode, e.Message)
+}
+
+// Is matches the sentinel errors by status code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// FieldError is one invalid request field reported by the API
+type FieldError struct {
+	// Field is the JSON field name, empty when the error concerns the whole body
+	Field string `json:"field"`
+	// Rule names the check that failed, e.g. "required" or "max_length"
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// TransportError means the request did not get an HTTP response, for
+// example because the server could not be reached
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return "api: request failed: " + e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
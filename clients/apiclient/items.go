@@ -0,0 +1,230 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemStatus is an item's lifecycle state
+type ItemStatus string
+
+const (
+	StatusDraft     ItemStatus = "draft"
+	StatusPublished ItemStatus = "published"
+	StatusArchived  ItemStatus = "archived"
+)
+
+// Metadata holds arbitrary JSON fields attached to an item
+type Metadata map[string]any
+
+// Item is an item as returned by the API
+type Item struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	Metadata    Metadata   `json:"metadata"`
+	Status      ItemStatus `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// CreateItemRequest is the payload for CreateItem
+type CreateItemRequest struct {
+	Name        string   `json:"name"`
+	Description *string  `json:"description,omitempty"`
+	Metadata    Metadata `json:"metadata,omitempty"`
+}
+
+// UpdateItemRequest is the payload for UpdateItem; nil fields are left unchanged.
+// A non-nil Metadata replaces the item's metadata.
+type UpdateItemRequest struct {
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Metadata    *Metadata `json:"metadata,omitempty"`
+}
+
+// ListItemsOptions filters and pages ListItems
+type ListItemsOptions struct {
+	// Limit is the page size (server default 10, maximum 100)
+	Limit int
+	// Offset is how many items to skip
+	Offset int
+	// Status restricts results to these statuses
+	Status []ItemStatus
+	// Where holds metadata filters such as "color=red" or "dims.width>=10"
+	Where []string
+	// Fields limits each item to these fields, such as "id" and "name";
+	// the others are left zero. Empty returns every field.
+	Fields []string
+	// SkipTotal asks the server not to count matching items, leaving
+	// ListItemsResponse.Total nil
+	SkipTotal bool
+}
+
+// ListItemsResponse is one page of items
+type ListItemsResponse struct {
+	Items   []Item    `json:"items"`
+	Total   *int      `json:"total"`
+	Limit   int       `json:"limit"`
+	Offset  int       `json:"offset"`
+	HasMore bool      `json:"has_more"`
+	Links   PageLinks `json:"links"`
+}
+
+// PageLinks are the server-relative URLs of a page and its neighbours; Next
+// is empty on the last page and Prev on the first
+type PageLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next"`
+	Prev string `json:"prev"`
+}
+
+// deleteItemResponse is the body of DELETE /items/:id
+type deleteItemResponse struct {
+	Item Item `json:"item"`
+}
+
+// CreateItem creates an item
+func (c *Client) CreateItem(ctx context.Context, req CreateItemRequest) (*Item, error) {
+	var item Item
+	if err := c.Do(ctx, http.MethodPost, "/items", nil, req, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListItems returns one page of items
+func (c *Client) ListItems(ctx context.Context, opts ListItemsOptions) (*ListItemsResponse, error) {
+	query, err := opts.Query()
+	if err != nil {
+		return nil, err
+	}
+
+	var response ListItemsResponse
+	if err := c.Do(ctx, http.MethodGet, "/items", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AllItems iterates over every item matching opts, fetching pages of
+// opts.Limit (default 100) as needed, starting at opts.Offset. Iteration
+// stops at the first error, which is yielded with a zero Item. Pages are
+// offset-based, so items created or deleted during iteration may shift
+// results between pages.
+func (c *Client) AllItems(ctx context.Context, opts ListItemsOptions) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		if opts.Limit <= 0 {
+			opts.Limit = 100
+		}
+		for {
+			page, err := c.ListItems(ctx, opts)
+			if err != nil {
+				yield(Item{}, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			opts.Offset += len(page.Items)
+			if len(page.Items) == 0 || !page.HasMore {
+				return
+			}
+		}
+	}
+}
+
+// GetItem returns a single item
+func (c *Client) GetItem(ctx context.Context, id uuid.UUID) (*Item, error) {
+	var item Item
+	if err := c.Do(ctx, http.MethodGet, "/items/"+id.String(), nil, nil, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UpdateItem changes an item's name, description or metadata
+func (c *Client) UpdateItem(ctx context.Context, id uuid.UUID, req UpdateItemRequest) (*Item, error) {
+	var item Item
+	if err := c.Do(ctx, http.MethodPut, "/items/"+id.String(), nil, req, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// DeleteItem deletes an item and returns it as it was before deletion
+func (c *Client) DeleteItem(ctx context.Context, id uuid.UUID) (*Item, error) {
+	var response deleteItemResponse
+	if err := c.Do(ctx, http.MethodDelete, "/items/"+id.String(), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response.Item, nil
+}
+
+// Query encodes the options as /items query parameters, for DoFormat
+func (o ListItemsOptions) Query() (url.Values, error) {
+	query := url.Values{}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		query.Set("offset", strconv.Itoa(o.Offset))
+	}
+	for _, status := range o.Status {
+		query.Add("status", string(status))
+	}
+	if len(o.Fields) > 0 {
+		query.Set("fields", strings.Join(o.Fields, ","))
+	}
+	if o.SkipTotal {
+		query.Set("include_total", "false")
+	}
+	for _, expr := range o.Where {
+		key, value, err := metadataQueryParam(expr)
+		if err != nil {
+			return nil, err
+		}
+		query.Add(key, value)
+	}
+	return query, nil
+}
+
+// metadataOperators lists filter operators, two-character operators first
+var metadataOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// metadataQueryParam encodes a filter expression as a "meta." query
+// parameter. The server splits keys at "=", so "size>10" is sent as the bare
+// key "meta.size>10" and "size>=10" as key "meta.size>" with value "10".
+func metadataQueryParam(expr string) (string, string, error) {
+	for i := 0; i < len(expr); i++ {
+		for _, op := range metadataOperators {
+			if !strings.HasPrefix(expr[i:], op) {
+				continue
+			}
+			if i == 0 {
+				return "", "", fmt.Errorf("metadata filter %q is missing a path", expr)
+			}
+			key := "meta." + expr[:i]
+			value := expr[i+len(op):]
+			switch op {
+			case "=":
+				return key, value, nil
+			case ">", "<":
+				return key + op + value, "", nil
+			default:
+				return key + strings.TrimSuffix(op, "="), value, nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("metadata filter %q has no operator", expr)
+}
@@ -0,0 +1,116 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAPIVersion is the API version requests are sent to
+const DefaultAPIVersion = "v1"
+
+var apiVersionPattern = regexp.MustCompile(`^v[1-9][0-9]*$`)
+
+// Deprecation describes the server's notice that the API version in use is
+// deprecated, read from the Deprecation, Sunset and Link headers
+type Deprecation struct {
+	// Path is the request path the notice came with
+	Path string
+	// Deprecated is when the version was deprecated; zero if the server gave no date
+	Deprecated time.Time
+	// Sunset is when the version is due to be removed; zero if not announced
+	Sunset time.Time
+	// Successor is the replacement path from the successor-version link, if any
+	Successor string
+}
+
+func (d Deprecation) String() string {
+	msg := d.Path + " is deprecated"
+	if !d.Sunset.IsZero() {
+		msg += " and will be removed on " + d.Sunset.Format(time.DateOnly)
+	}
+	if d.Successor != "" {
+		msg += "; use " + d.Successor
+	}
+	return msg
+}
+
+// WithAPIVersion pins the API version, such as "v1", that paths are sent
+// under. An empty version sends unversioned paths, which the server serves
+// as deprecated aliases of v1.
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+// WithDeprecationHandler calls fn for every response that carries a
+// Deprecation header. Without one, deprecations are logged once at warn
+// level to the WithLogger logger.
+func WithDeprecationHandler(fn func(Deprecation)) Option {
+	return func(c *Client) {
+		c.onDeprecation = fn
+	}
+}
+
+// APIVersion returns the API version the client sends requests to
+func (c *Client) APIVersion() string {
+	return c.apiVersion
+}
+
+// ParseDeprecation reads a deprecation notice from response headers. It
+// reports false when the response is not deprecated.
+func ParseDeprecation(resp *http.Response) (Deprecation, bool) {
+	value := resp.Header.Get("Deprecation")
+	if value == "" {
+		return Deprecation{}, false
+	}
+
+	var d Deprecation
+	if resp.Request != nil {
+		d.Path = resp.Request.URL.Path
+	}
+	// RFC 9745 gives the date as a structured field, such as @1688169599
+	if seconds, err := strconv.ParseInt(strings.TrimPrefix(value, "@"), 10, 64); err == nil {
+		d.Deprecated = time.Unix(seconds, 0).UTC()
+	}
+	if sunset, err := http.ParseTime(resp.Header.Get("Sunset")); err == nil {
+		d.Sunset = sunset.UTC()
+	}
+	for _, link := range resp.Header.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			target, params, ok := strings.Cut(part, ";")
+			if ok && strings.Contains(params, `rel="successor-version"`) {
+				d.Successor = strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return d, true
+}
+
+// notifyDeprecation passes a deprecated response to the handler
+func (c *Client) notifyDeprecation(resp *http.Response) {
+	d, ok := ParseDeprecation(resp)
+	if !ok {
+		return
+	}
+	if c.onDeprecation != nil {
+		c.onDeprecation(d)
+		return
+	}
+	if c.logger != nil {
+		c.deprecationOnce.Do(func() {
+			c.logger.Warn("API deprecation", "notice", d.String())
+		})
+	}
+}
+
+func validAPIVersion(version string) error {
+	if version != "" && !apiVersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid API version %q: expected v1, v2, ...", version)
+	}
+	return nil
+}
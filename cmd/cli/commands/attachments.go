@@ -0,0 +1,364 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/spf13/cobra"
+)
+
+var attachItemCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Attach a file to an item",
+	Long: `Upload a file as an attachment of an item. The file is streamed, so large
+files are never loaded into memory.
+
+Example:
+  mycli items attach --id <item-id> --file ./diagram.png`,
+	RunE: runAttachFile,
+}
+
+var attachmentsItemCmd = &cobra.Command{
+	Use:   "attachments",
+	Short: "List an item's attachments",
+	Long:  "List the files attached to an item",
+	RunE:  runListAttachments,
+}
+
+var downloadItemCmd = &cobra.Command{
+	Use:   "download",
+	Short: "Download an attachment",
+	Long: `Download an attachment and verify its SHA-256 checksum.
+
+Example:
+  mycli items download --id <item-id> --attachment <attachment-id> --output ./copy.png`,
+	RunE: runDownloadAttachment,
+}
+
+var detachItemCmd = &cobra.Command{
+	Use:   "detach",
+	Short: "Delete an attachment",
+	Long:  "Delete an attachment from an item",
+	RunE:  runDeleteAttachment,
+}
+
+var (
+	attachFile     string
+	attachmentID   string
+	downloadOutput string
+)
+
+func init() {
+	attachItemCmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
+	attachItemCmd.Flags().StringVar(&attachFile, "file", "", "Path of the file to upload (required)")
+	attachItemCmd.MarkFlagRequired("id")
+	attachItemCmd.MarkFlagRequired("file")
+
+	attachmentsItemCmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
+	attachmentsItemCmd.MarkFlagRequired("id")
+
+	downloadItemCmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
+	downloadItemCmd.Flags().StringVar(&attachmentID, "attachment", "", "Attachment ID (required)")
+	downloadItemCmd.Flags().StringVarP(&downloadOutput, "output", "o", "", "Output path (defaults to the attachment's filename)")
+	downloadItemCmd.MarkFlagRequired("id")
+	downloadItemCmd.MarkFlagRequired("attachment")
+
+	detachItemCmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
+	detachItemCmd.Flags().StringVar(&attachmentID, "attachment", "", "Attachment ID (required)")
+	detachItemCmd.MarkFlagRequired("id")
+	detachItemCmd.MarkFlagRequired("attachment")
+
+	itemsCmd.AddCommand(attachItemCmd)
+	itemsCmd.AddCommand(attachmentsItemCmd)
+	itemsCmd.AddCommand(downloadItemCmd)
+	itemsCmd.AddCommand(detachItemCmd)
+}
+
+func runAttachFile(cmd *cobra.Command, args []string) error {
+	file, err := os.Open(attachFile)
+	if err != nil {
+		fmt.Printf("❌ Cannot open file: %v\n", err)
+		return nil
+	}
+	defer file.Close()
+
+	// Stream the multipart body through a pipe instead of building it in memory
+	pipeReader, pipeWriter := io.Pipe()
+	form := multipart.NewWriter(pipeWriter)
+	go func() {
+		part, err := form.CreateFormFile("file", filepath.Base(attachFile))
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+
+	url := fmt.Sprintf("%s/items/%s/attachments", apiBase(), itemID)
+	verboseLog(fmt.Sprintf("Making POST request to: %s", url))
+
+	resp, err := http.Post(url, form.FormDataContentType(), pipeReader)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		var apiErr map[string]interface{}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr["error"] != nil {
+			fmt.Printf("❌ Failed to attach file: %v\n", apiErr["error"])
+		} else {
+			fmt.Printf("❌ Failed to attach file (status: %s)\n", resp.Status)
+		}
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	var attachment storage.Attachment
+	if err := json.Unmarshal(body, &attachment); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	fmt.Printf("📎 File attached successfully!\n")
+	printAttachment(attachment)
+
+	return nil
+}
+
+func runListAttachments(cmd *cobra.Command, args []string) error {
+	url := fmt.Sprintf("%s/items/%s/attachments", apiBase(), itemID)
+	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to list attachments (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	var response struct {
+		Attachments []storage.Attachment `json:"attachments"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if len(response.Attachments) == 0 {
+		fmt.Println("📭 No attachments")
+		return nil
+	}
+
+	fmt.Printf("📎 %d attachments\n", len(response.Attachments))
+	for i, attachment := range response.Attachments {
+		fmt.Println()
+		fmt.Printf("%d. %s\n", i+1, attachment.Filename)
+		printAttachment(attachment)
+	}
+
+	return nil
+}
+
+func runDownloadAttachment(cmd *cobra.Command, args []string) error {
+	url := fmt.Sprintf("%s/items/%s/attachments/%s", apiBase(), itemID, attachmentID)
+	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Attachment not found (ID: %s)\n", attachmentID)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("❌ Failed to download attachment (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	output := downloadOutput
+	if output == "" {
+		output = attachmentID
+		if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+			output = filepath.Base(params["filename"])
+		}
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hasher), resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download attachment: %w", err)
+	}
+
+	checksum := hex.EncodeToString(hasher.Sum(nil))
+	expected := strings.Trim(resp.Header.Get("ETag"), `"`)
+	if expected != "" && expected != checksum {
+		fmt.Printf("❌ Checksum mismatch (expected %s, got %s)\n", expected, checksum)
+		return nil
+	}
+
+	if format == "json" {
+		out, _ := json.Marshal(map[string]interface{}{
+			"path":       output,
+			"size_bytes": written,
+			"sha256":     checksum,
+		})
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("✅ Downloaded %s (%d bytes)\n", output, written)
+	fmt.Printf("   SHA-256: %s\n", checksum)
+	return nil
+}
+
+func runDeleteAttachment(cmd *cobra.Command, args []string) error {
+	url := fmt.Sprintf("%s/items/%s/attachments/%s", apiBase(), itemID, attachmentID)
+	verboseLog(fmt.Sprintf("Making DELETE request to: %s", url))
+
+	client := &http.Client{}
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Attachment not found (ID: %s)\n", attachmentID)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to delete attachment (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	fmt.Printf("✅ Attachment deleted successfully! (ID: %s)\n", attachmentID)
+	return nil
+}
+
+func printAttachment(attachment storage.Attachment) {
+	fmt.Printf("   ID: %s\n", attachment.ID)
+	fmt.Printf("   Filename: %s\n", attachment.Filename)
+	fmt.Printf("   Content-Type: %s\n", attachment.ContentType)
+	fmt.Printf("   Size: %d bytes\n", attachment.SizeBytes)
+	fmt.Printf("   SHA-256: %s\n", attachment.SHA256)
+	fmt.Printf("   Uploaded: %s\n", attachment.CreatedAt.Format("2006-01-02 15:04:05"))
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect the item cache",
+	Long:  "Commands for inspecting the server's item read cache",
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show item cache size and hit rate",
+	Long:  "Calls the /cache/stats endpoint to show cached entries and hit/miss counters",
+	RunE:  runCacheStats,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStatsCmd)
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	url := apiBase() + "/cache/stats"
+	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to get cache stats (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	var stats struct {
+		Enabled       bool  `json:"enabled"`
+		Items         int   `json:"items"`
+		Lists         int   `json:"lists"`
+		Remote        bool  `json:"remote"`
+		HitsLocal     int64 `json:"hits_local"`
+		HitsRemote    int64 `json:"hits_remote"`
+		Misses        int64 `json:"misses"`
+		Coalesced     int64 `json:"coalesced"`
+		Invalidations int64 `json:"invalidations"`
+		Evictions     int64 `json:"evictions"`
+		RemoteErrors  int64 `json:"remote_errors"`
+	}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if !stats.Enabled {
+		fmt.Println("⚪ Item cache is disabled (CACHE_ENABLED=false)")
+		return nil
+	}
+
+	hits := stats.HitsLocal + stats.HitsRemote
+	var hitRate float64
+	if total := hits + stats.Misses; total > 0 {
+		hitRate = float64(hits) / float64(total) * 100
+	}
+
+	remote := "none"
+	if stats.Remote {
+		remote = "redis"
+	}
+	fmt.Printf("🗄️  %d item(s) and %d list page(s) cached (remote: %s)\n", stats.Items, stats.Lists, remote)
+	fmt.Printf("   Hit rate: %.1f%% (%d local, %d remote, %d misses)\n", hitRate, stats.HitsLocal, stats.HitsRemote, stats.Misses)
+	fmt.Printf("   Coalesced: %d  Invalidations: %d  Evictions: %d\n", stats.Coalesced, stats.Invalidations, stats.Evictions)
+	if stats.RemoteErrors > 0 {
+		fmt.Printf("   ⚠️  %d remote cache error(s)\n", stats.RemoteErrors)
+	}
+
+	return nil
+}
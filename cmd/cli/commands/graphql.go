@@ -0,0 +1,296 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+)
+
+var graphqlCmd = &cobra.Command{
+	Use:   "graphql [query]",
+	Short: "Run a GraphQL query, mutation or subscription",
+	Long: `Send an operation to the /graphql endpoint. The query is taken from the
+argument, or from a file with -f (use - for stdin). Variables are given as
+name=value, where value is parsed as JSON when it is valid JSON.
+
+Subscriptions stream over a WebSocket until interrupted.
+
+Examples:
+  mycli graphql '{ items(first: 5) { edges { node { id name status } } } }'
+  mycli graphql -f query.graphql --var id=<item-id>
+  mycli graphql 'mutation($id: ID!) { publishItem(id: $id) { status } }' --var id=<item-id>
+  mycli graphql --subscribe 'subscription { itemChanged { type item { name } } }'`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGraphQL,
+}
+
+var (
+	graphqlFile      string
+	graphqlVars      []string
+	graphqlOperation string
+	graphqlSubscribe bool
+)
+
+func init() {
+	graphqlCmd.Flags().StringVarP(&graphqlFile, "file", "f", "", "Read the query from this file (- for stdin)")
+	graphqlCmd.Flags().StringArrayVar(&graphqlVars, "var", nil, "Set a variable as name=value (repeatable)")
+	graphqlCmd.Flags().StringVar(&graphqlOperation, "operation", "", "Operation to run when the document has several")
+	graphqlCmd.Flags().BoolVar(&graphqlSubscribe, "subscribe", false, "Run a subscription over a WebSocket")
+}
+
+// graphqlRequest is the body of POST /graphql and the payload of a subscribe message
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// graphqlResponse is the result of an operation
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Path       []any          `json:"path"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func runGraphQL(cmd *cobra.Command, args []string) error {
+	req, err := buildGraphQLRequest(args)
+	if err != nil {
+		return err
+	}
+	if graphqlSubscribe {
+		return runGraphQLSubscription(req)
+	}
+
+	resp, body, err := sendRequest(http.MethodPost, serverURL+"/graphql", req)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(strings.TrimSpace(string(body)))
+		return nil
+	}
+
+	var result graphqlResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			printAPIError("run GraphQL operation", resp, body)
+			return nil
+		}
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK && len(result.Errors) == 0 {
+		printAPIError("run GraphQL operation", resp, body)
+		return nil
+	}
+
+	printGraphQLResult(result)
+	return nil
+}
+
+// buildGraphQLRequest reads the query and parses --var flags
+func buildGraphQLRequest(args []string) (graphqlRequest, error) {
+	req := graphqlRequest{OperationName: graphqlOperation}
+	switch {
+	case len(args) == 1 && graphqlFile != "":
+		return req, errors.New("give the query as an argument or with --file, not both")
+	case len(args) == 1:
+		req.Query = args[0]
+	case graphqlFile == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return req, fmt.Errorf("failed to read query from stdin: %w", err)
+		}
+		req.Query = string(data)
+	case graphqlFile != "":
+		data, err := os.ReadFile(graphqlFile)
+		if err != nil {
+			return req, fmt.Errorf("failed to read %s: %w", graphqlFile, err)
+		}
+		req.Query = string(data)
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return req, errors.New("a query is required, as an argument or with --file")
+	}
+
+	for _, v := range graphqlVars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return req, fmt.Errorf("invalid --var %q (expected name=value)", v)
+		}
+		if req.Variables == nil {
+			req.Variables = make(map[string]any)
+		}
+		var parsed any
+		if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+			req.Variables[name] = parsed
+		} else {
+			req.Variables[name] = value
+		}
+	}
+	return req, nil
+}
+
+func printGraphQLResult(result graphqlResponse) {
+	for _, e := range result.Errors {
+		code, _ := e.Extensions["code"].(string)
+		path := make([]string, len(e.Path))
+		for i, p := range e.Path {
+			path[i] = fmt.Sprint(p)
+		}
+		switch {
+		case code != "" && len(path) > 0:
+			fmt.Printf("❌ %s [%s] at %s\n", e.Message, code, strings.Join(path, "."))
+		case code != "":
+			fmt.Printf("❌ %s [%s]\n", e.Message, code)
+		default:
+			fmt.Printf("❌ %s\n", e.Message)
+		}
+		if fields, ok := e.Extensions["fields"].([]any); ok {
+			for _, field := range fields {
+				f, _ := field.(map[string]any)
+				fmt.Printf("   • %v: %v\n", f["field"], f["message"])
+			}
+		}
+	}
+
+	if len(result.Data) == 0 || string(result.Data) == "null" {
+		return
+	}
+	var data any
+	if err := json.Unmarshal(result.Data, &data); err != nil {
+		fmt.Println(string(result.Data))
+		return
+	}
+	pretty, _ := json.MarshalIndent(data, "", "  ")
+	fmt.Println(string(pretty))
+}
+
+// runGraphQLSubscription streams a subscription over the graphql-transport-ws
+// protocol until the server completes it or the user interrupts
+func runGraphQLSubscription(req graphqlRequest) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	wsURL := "ws" + strings.TrimPrefix(serverURL, "http") + "/graphql"
+	verboseLog(fmt.Sprintf("Opening WebSocket to: %s", wsURL))
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}, HandshakeTimeout: 10 * time.Second, TLSClientConfig: tlsClientConfig}
+	conn, resp, err := dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(resp.Body)
+			printAPIError("open GraphQL subscription", resp, body)
+			return nil
+		}
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer conn.Close()
+
+	type message struct {
+		ID      string          `json:"id,omitempty"`
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload,omitempty"`
+	}
+
+	if err := conn.WriteJSON(message{Type: "connection_init"}); err != nil {
+		return fmt.Errorf("failed to initialise subscription: %w", err)
+	}
+	var ack message
+	if err := conn.ReadJSON(&ack); err != nil || ack.Type != "connection_ack" {
+		return fmt.Errorf("server did not acknowledge the connection: %v", err)
+	}
+	payload, _ := json.Marshal(req)
+	if err := conn.WriteJSON(message{ID: "1", Type: "subscribe", Payload: payload}); err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	if format != "json" {
+		fmt.Printf("👀 Subscribed at %s (Ctrl+C to stop)\n", serverURL)
+	}
+
+	// Pongs and the complete sent on interrupt come from different goroutines
+	var writeMu sync.Mutex
+	send := func(msg message) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.WriteJSON(msg)
+	}
+
+	// Closing the connection unblocks the read loop on interrupt
+	go func() {
+		<-ctx.Done()
+		send(message{ID: "1", Type: "complete"})
+		conn.Close()
+	}()
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				fmt.Printf("❌ Subscription closed by server: %s (%d)\n", closeErr.Text, closeErr.Code)
+				return nil
+			}
+			return fmt.Errorf("subscription ended: %w", err)
+		}
+		verboseLog(fmt.Sprintf("Received %s message", msg.Type))
+
+		switch msg.Type {
+		case "next":
+			if format == "json" {
+				fmt.Println(string(msg.Payload))
+				continue
+			}
+			var result graphqlResponse
+			if err := json.Unmarshal(msg.Payload, &result); err != nil {
+				fmt.Println(string(msg.Payload))
+				continue
+			}
+			fmt.Printf("📨 %s\n", time.Now().Format("15:04:05"))
+			printGraphQLResult(result)
+		case "error":
+			if format == "json" {
+				fmt.Printf("{\"errors\":%s}\n", msg.Payload)
+				return nil
+			}
+			var result graphqlResponse
+			json.Unmarshal([]byte(`{"errors":`+string(msg.Payload)+`}`), &result)
+			printGraphQLResult(result)
+			return nil
+		case "complete":
+			if format != "json" {
+				fmt.Println("✅ Subscription complete")
+			}
+			return nil
+		case "ping":
+			send(message{Type: "pong"})
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	itemsv1 "github.com/joel-thompson/my-go-service/api/proto/items/v1"
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+var grpcCmd = &cobra.Command{
+	Use:   "grpc",
+	Short: "Call the gRPC ItemsService",
+	Long: `Commands for calling the server's gRPC port (GRPC_ADDR) instead of the
+REST API. Use --api-key when the server sets GRPC_API_KEYS.`,
+}
+
+var grpcHealthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Check gRPC server health",
+	Long:  "Calls grpc.health.v1.Health/Check for ItemsService",
+	RunE:  runGRPCHealth,
+}
+
+var grpcGetCmd = &cobra.Command{
+	Use:   "get <id>",
+	Short: "Get an item over gRPC",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGRPCGet,
+}
+
+var grpcListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Stream every matching item over gRPC",
+	Long: `Calls ItemsService/ListItems, which streams all matching items newest first.
+
+Examples:
+  mycli grpc list --status published
+  mycli grpc list --where color=red --search widget`,
+	RunE: runGRPCList,
+}
+
+var grpcWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream item changes over gRPC",
+	Long:  "Calls ItemsService/WatchItems and prints changes until interrupted",
+	RunE:  runGRPCWatch,
+}
+
+var (
+	grpcAddr   string
+	grpcAPIKey string
+
+	grpcListStatus []string
+	grpcListWhere  []string
+	grpcListSearch string
+
+	grpcWatchItem string
+	grpcWatchTag  string
+)
+
+func init() {
+	grpcCmd.PersistentFlags().StringVar(&grpcAddr, "grpc-addr", "localhost:9090", "gRPC server address")
+	grpcCmd.PersistentFlags().StringVar(&grpcAPIKey, "api-key", "", "API key sent as a Bearer token")
+
+	grpcListCmd.Flags().StringSliceVar(&grpcListStatus, "status", nil, "Only items with these statuses (draft, published, archived)")
+	grpcListCmd.Flags().StringArrayVar(&grpcListWhere, "where", nil, "Metadata filter such as color=red or size>10 (repeatable)")
+	grpcListCmd.Flags().StringVar(&grpcListSearch, "search", "", "Only items whose name or description contains the text")
+
+	grpcWatchCmd.Flags().StringVar(&grpcWatchItem, "item", "", "Only changes to this item ID")
+	grpcWatchCmd.Flags().StringVar(&grpcWatchTag, "tag", "", "Only items tagged with this value")
+
+	grpcCmd.AddCommand(grpcHealthCmd)
+	grpcCmd.AddCommand(grpcGetCmd)
+	grpcCmd.AddCommand(grpcListCmd)
+	grpcCmd.AddCommand(grpcWatchCmd)
+}
+
+// dialGRPC connects to --grpc-addr; the connection is made lazily on the first call
+func dialGRPC() (*grpc.ClientConn, error) {
+	verboseLog(fmt.Sprintf("Connecting to gRPC server at: %s", grpcAddr))
+	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --grpc-addr: %w", err)
+	}
+	return conn, nil
+}
+
+// grpcContext attaches the API key to outgoing calls
+func grpcContext(ctx context.Context) context.Context {
+	if grpcAPIKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+grpcAPIKey)
+	}
+	return ctx
+}
+
+// printGRPCError reports a failed call, with field violations when validation failed
+func printGRPCError(action string, err error) {
+	st := status.Convert(err)
+	if st.Code() == codes.Unavailable {
+		fmt.Printf("❌ Cannot connect to gRPC server at %s\n", grpcAddr)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return
+	}
+
+	fmt.Printf("❌ Failed to %s: %s (%s)\n", action, st.Message(), st.Code())
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fmt.Printf("   • %s: %s\n", violation.GetField(), violation.GetDescription())
+			}
+		}
+	}
+}
+
+// printProtoJSON prints a message in its canonical JSON form
+func printProtoJSON(msg proto.Message) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func runGRPCHealth(cmd *cobra.Command, args []string) error {
+	conn, err := dialGRPC()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: itemsv1.ItemsService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		printGRPCError("check gRPC health", err)
+		return nil
+	}
+
+	if format == "json" {
+		return printProtoJSON(resp)
+	}
+	if resp.GetStatus() == healthpb.HealthCheckResponse_SERVING {
+		fmt.Printf("✅ gRPC server is serving at %s\n", grpcAddr)
+	} else {
+		fmt.Printf("⚠️  gRPC server at %s reports %s\n", grpcAddr, resp.GetStatus())
+	}
+	return nil
+}
+
+func runGRPCGet(cmd *cobra.Command, args []string) error {
+	if _, err := uuid.Parse(args[0]); err != nil {
+		fmt.Printf("❌ Invalid item ID format: %s\n", args[0])
+		return nil
+	}
+
+	conn, err := dialGRPC()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	item, err := itemsv1.NewItemsServiceClient(conn).GetItem(grpcContext(cmd.Context()), &itemsv1.GetItemRequest{Id: args[0]})
+	if err != nil {
+		if status.Code(err) == codes.NotFound && format != "json" {
+			fmt.Printf("❌ Item not found (ID: %s)\n", args[0])
+			return nil
+		}
+		printGRPCError("get item", err)
+		return nil
+	}
+
+	if format == "json" {
+		return printProtoJSON(item)
+	}
+
+	fmt.Printf("📄 Item Details\n")
+	printGRPCItem(item)
+	fmt.Printf("   Updated: %s\n", item.GetUpdateTime().AsTime().Local().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+func runGRPCList(cmd *cobra.Command, args []string) error {
+	req := &itemsv1.ListItemsRequest{Where: grpcListWhere, Search: grpcListSearch}
+	for _, s := range grpcListStatus {
+		value, ok := itemsv1.ItemStatus_value["ITEM_STATUS_"+strings.ToUpper(s)]
+		if !ok || value == 0 {
+			return fmt.Errorf("invalid --status %q (expected draft, published or archived)", s)
+		}
+		req.Status = append(req.Status, itemsv1.ItemStatus(value))
+	}
+
+	conn, err := dialGRPC()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stream, err := itemsv1.NewItemsServiceClient(conn).ListItems(grpcContext(cmd.Context()), req)
+	if err != nil {
+		printGRPCError("list items", err)
+		return nil
+	}
+
+	count := 0
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			printGRPCError("list items", err)
+			return nil
+		}
+		count++
+		if format == "json" {
+			if err := printProtoJSON(resp); err != nil {
+				return err
+			}
+			continue
+		}
+		if count > 1 {
+			fmt.Println()
+		}
+		printGRPCItem(resp.GetItem())
+	}
+
+	if format != "json" {
+		fmt.Printf("\n📋 %d item(s)\n", count)
+	}
+	return nil
+}
+
+func runGRPCWatch(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	conn, err := dialGRPC()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stream, err := itemsv1.NewItemsServiceClient(conn).WatchItems(grpcContext(ctx), &itemsv1.WatchItemsRequest{
+		ItemId: grpcWatchItem,
+		Tag:    grpcWatchTag,
+	})
+	if err != nil {
+		printGRPCError("watch items", err)
+		return nil
+	}
+
+	if format != "json" {
+		fmt.Printf("👀 Watching item changes at %s (Ctrl+C to stop)\n", grpcAddr)
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return nil
+			}
+			printGRPCError("watch items", err)
+			return nil
+		}
+		if format == "json" {
+			if err := printProtoJSON(event); err != nil {
+				return err
+			}
+			continue
+		}
+
+		icon := "•"
+		switch event.GetType() {
+		case itemsv1.ItemEvent_TYPE_CREATED:
+			icon = "🆕"
+		case itemsv1.ItemEvent_TYPE_UPDATED:
+			icon = "✏️ "
+		case itemsv1.ItemEvent_TYPE_DELETED:
+			icon = "🗑️ "
+		}
+		eventType := strings.ToLower(strings.TrimPrefix(event.GetType().String(), "TYPE_"))
+		fmt.Printf("%s %s  %-7s %s %s\n",
+			icon,
+			event.GetOccurTime().AsTime().Local().Format("15:04:05"),
+			eventType,
+			event.GetItem().GetName(),
+			statusBadge(grpcItemStatus(event.GetItem())))
+		fmt.Printf("   ID: %s  (event %d)\n", event.GetItem().GetId(), event.GetId())
+	}
+}
+
+// printGRPCItem prints an item the way items get does
+func printGRPCItem(item *itemsv1.Item) {
+	fmt.Printf("   ID: %s\n", item.GetId())
+	fmt.Printf("   Name: %s\n", item.GetName())
+	fmt.Printf("   Status: %s\n", statusBadge(grpcItemStatus(item)))
+	if item.Description != nil {
+		fmt.Printf("   Description: %s\n", item.GetDescription())
+	} else {
+		fmt.Printf("   Description: (none)\n")
+	}
+	printMetadata(item.GetMetadata().AsMap())
+	fmt.Printf("   Created: %s\n", item.GetCreateTime().AsTime().Local().Format("2006-01-02 15:04:05"))
+}
+
+func grpcItemStatus(item *itemsv1.Item) storage.ItemStatus {
+	return storage.ItemStatus(strings.ToLower(strings.TrimPrefix(item.GetStatus().String(), "ITEM_STATUS_")))
+}
@@ -17,7 +17,7 @@ var helloCmd = &cobra.Command{
 }
 
 func runHelloCheck(cmd *cobra.Command, args []string) error {
-	url := serverURL + "/hello"
+	url := apiBase() + "/hello"
 	verboseLog(fmt.Sprintf("Making request to: %s", url))
 
 	resp, err := http.Get(url)
@@ -1,14 +1,21 @@
 package commands
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
-	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
+
+	"github.com/joel-thompson/my-go-service/clients/apiclient"
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/joel-thompson/my-go-service/validation"
 )
 
 var itemsCmd = &cobra.Command{
@@ -60,17 +67,34 @@ var (
 	itemID          string
 	updateName      string
 	updateDesc      string
+	metaPairs       []string
+	metaFile        string
+	listWhere       []string
+	listStatus      []string
+	listAll         bool
+	listFields      []string
+	skipValidation  bool
 )
 
+// itemValidator applies the server's default item rules before a request is sent
+var itemValidator = validation.New(validation.DefaultLimits())
+
 func init() {
 	// Add flags for create command
 	createItemCmd.Flags().StringVar(&itemName, "name", "", "Item name (required)")
 	createItemCmd.Flags().StringVar(&itemDescription, "description", "", "Item description (optional)")
+	createItemCmd.Flags().StringArrayVar(&metaPairs, "meta", nil, "Metadata key=value pair, repeatable (dotted keys nest, e.g. dims.width=10)")
+	createItemCmd.Flags().StringVar(&metaFile, "meta-file", "", "Path to a JSON file with item metadata")
+	createItemCmd.Flags().BoolVar(&skipValidation, "skip-validation", false, "Send without checking fields locally (e.g. when the server allows longer descriptions)")
 	createItemCmd.MarkFlagRequired("name")
 
 	// Add flags for list command
 	listItemsCmd.Flags().IntVar(&listLimit, "limit", 10, "Number of items to retrieve (max 100)")
 	listItemsCmd.Flags().IntVar(&listOffset, "offset", 0, "Number of items to skip")
+	listItemsCmd.Flags().StringSliceVar(&listStatus, "status", nil, "Only show items with these statuses (draft, published, archived)")
+	listItemsCmd.Flags().StringArrayVar(&listWhere, "where", nil, "Metadata filter such as color=red or size>10, repeatable")
+	listItemsCmd.Flags().BoolVar(&listAll, "all", false, "Fetch every page (--limit sets the page size)")
+	listItemsCmd.Flags().StringSliceVar(&listFields, "fields", nil, "Only fetch these fields and show them as table columns (e.g. id,name,status)")
 
 	// Add flags for get command
 	getItemCmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
@@ -80,6 +104,9 @@ func init() {
 	updateItemCmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
 	updateItemCmd.Flags().StringVar(&updateName, "name", "", "New item name")
 	updateItemCmd.Flags().StringVar(&updateDesc, "description", "", "New item description")
+	updateItemCmd.Flags().StringArrayVar(&metaPairs, "meta", nil, "Metadata key=value pair, repeatable (replaces existing metadata)")
+	updateItemCmd.Flags().StringVar(&metaFile, "meta-file", "", "Path to a JSON file with item metadata (replaces existing metadata)")
+	updateItemCmd.Flags().BoolVar(&skipValidation, "skip-validation", false, "Send without checking fields locally (e.g. when the server allows longer descriptions)")
 	updateItemCmd.MarkFlagRequired("id")
 
 	// Add flags for delete command
@@ -95,127 +122,137 @@ func init() {
 }
 
 func runCreateItem(cmd *cobra.Command, args []string) error {
-	// Prepare request payload
-	req := storage.CreateItemRequest{
+	req := apiclient.CreateItemRequest{
 		Name: itemName,
 	}
 	if itemDescription != "" {
 		req.Description = &itemDescription
 	}
 
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+	if !skipValidation {
+		// Validate the storage form of the request so the rules match the server's exactly
+		check := storage.CreateItemRequest{Name: req.Name, Description: req.Description}
+		if err := itemValidator.CreateItem(&check); err != nil {
+			printValidationErrors("create item", err)
+			return nil
+		}
+		req.Name, req.Description = check.Name, check.Description
 	}
 
-	url := serverURL + "/items"
-	verboseLog(fmt.Sprintf("Making POST request to: %s", url))
-	verboseLog(fmt.Sprintf("Request body: %s", string(jsonData)))
-
-	// Make HTTP request
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	metadata, err := buildMetadata()
 	if err != nil {
-		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
-		if verbose {
-			fmt.Printf("Error: %v\n", err)
-		}
-		fmt.Println("💡 Make sure the server is running with: ./do start")
-		return nil // Don't exit with error for connection issues
+		fmt.Printf("❌ %v\n", err)
+		return nil
 	}
-	defer resp.Body.Close()
+	req.Metadata = apiclient.Metadata(metadata)
 
-	body, err := io.ReadAll(resp.Body)
+	client, err := newAPIClient()
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
 
-	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+	if mediaType, ok := serverFormat(); ok {
+		return printServerFormat(cmd, client, "create item", mediaType, http.MethodPost, "/items", nil, req)
+	}
 
-	if format == "json" {
-		fmt.Println(string(body))
+	item, err := client.CreateItem(cmd.Context(), req)
+	if err != nil {
+		printClientError("create item", err)
 		return nil
 	}
 
-	// Pretty format
-	if resp.StatusCode == http.StatusCreated {
-		var item storage.Item
-		if err := json.Unmarshal(body, &item); err != nil {
-			fmt.Printf("❌ API returned invalid response (not JSON)\n")
-			if verbose {
-				fmt.Printf("Response: %s\n", string(body))
-			}
-			return nil
-		}
+	if format == "json" {
+		return printJSON(item)
+	}
 
-		fmt.Printf("✅ Item created successfully!\n")
-		fmt.Printf("   ID: %s\n", item.ID)
-		fmt.Printf("   Name: %s\n", item.Name)
-		if item.Description != nil {
-			fmt.Printf("   Description: %s\n", *item.Description)
-		}
-		fmt.Printf("   Created: %s\n", item.CreatedAt.Format("2006-01-02 15:04:05"))
-	} else {
-		fmt.Printf("❌ Failed to create item (status: %s)\n", resp.Status)
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
-		}
+	fmt.Printf("✅ Item created successfully!\n")
+	fmt.Printf("   ID: %s\n", item.ID)
+	fmt.Printf("   Name: %s\n", item.Name)
+	fmt.Printf("   Status: %s\n", statusBadge(storage.ItemStatus(item.Status)))
+	if item.Description != nil {
+		fmt.Printf("   Description: %s\n", *item.Description)
 	}
+	printMetadata(item.Metadata)
+	fmt.Printf("   Created: %s\n", item.CreatedAt.Format("2006-01-02 15:04:05"))
 
 	return nil
 }
 
-func runListItems(cmd *cobra.Command, args []string) error {
-	// Build URL with query parameters
-	url := fmt.Sprintf("%s/items?limit=%d&offset=%d", serverURL, listLimit, listOffset)
-	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+// printValidationErrors reports fields rejected before the request was sent
+func printValidationErrors(action string, err error) {
+	fmt.Printf("❌ Cannot %s: invalid fields\n", action)
+	var fields validation.Errors
+	if !errors.As(err, &fields) {
+		fmt.Printf("   • %v\n", err)
+		return
+	}
+	for _, field := range fields {
+		fmt.Printf("   • %s\n", field)
+	}
+	fmt.Println("💡 Use --skip-validation to let the server decide")
+}
 
-	// Make HTTP request
-	resp, err := http.Get(url)
-	if err != nil {
-		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
-		if verbose {
-			fmt.Printf("Error: %v\n", err)
+func runListItems(cmd *cobra.Command, args []string) error {
+	for _, expr := range listWhere {
+		if _, err := storage.ParseMetadataExpr(expr); err != nil {
+			fmt.Printf("❌ Invalid --where filter: %v\n", err)
+			return nil
 		}
-		fmt.Println("💡 Make sure the server is running with: ./do start")
-		return nil // Don't exit with error for connection issues
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	fields, err := storage.ParseItemFields(listFields)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		fmt.Printf("❌ Invalid --fields: %v\n", err)
+		return nil
 	}
 
-	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+	opts := apiclient.ListItemsOptions{
+		Limit:  listLimit,
+		Offset: listOffset,
+		Where:  listWhere,
+		Fields: fields,
+	}
+	for _, status := range listStatus {
+		opts.Status = append(opts.Status, apiclient.ItemStatus(status))
+	}
 
-	if format == "json" {
-		fmt.Println(string(body))
-		return nil
+	client, err := newAPIClient()
+	if err != nil {
+		return err
 	}
 
-	// Check if response is successful before parsing
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("❌ Failed to list items (status: %s)\n", resp.Status)
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
+	if mediaType, ok := serverFormat(); ok {
+		if listAll {
+			fmt.Printf("❌ --all supports --format pretty and json only\n")
+			return nil
 		}
-		return nil
+		query, err := opts.Query()
+		if err != nil {
+			return err
+		}
+		return printServerFormat(cmd, client, "list items", mediaType, http.MethodGet, "/items", query, nil)
 	}
 
-	// Pretty format - try to parse JSON
-	var response storage.ListItemsResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		fmt.Printf("❌ API returned invalid response (not JSON)\n")
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
-		}
+	if listAll {
+		return listAllItems(cmd, client, opts)
+	}
+
+	response, err := client.ListItems(cmd.Context(), opts)
+	if err != nil {
+		printClientError("list items", err)
 		return nil
 	}
 
+	if format == "json" {
+		return printJSON(response)
+	}
+
 	// Display results
 	if len(response.Items) == 0 {
 		fmt.Println("📭 No items found")
-		fmt.Printf("   Total: %d items\n", response.Total)
+		if response.Total != nil {
+			fmt.Printf("   Total: %d items\n", *response.Total)
+		}
 		return nil
 	}
 
@@ -223,93 +260,153 @@ func runListItems(cmd *cobra.Command, args []string) error {
 		len(response.Items),
 		response.Offset+1,
 		response.Offset+len(response.Items),
-		response.Total)
+		*response.Total)
 	fmt.Println()
 
-	for i, item := range response.Items {
-		fmt.Printf("%d. %s\n", i+1, item.Name)
+	if len(fields) > 0 {
+		printItemTable(response.Items, fields)
+	} else {
+		printItemList(response.Items, response.Offset)
+	}
+
+	// Show pagination info
+	if response.HasMore {
+		fmt.Println()
+		fmt.Printf("💡 To see more items, use: --offset %d\n", response.Offset+response.Limit)
+	}
+
+	return nil
+}
+
+// printItemList prints items one block each, numbered from offset+1
+func printItemList(items []apiclient.Item, offset int) {
+	for i, item := range items {
+		fmt.Printf("%d. %s %s\n", offset+i+1, item.Name, statusBadge(storage.ItemStatus(item.Status)))
 		fmt.Printf("   ID: %s\n", item.ID)
 		if item.Description != nil {
 			fmt.Printf("   Description: %s\n", *item.Description)
 		}
+		printMetadata(item.Metadata)
 		fmt.Printf("   Created: %s\n", item.CreatedAt.Format("2006-01-02 15:04:05"))
-		if i < len(response.Items)-1 {
+		if i < len(items)-1 {
 			fmt.Println()
 		}
 	}
+}
 
-	// Show pagination info
-	if response.Total > response.Limit {
-		fmt.Println()
-		nextOffset := response.Offset + response.Limit
-		if nextOffset < response.Total {
-			fmt.Printf("💡 To see more items, use: --offset %d\n", nextOffset)
+// printItemTable prints items as a table with a column per field, for --fields
+func printItemTable(items []apiclient.Item, fields []string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(fields, "\t")))
+	row := make([]string, len(fields))
+	for _, item := range items {
+		for i, field := range fields {
+			row[i] = itemTableCell(item, field)
 		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
 	}
-
-	return nil
+	w.Flush()
 }
 
-func runGetItem(cmd *cobra.Command, args []string) error {
-	url := fmt.Sprintf("%s/items/%s", serverURL, itemID)
-	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+// itemTableCell formats one field of an item for printItemTable
+func itemTableCell(item apiclient.Item, field string) string {
+	switch field {
+	case "id":
+		return item.ID.String()
+	case "name":
+		return item.Name
+	case "description":
+		if item.Description == nil {
+			return "-"
+		}
+		return *item.Description
+	case "metadata":
+		if len(item.Metadata) == 0 {
+			return "-"
+		}
+		data, _ := json.Marshal(item.Metadata)
+		return string(data)
+	case "status":
+		return string(item.Status)
+	case "created_at":
+		return item.CreatedAt.Format("2006-01-02 15:04:05")
+	case "updated_at":
+		return item.UpdatedAt.Format("2006-01-02 15:04:05")
+	}
+	return ""
+}
 
-	// Make HTTP request
-	resp, err := http.Get(url)
-	if err != nil {
-		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
-		if verbose {
-			fmt.Printf("Error: %v\n", err)
+// listAllItems prints every matching item, following pages from --offset
+func listAllItems(cmd *cobra.Command, client *apiclient.Client, opts apiclient.ListItemsOptions) error {
+	items := []apiclient.Item{}
+	for item, err := range client.AllItems(cmd.Context(), opts) {
+		if err != nil {
+			printClientError("list items", err)
+			return nil
 		}
-		fmt.Println("💡 Make sure the server is running with: ./do start")
+		items = append(items, item)
+	}
+
+	if format == "json" {
+		return printJSON(items)
+	}
+
+	if len(items) == 0 {
+		fmt.Println("📭 No items found")
 		return nil
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+	fmt.Printf("📋 Found %d items\n", len(items))
+	fmt.Println()
+	if len(opts.Fields) > 0 {
+		printItemTable(items, opts.Fields)
+	} else {
+		printItemList(items, opts.Offset)
 	}
 
-	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+	return nil
+}
 
-	if format == "json" {
-		fmt.Println(string(body))
+func runGetItem(cmd *cobra.Command, args []string) error {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		fmt.Printf("❌ Invalid item ID format: %s\n", itemID)
 		return nil
 	}
 
-	// Check response status
-	if resp.StatusCode == http.StatusNotFound {
-		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
-		return nil
+	client, err := newAPIClient()
+	if err != nil {
+		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("❌ Failed to get item (status: %s)\n", resp.Status)
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
-		}
-		return nil
+	if mediaType, ok := serverFormat(); ok {
+		return printServerFormat(cmd, client, "get item", mediaType, http.MethodGet, "/items/"+id.String(), nil, nil)
 	}
 
-	// Pretty format
-	var item storage.Item
-	if err := json.Unmarshal(body, &item); err != nil {
-		fmt.Printf("❌ API returned invalid response (not JSON)\n")
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
+	item, err := client.GetItem(cmd.Context(), id)
+	if err != nil {
+		if errors.Is(err, apiclient.ErrNotFound) && format != "json" {
+			fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+			return nil
 		}
+		printClientError("get item", err)
 		return nil
 	}
 
+	if format == "json" {
+		return printJSON(item)
+	}
+
 	fmt.Printf("📄 Item Details\n")
 	fmt.Printf("   ID: %s\n", item.ID)
 	fmt.Printf("   Name: %s\n", item.Name)
+	fmt.Printf("   Status: %s\n", statusBadge(storage.ItemStatus(item.Status)))
 	if item.Description != nil {
 		fmt.Printf("   Description: %s\n", *item.Description)
 	} else {
 		fmt.Printf("   Description: (none)\n")
 	}
+	printMetadata(item.Metadata)
 	fmt.Printf("   Created: %s\n", item.CreatedAt.Format("2006-01-02 15:04:05"))
 	fmt.Printf("   Updated: %s\n", item.UpdatedAt.Format("2006-01-02 15:04:05"))
 
@@ -317,160 +414,162 @@ func runGetItem(cmd *cobra.Command, args []string) error {
 }
 
 func runUpdateItem(cmd *cobra.Command, args []string) error {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		fmt.Printf("❌ Invalid item ID format: %s\n", itemID)
+		return nil
+	}
+
 	// Build update request
-	reqData := make(map[string]interface{})
+	var req apiclient.UpdateItemRequest
 	if updateName != "" {
-		reqData["name"] = updateName
+		req.Name = &updateName
 	}
 	if updateDesc != "" {
-		reqData["description"] = updateDesc
+		req.Description = &updateDesc
 	}
 
-	// Check if at least one field is being updated
-	if len(reqData) == 0 {
-		fmt.Println("❌ At least one field (--name or --description) must be provided for update")
-		return nil
+	if !skipValidation {
+		check := storage.UpdateItemRequest{Name: req.Name, Description: req.Description}
+		if err := itemValidator.UpdateItem(&check); err != nil {
+			printValidationErrors("update item", err)
+			return nil
+		}
+		req.Name, req.Description = check.Name, check.Description
 	}
 
-	jsonData, err := json.Marshal(reqData)
+	metadata, err := buildMetadata()
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		fmt.Printf("❌ %v\n", err)
+		return nil
 	}
-
-	url := fmt.Sprintf("%s/items/%s", serverURL, itemID)
-	verboseLog(fmt.Sprintf("Making PUT request to: %s", url))
-	verboseLog(fmt.Sprintf("Request body: %s", string(jsonData)))
-
-	// Create PUT request
-	client := &http.Client{}
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if metadata != nil {
+		m := apiclient.Metadata(metadata)
+		req.Metadata = &m
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
-		if verbose {
-			fmt.Printf("Error: %v\n", err)
-		}
-		fmt.Println("💡 Make sure the server is running with: ./do start")
+	// Check if at least one field is being updated
+	if req.Name == nil && req.Description == nil && req.Metadata == nil {
+		fmt.Println("❌ At least one field (--name, --description, --meta or --meta-file) must be provided for update")
 		return nil
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	client, err := newAPIClient()
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
 
-	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
-
-	if format == "json" {
-		fmt.Println(string(body))
-		return nil
-	}
-
-	// Check response status
-	if resp.StatusCode == http.StatusNotFound {
-		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
-		return nil
+	if mediaType, ok := serverFormat(); ok {
+		return printServerFormat(cmd, client, "update item", mediaType, http.MethodPut, "/items/"+id.String(), nil, req)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("❌ Failed to update item (status: %s)\n", resp.Status)
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
+	item, err := client.UpdateItem(cmd.Context(), id, req)
+	if err != nil {
+		if errors.Is(err, apiclient.ErrNotFound) && format != "json" {
+			fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+			return nil
 		}
+		printClientError("update item", err)
 		return nil
 	}
 
-	// Pretty format
-	var item storage.Item
-	if err := json.Unmarshal(body, &item); err != nil {
-		fmt.Printf("❌ API returned invalid response (not JSON)\n")
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
-		}
-		return nil
+	if format == "json" {
+		return printJSON(item)
 	}
 
 	fmt.Printf("✅ Item updated successfully!\n")
 	fmt.Printf("   ID: %s\n", item.ID)
 	fmt.Printf("   Name: %s\n", item.Name)
+	fmt.Printf("   Status: %s\n", statusBadge(storage.ItemStatus(item.Status)))
 	if item.Description != nil {
 		fmt.Printf("   Description: %s\n", *item.Description)
 	}
+	printMetadata(item.Metadata)
 	fmt.Printf("   Updated: %s\n", item.UpdatedAt.Format("2006-01-02 15:04:05"))
 
 	return nil
 }
 
 func runDeleteItem(cmd *cobra.Command, args []string) error {
-	url := fmt.Sprintf("%s/items/%s", serverURL, itemID)
-	verboseLog(fmt.Sprintf("Making DELETE request to: %s", url))
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		fmt.Printf("❌ Invalid item ID format: %s\n", itemID)
+		return nil
+	}
 
-	// Create DELETE request
-	client := &http.Client{}
-	req, err := http.NewRequest("DELETE", url, nil)
+	client, err := newAPIClient()
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
 
-	resp, err := client.Do(req)
+	if mediaType, ok := serverFormat(); ok {
+		return printServerFormat(cmd, client, "delete item", mediaType, http.MethodDelete, "/items/"+id.String(), nil, nil)
+	}
+
+	item, err := client.DeleteItem(cmd.Context(), id)
 	if err != nil {
-		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
-		if verbose {
-			fmt.Printf("Error: %v\n", err)
+		if errors.Is(err, apiclient.ErrNotFound) && format != "json" {
+			fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+			return nil
 		}
-		fmt.Println("💡 Make sure the server is running with: ./do start")
+		printClientError("delete item", err)
 		return nil
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+	if format == "json" {
+		return printJSON(item)
 	}
 
-	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+	fmt.Printf("✅ Item deleted successfully!\n")
+	fmt.Printf("   Deleted: %s (ID: %s)\n", item.Name, item.ID)
 
-	if format == "json" {
-		fmt.Println(string(body))
-		return nil
-	}
+	return nil
+}
 
-	// Check response status
-	if resp.StatusCode == http.StatusNotFound {
-		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
-		return nil
+// buildMetadata combines --meta-file and --meta flags; --meta pairs override file values.
+// It returns nil when neither flag was given.
+func buildMetadata() (storage.Metadata, error) {
+	if metaFile == "" && len(metaPairs) == 0 {
+		return nil, nil
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("❌ Failed to delete item (status: %s)\n", resp.Status)
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
+	metadata := storage.Metadata{}
+	if metaFile != "" {
+		data, err := os.ReadFile(metaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metadata file: %w", err)
+		}
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("metadata file must contain a JSON object: %w", err)
 		}
-		return nil
 	}
 
-	// Pretty format
-	var response map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		fmt.Printf("❌ API returned invalid response (not JSON)\n")
-		if verbose {
-			fmt.Printf("Response: %s\n", string(body))
+	for _, pair := range metaPairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --meta value %q (expected key=value)", pair)
 		}
-		return nil
+		metadata.Set(key, storage.ParseMetadataValue(value))
 	}
 
-	fmt.Printf("✅ Item deleted successfully!\n")
-	if item, ok := response["item"].(map[string]interface{}); ok {
-		if name, ok := item["name"].(string); ok {
-			fmt.Printf("   Deleted: %s (ID: %s)\n", name, itemID)
-		}
+	return metadata, nil
+}
+
+// printMetadata prints metadata keys in a stable order for pretty output
+func printMetadata(metadata map[string]any) {
+	if len(metadata) == 0 {
+		return
 	}
 
-	return nil
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("   Metadata:\n")
+	for _, key := range keys {
+		value, _ := json.Marshal(metadata[key])
+		fmt.Printf("     %s: %s\n", key, value)
+	}
 }
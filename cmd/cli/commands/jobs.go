@@ -0,0 +1,305 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/spf13/cobra"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage background jobs",
+	Long:  "Commands for enqueuing background jobs and checking their status",
+	RunE:  runListJobs,
+}
+
+var listJobsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List jobs",
+	Long:  "List background jobs, newest first",
+	RunE:  runListJobs,
+}
+
+var getJobCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Show a job",
+	Long:  "Show a job's status, attempts and result; --wait polls until it finishes",
+	RunE:  runGetJob,
+}
+
+var enqueueJobCmd = &cobra.Command{
+	Use:   "enqueue",
+	Short: "Enqueue a job",
+	Long:  "Queue a background job of a registered kind, e.g. items.export",
+	RunE:  runEnqueueJob,
+}
+
+var cancelJobCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Cancel a job",
+	Long:  "Cancel a queued or running job",
+	RunE:  runCancelJob,
+}
+
+var (
+	jobID          string
+	jobKind        string
+	jobPayload     string
+	jobPriority    int
+	jobDelay       time.Duration
+	jobMaxAttempts int
+	jobTimeout     time.Duration
+	jobWait        bool
+	jobsStatus     string
+	jobsLimit      int
+	jobsOffset     int
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{jobsCmd, listJobsCmd} {
+		cmd.Flags().StringVar(&jobsStatus, "status", "", "Filter by status (queued|running|succeeded|failed|cancelled)")
+		cmd.Flags().StringVar(&jobKind, "kind", "", "Filter by job kind")
+		cmd.Flags().IntVar(&jobsLimit, "limit", 20, "Maximum number of jobs to return")
+		cmd.Flags().IntVar(&jobsOffset, "offset", 0, "Number of jobs to skip")
+	}
+
+	for _, cmd := range []*cobra.Command{getJobCmd, cancelJobCmd} {
+		cmd.Flags().StringVar(&jobID, "id", "", "Job ID (required)")
+		cmd.MarkFlagRequired("id")
+	}
+	getJobCmd.Flags().BoolVar(&jobWait, "wait", false, "Poll until the job finishes")
+
+	enqueueJobCmd.Flags().StringVar(&jobKind, "kind", "", "Job kind (required)")
+	enqueueJobCmd.Flags().StringVar(&jobPayload, "payload", "", "Job payload as JSON")
+	enqueueJobCmd.Flags().IntVar(&jobPriority, "priority", 0, "Priority; higher runs first")
+	enqueueJobCmd.Flags().DurationVar(&jobDelay, "delay", 0, "Run the job after this delay (e.g. 10m)")
+	enqueueJobCmd.Flags().IntVar(&jobMaxAttempts, "max-attempts", 0, "Attempts before the job fails (default 5)")
+	enqueueJobCmd.Flags().DurationVar(&jobTimeout, "timeout", 0, "Timeout per attempt (default 5m)")
+	enqueueJobCmd.Flags().BoolVar(&jobWait, "wait", false, "Poll until the job finishes")
+	enqueueJobCmd.MarkFlagRequired("kind")
+
+	jobsCmd.AddCommand(listJobsCmd)
+	jobsCmd.AddCommand(getJobCmd)
+	jobsCmd.AddCommand(enqueueJobCmd)
+	jobsCmd.AddCommand(cancelJobCmd)
+}
+
+func runListJobs(cmd *cobra.Command, args []string) error {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(jobsLimit))
+	params.Set("offset", strconv.Itoa(jobsOffset))
+	if jobsStatus != "" {
+		params.Set("status", jobsStatus)
+	}
+	if jobKind != "" {
+		params.Set("kind", jobKind)
+	}
+
+	resp, body, err := sendRequest(http.MethodGet, apiBase()+"/jobs?"+params.Encode(), nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("list jobs", resp, body)
+		return nil
+	}
+
+	var result storage.ListJobsResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if len(result.Jobs) == 0 {
+		fmt.Println("📭 No jobs found")
+		return nil
+	}
+
+	fmt.Printf("⚙️  Jobs (showing %d of %d)\n", len(result.Jobs), result.Total)
+	for _, job := range result.Jobs {
+		fmt.Printf("\n   %s %s\n", jobBadge(job.Status), job.Kind)
+		fmt.Printf("   ID: %s\n", job.ID)
+		fmt.Printf("   Attempts: %d/%d, priority %d\n", job.Attempts, job.MaxAttempts, job.Priority)
+		fmt.Printf("   Created: %s\n", job.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	if result.Offset+len(result.Jobs) < result.Total {
+		fmt.Printf("\n💡 More jobs available: --offset %d\n", result.Offset+len(result.Jobs))
+	}
+
+	return nil
+}
+
+func runGetJob(cmd *cobra.Command, args []string) error {
+	return showJob(jobID, jobWait)
+}
+
+func runEnqueueJob(cmd *cobra.Command, args []string) error {
+	req := storage.EnqueueJobRequest{
+		Kind:        jobKind,
+		Priority:    jobPriority,
+		MaxAttempts: jobMaxAttempts,
+	}
+	if jobPayload != "" {
+		if !json.Valid([]byte(jobPayload)) {
+			return fmt.Errorf("--payload must be valid JSON")
+		}
+		req.Payload = json.RawMessage(jobPayload)
+	}
+	if jobDelay > 0 {
+		runAt := time.Now().Add(jobDelay)
+		req.RunAt = &runAt
+	}
+	if jobTimeout > 0 {
+		req.TimeoutSeconds = int(jobTimeout.Seconds())
+	}
+
+	resp, body, err := sendRequest(http.MethodPost, apiBase()+"/jobs", req)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" && !jobWait {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusAccepted {
+		printAPIError("enqueue job", resp, body)
+		return nil
+	}
+
+	var job storage.Job
+	if err := json.Unmarshal(body, &job); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if jobWait {
+		return showJob(job.ID.String(), true)
+	}
+
+	fmt.Printf("✅ Job enqueued: %s\n", job.Kind)
+	fmt.Printf("   ID: %s\n", job.ID)
+	fmt.Printf("   Runs at: %s\n", job.RunAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("💡 Check progress with: mycli jobs get --id %s --wait\n", job.ID)
+
+	return nil
+}
+
+// showJob prints a job, optionally polling until it reaches a final status
+func showJob(id string, wait bool) error {
+	for {
+		resp, body, err := sendRequest(http.MethodGet, fmt.Sprintf("%s/jobs/%s", apiBase(), id), nil)
+		if err != nil || body == nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			if format == "json" {
+				fmt.Println(string(body))
+				return nil
+			}
+			printAPIError("get job", resp, body)
+			return nil
+		}
+
+		var job storage.Job
+		if err := json.Unmarshal(body, &job); err != nil {
+			fmt.Printf("❌ API returned invalid response (not JSON)\n")
+			if verbose {
+				fmt.Printf("Response: %s\n", string(body))
+			}
+			return nil
+		}
+
+		finished := job.Status != storage.JobQueued && job.Status != storage.JobRunning
+		if wait && !finished {
+			verboseLog(fmt.Sprintf("Job is %s, polling again", job.Status))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if format == "json" {
+			fmt.Println(string(body))
+			return nil
+		}
+
+		printJob(job)
+		return nil
+	}
+}
+
+func printJob(job storage.Job) {
+	fmt.Printf("%s %s (%s)\n", jobBadge(job.Status), job.Kind, job.Status)
+	fmt.Printf("   ID: %s\n", job.ID)
+	fmt.Printf("   Attempts: %d/%d, priority %d, timeout %ds\n", job.Attempts, job.MaxAttempts, job.Priority, job.TimeoutSeconds)
+	if job.Status == storage.JobQueued {
+		fmt.Printf("   Runs at: %s\n", job.RunAt.Format("2006-01-02 15:04:05"))
+	}
+	if job.StartedAt != nil {
+		fmt.Printf("   Started: %s\n", job.StartedAt.Format("2006-01-02 15:04:05"))
+	}
+	if job.FinishedAt != nil {
+		fmt.Printf("   Finished: %s\n", job.FinishedAt.Format("2006-01-02 15:04:05"))
+	}
+	if job.LastError != nil {
+		fmt.Printf("   Last error: %s\n", *job.LastError)
+	}
+	if len(job.Result) > 0 {
+		fmt.Printf("   Result: %s\n", string(job.Result))
+	}
+}
+
+func runCancelJob(cmd *cobra.Command, args []string) error {
+	resp, body, err := sendRequest(http.MethodPost, fmt.Sprintf("%s/jobs/%s/cancel", apiBase(), jobID), nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("cancel job", resp, body)
+		return nil
+	}
+
+	fmt.Printf("✅ Job cancelled (ID: %s)\n", jobID)
+	return nil
+}
+
+func jobBadge(status storage.JobStatus) string {
+	switch status {
+	case storage.JobSucceeded:
+		return "✅"
+	case storage.JobFailed:
+		return "❌"
+	case storage.JobCancelled:
+		return "🚫"
+	case storage.JobRunning:
+		return "🔄"
+	default:
+		return "⏳"
+	}
+}
@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/spf13/cobra"
+)
+
+var publishItemCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Publish a draft item",
+	Long:  "Move an item from draft to published",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTransitionItem(storage.TransitionPublish)
+	},
+}
+
+var archiveItemCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Archive an item",
+	Long:  "Move a draft or published item to archived",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTransitionItem(storage.TransitionArchive)
+	},
+}
+
+var unarchiveItemCmd = &cobra.Command{
+	Use:   "unarchive",
+	Short: "Unarchive an item",
+	Long:  "Move an archived item back to draft",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTransitionItem(storage.TransitionUnarchive)
+	},
+}
+
+var historyItemCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show an item's status history",
+	Long:  "List the status transitions recorded for an item, oldest first",
+	RunE:  runItemHistory,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{publishItemCmd, archiveItemCmd, unarchiveItemCmd, historyItemCmd} {
+		cmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
+		cmd.MarkFlagRequired("id")
+		itemsCmd.AddCommand(cmd)
+	}
+}
+
+func runTransitionItem(transition storage.Transition) error {
+	url := fmt.Sprintf("%s/items/%s/%s", apiBase(), itemID, transition)
+	verboseLog(fmt.Sprintf("Making POST request to: %s", url))
+
+	resp, err := http.Post(url, "application/json", nil)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	// Check response status
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		var conflict map[string]interface{}
+		if err := json.Unmarshal(body, &conflict); err == nil {
+			fmt.Printf("⛔ %v\n", conflict["error"])
+			return nil
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to %s item (status: %s)\n", transition, resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	// Pretty format
+	var item storage.Item
+	if err := json.Unmarshal(body, &item); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	fmt.Printf("✅ Item %s: %s %s\n", pastTense(transition), item.Name, statusBadge(item.Status))
+	fmt.Printf("   ID: %s\n", item.ID)
+	fmt.Printf("   Updated: %s\n", item.UpdatedAt.Format("2006-01-02 15:04:05"))
+
+	return nil
+}
+
+func runItemHistory(cmd *cobra.Command, args []string) error {
+	url := fmt.Sprintf("%s/items/%s/history", apiBase(), itemID)
+	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to get item history (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	var response struct {
+		Events []storage.ItemStatusEvent `json:"events"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if len(response.Events) == 0 {
+		fmt.Println("📭 No status changes recorded (item is still in its initial draft status)")
+		return nil
+	}
+
+	fmt.Printf("🕒 Status history for %s\n", itemID)
+	for _, event := range response.Events {
+		fmt.Printf("   %s  %-9s %s → %s\n",
+			event.OccurredAt.Format("2006-01-02 15:04:05"),
+			event.Transition,
+			statusBadge(event.FromStatus),
+			statusBadge(event.ToStatus))
+	}
+
+	return nil
+}
+
+// statusBadge renders an item status for pretty output
+func statusBadge(status storage.ItemStatus) string {
+	switch status {
+	case storage.StatusDraft:
+		return "[📝 draft]"
+	case storage.StatusPublished:
+		return "[🟢 published]"
+	case storage.StatusArchived:
+		return "[📦 archived]"
+	default:
+		return fmt.Sprintf("[%s]", status)
+	}
+}
+
+func pastTense(transition storage.Transition) string {
+	switch transition {
+	case storage.TransitionPublish:
+		return "published"
+	case storage.TransitionArchive:
+		return "archived"
+	case storage.TransitionUnarchive:
+		return "unarchived"
+	default:
+		return string(transition)
+	}
+}
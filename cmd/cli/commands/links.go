@@ -0,0 +1,410 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/spf13/cobra"
+)
+
+var linkItemCmd = &cobra.Command{
+	Use:   "link",
+	Short: "Link an item to another item",
+	Long: `Create a typed link from one item to another.
+
+Relations: depends_on, blocks, duplicates, relates_to.
+Dependency links (depends_on, blocks) that would create a cycle are rejected.
+
+Example:
+  mycli items link --id <item-id> --target <other-id> --relation depends_on`,
+	RunE: runLinkItem,
+}
+
+var unlinkItemCmd = &cobra.Command{
+	Use:   "unlink",
+	Short: "Remove a link from an item",
+	Long:  "Delete a link by its ID; the item may be either end of the link",
+	RunE:  runUnlinkItem,
+}
+
+var linksItemCmd = &cobra.Command{
+	Use:   "links",
+	Short: "List an item's linked items",
+	Long:  "Show every item directly linked to an item, in either direction",
+	RunE:  runListItemLinks,
+}
+
+var graphItemCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show an item's dependency tree",
+	Long: `Render the transitive dependency graph of an item as an ASCII tree or as Graphviz DOT.
+
+Examples:
+  mycli items graph --id <item-id>
+  mycli items graph --id <item-id> --direction dependents --depth 3
+  mycli items graph --id <item-id> --output dot | dot -Tpng -o deps.png`,
+	RunE: runItemGraph,
+}
+
+var (
+	linkTarget     string
+	linkRelation   string
+	linkID         string
+	graphOutput    string
+	graphDirection string
+	graphDepth     int
+)
+
+func init() {
+	linkItemCmd.Flags().StringVar(&itemID, "id", "", "Source item ID (required)")
+	linkItemCmd.Flags().StringVar(&linkTarget, "target", "", "Target item ID (required)")
+	linkItemCmd.Flags().StringVar(&linkRelation, "relation", string(storage.RelationRelatesTo), "Relation (depends_on|blocks|duplicates|relates_to)")
+	linkItemCmd.MarkFlagRequired("id")
+	linkItemCmd.MarkFlagRequired("target")
+
+	unlinkItemCmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
+	unlinkItemCmd.Flags().StringVar(&linkID, "link", "", "Link ID (required)")
+	unlinkItemCmd.MarkFlagRequired("id")
+	unlinkItemCmd.MarkFlagRequired("link")
+
+	linksItemCmd.Flags().StringVar(&itemID, "id", "", "Item ID (required)")
+	linksItemCmd.MarkFlagRequired("id")
+
+	graphItemCmd.Flags().StringVar(&itemID, "id", "", "Root item ID (required)")
+	graphItemCmd.Flags().StringVar(&graphOutput, "output", "ascii", "Graph output (ascii|dot)")
+	graphItemCmd.Flags().StringVar(&graphDirection, "direction", string(storage.GraphDependencies), "Walk direction (dependencies|dependents)")
+	graphItemCmd.Flags().IntVar(&graphDepth, "depth", 10, "Maximum depth to walk (max 50)")
+	graphItemCmd.MarkFlagRequired("id")
+
+	itemsCmd.AddCommand(linkItemCmd)
+	itemsCmd.AddCommand(unlinkItemCmd)
+	itemsCmd.AddCommand(linksItemCmd)
+	itemsCmd.AddCommand(graphItemCmd)
+}
+
+func runLinkItem(cmd *cobra.Command, args []string) error {
+	jsonData, err := json.Marshal(map[string]string{
+		"target_id": linkTarget,
+		"relation":  linkRelation,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/items/%s/links", apiBase(), itemID)
+	verboseLog(fmt.Sprintf("Making POST request to: %s", url))
+	verboseLog(fmt.Sprintf("Request body: %s", string(jsonData)))
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		var apiErr map[string]interface{}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr["error"] != nil {
+			fmt.Printf("❌ Failed to link items: %v\n", apiErr["error"])
+		} else {
+			fmt.Printf("❌ Failed to link items (status: %s)\n", resp.Status)
+		}
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	var link storage.ItemLink
+	if err := json.Unmarshal(body, &link); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	fmt.Printf("🔗 Link created successfully!\n")
+	fmt.Printf("   Link ID: %s\n", link.ID)
+	fmt.Printf("   %s %s %s\n", link.SourceID, link.Relation, link.TargetID)
+
+	return nil
+}
+
+func runUnlinkItem(cmd *cobra.Command, args []string) error {
+	url := fmt.Sprintf("%s/items/%s/links/%s", apiBase(), itemID, linkID)
+	verboseLog(fmt.Sprintf("Making DELETE request to: %s", url))
+
+	client := &http.Client{}
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Link not found (ID: %s)\n", linkID)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to delete link (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	fmt.Printf("✅ Link deleted successfully! (ID: %s)\n", linkID)
+	return nil
+}
+
+func runListItemLinks(cmd *cobra.Command, args []string) error {
+	url := fmt.Sprintf("%s/items/%s/links", apiBase(), itemID)
+	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to list links (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	var response struct {
+		Neighbors []storage.ItemNeighbor `json:"neighbors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if len(response.Neighbors) == 0 {
+		fmt.Println("📭 No linked items")
+		return nil
+	}
+
+	fmt.Printf("🔗 %d linked items\n", len(response.Neighbors))
+	for _, n := range response.Neighbors {
+		arrow := "→"
+		if n.Direction == "incoming" {
+			arrow = "←"
+		}
+		fmt.Printf("   %s %-10s %s %s\n", arrow, n.Relation, n.ItemName, statusBadge(n.ItemStatus))
+		fmt.Printf("     Item ID: %s  Link ID: %s\n", n.ItemID, n.LinkID)
+	}
+
+	return nil
+}
+
+func runItemGraph(cmd *cobra.Command, args []string) error {
+	if graphOutput != "ascii" && graphOutput != "dot" {
+		fmt.Println("❌ --output must be ascii or dot")
+		return nil
+	}
+
+	url := fmt.Sprintf("%s/items/%s/graph?direction=%s&depth=%d", apiBase(), itemID, graphDirection, graphDepth)
+	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("❌ Item not found (ID: %s)\n", itemID)
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to get dependency graph (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	var graph storage.DependencyGraph
+	if err := json.Unmarshal(body, &graph); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if graphOutput == "dot" {
+		fmt.Print(renderGraphDOT(graph))
+	} else {
+		fmt.Print(renderGraphASCII(graph))
+	}
+	return nil
+}
+
+// renderGraphASCII draws the graph as a tree rooted at graph.RootID.
+// Nodes reachable by more than one path are expanded once and marked afterwards.
+func renderGraphASCII(graph storage.DependencyGraph) string {
+	nodes := make(map[uuid.UUID]storage.Item, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	children := make(map[uuid.UUID][]uuid.UUID)
+	for _, edge := range graph.Edges {
+		children[edge.From] = append(children[edge.From], edge.To)
+	}
+
+	label := func(id uuid.UUID) string {
+		node, ok := nodes[id]
+		if !ok {
+			return id.String()
+		}
+		return fmt.Sprintf("%s %s (%s)", node.Name, statusBadge(node.Status), id.String()[:8])
+	}
+
+	var b strings.Builder
+	verb := "depends on"
+	if graph.Direction == storage.GraphDependents {
+		verb = "is needed by"
+	}
+	fmt.Fprintf(&b, "🌳 %s\n", label(graph.RootID))
+	if len(children[graph.RootID]) == 0 {
+		fmt.Fprintf(&b, "   (no %s)\n", graph.Direction)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "   %s:\n", verb)
+
+	expanded := map[uuid.UUID]bool{graph.RootID: true}
+	var walk func(id uuid.UUID, prefix string)
+	walk = func(id uuid.UUID, prefix string) {
+		kids := children[id]
+		for i, child := range kids {
+			branch, indent := "├── ", "│   "
+			if i == len(kids)-1 {
+				branch, indent = "└── ", "    "
+			}
+			if expanded[child] {
+				fmt.Fprintf(&b, "%s%s%s ↑\n", prefix, branch, label(child))
+				continue
+			}
+			expanded[child] = true
+			fmt.Fprintf(&b, "%s%s%s\n", prefix, branch, label(child))
+			walk(child, prefix+indent)
+		}
+	}
+	walk(graph.RootID, "   ")
+
+	return b.String()
+}
+
+// renderGraphDOT renders the graph in Graphviz DOT format
+func renderGraphDOT(graph storage.DependencyGraph) string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for _, node := range graph.Nodes {
+		attrs := fmt.Sprintf("label=%q", node.Name+"\n"+string(node.Status))
+		if node.ID == graph.RootID {
+			attrs += ", penwidth=2"
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", node.ID.String(), attrs)
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "  %q -> %q;\n", edge.From.String(), edge.To.String())
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var openapiCmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Fetch the OpenAPI document",
+	Long:  "Calls the /openapi.json endpoint to list the API's operations or save the document",
+	RunE:  runOpenAPI,
+}
+
+var openapiOutput string
+
+func init() {
+	openapiCmd.Flags().StringVarP(&openapiOutput, "output", "o", "", "Save the document to this file")
+}
+
+func runOpenAPI(cmd *cobra.Command, args []string) error {
+	resp, body, err := sendRequest(http.MethodGet, serverURL+"/openapi.json", nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if format == "json" {
+			fmt.Println(string(body))
+			return nil
+		}
+		printAPIError("get OpenAPI document", resp, body)
+		return nil
+	}
+
+	if openapiOutput != "" {
+		if err := os.WriteFile(openapiOutput, body, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", openapiOutput, err)
+		}
+		fmt.Printf("✅ Saved OpenAPI document to %s\n", openapiOutput)
+		return nil
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			Summary string `json:"summary"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	operations := 0
+	for path, item := range doc.Paths {
+		paths = append(paths, path)
+		operations += len(item)
+	}
+	slices.Sort(paths)
+
+	fmt.Printf("📘 %s %s (OpenAPI %s), %d operation(s)\n", doc.Info.Title, doc.Info.Version, doc.OpenAPI, operations)
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, method := range []string{"get", "post", "put", "patch", "delete"} {
+			if op, ok := item[method]; ok {
+				fmt.Printf("   %-7s %-48s %s\n", strings.ToUpper(method), path, op.Summary)
+			}
+		}
+	}
+	fmt.Printf("💡 Browse the reference at %s/docs\n", serverURL)
+
+	return nil
+}
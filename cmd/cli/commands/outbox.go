@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var outboxCmd = &cobra.Command{
+	Use:   "outbox",
+	Short: "Inspect the event outbox",
+	Long:  "Commands for inspecting the transactional outbox and its relay",
+}
+
+var outboxStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show outbox backlog and relay lag",
+	Long:  "Calls the /outbox/stats endpoint to show unpublished messages and how far the relay is behind",
+	RunE:  runOutboxStats,
+}
+
+func init() {
+	outboxCmd.AddCommand(outboxStatsCmd)
+}
+
+func runOutboxStats(cmd *cobra.Command, args []string) error {
+	url := apiBase() + "/outbox/stats"
+	verboseLog(fmt.Sprintf("Making GET request to: %s", url))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("❌ Failed to get outbox stats (status: %s)\n", resp.Status)
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	var stats struct {
+		Pending              int        `json:"pending"`
+		Retrying             int        `json:"retrying"`
+		OldestPendingSeconds float64    `json:"oldest_pending_seconds"`
+		LastPublishedAt      *time.Time `json:"last_published_at"`
+	}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if stats.Pending == 0 {
+		fmt.Println("✅ Outbox is drained")
+	} else {
+		lag := time.Duration(stats.OldestPendingSeconds * float64(time.Second)).Round(time.Second)
+		fmt.Printf("📤 %d pending message(s), oldest waiting %s\n", stats.Pending, lag)
+		if stats.Retrying > 0 {
+			fmt.Printf("   ⚠️  %d failing and being retried\n", stats.Retrying)
+		}
+	}
+	if stats.LastPublishedAt != nil {
+		fmt.Printf("   Last published: %s\n", stats.LastPublishedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joel-thompson/my-go-service/clients/apiclient"
+)
+
+// maxRetryWait caps how long a single 429 backoff may sleep
+const maxRetryWait = time.Minute
+
+var (
+	// rateLimitRetries is how many times a rate-limited request is retried
+	rateLimitRetries int
+
+	// directTransport is the transport without 429 retries, for the API
+	// client, which retries on its own
+	directTransport = http.DefaultTransport
+)
+
+// retryTransport warns when the server reports the API version as
+// deprecated, and retries requests the server rejects with 429 Too Many
+// Requests, waiting for Retry-After (or backing off exponentially when the
+// header is missing). Requests whose body cannot be replayed, such as
+// streamed uploads, are returned as-is.
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err == nil {
+			if d, ok := apiclient.ParseDeprecation(resp); ok {
+				warnDeprecation(d)
+			}
+		}
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= rateLimitRetries {
+			return resp, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait := backoff
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		wait = min(wait, maxRetryWait)
+		backoff = min(backoff*2, maxRetryWait)
+		resp.Body.Close()
+
+		fmt.Fprintf(os.Stderr, "⏳ Rate limited, retrying in %s (%d/%d)\n", wait, attempt+1, rateLimitRetries)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// All commands use http.DefaultClient, http.Get or a zero http.Client, and
+// all of those go through http.DefaultTransport
+func init() {
+	http.DefaultTransport = &retryTransport{base: http.DefaultTransport}
+}
@@ -0,0 +1,274 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/joel-thompson/my-go-service/retention"
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/spf13/cobra"
+)
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Manage retention rules",
+	Long:  "Commands for inspecting, previewing and running item retention rules",
+}
+
+var listRetentionCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List retention rules",
+	Long:  "List configured retention rules and when each next runs",
+	RunE:  runListRetention,
+}
+
+var previewRetentionCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Preview a retention rule",
+	Long:  "Show which items a retention rule would archive or purge if it ran now",
+	RunE:  runPreviewRetention,
+}
+
+var runRetentionCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run a retention rule now",
+	Long:  "Run a retention rule immediately, outside its schedule",
+	RunE:  runRunRetention,
+}
+
+var retentionRunsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "List retention runs",
+	Long:  "List recent retention runs and how many items each affected",
+	RunE:  runListRetentionRuns,
+}
+
+var (
+	retentionRule  string
+	retentionLimit int
+	retentionDry   bool
+)
+
+func init() {
+	previewRetentionCmd.Flags().StringVar(&retentionRule, "rule", "", "Rule name (required)")
+	previewRetentionCmd.Flags().IntVar(&retentionLimit, "limit", 20, "Maximum number of items to show")
+	previewRetentionCmd.MarkFlagRequired("rule")
+
+	runRetentionCmd.Flags().StringVar(&retentionRule, "rule", "", "Rule name (required)")
+	runRetentionCmd.Flags().BoolVar(&retentionDry, "dry-run", false, "Preview instead of running")
+	runRetentionCmd.MarkFlagRequired("rule")
+
+	retentionRunsCmd.Flags().StringVar(&retentionRule, "rule", "", "Only show runs of this rule")
+	retentionRunsCmd.Flags().IntVar(&retentionLimit, "limit", 20, "Maximum number of runs to show")
+
+	retentionCmd.AddCommand(listRetentionCmd)
+	retentionCmd.AddCommand(previewRetentionCmd)
+	retentionCmd.AddCommand(runRetentionCmd)
+	retentionCmd.AddCommand(retentionRunsCmd)
+}
+
+func runListRetention(cmd *cobra.Command, args []string) error {
+	resp, body, err := sendRequest(http.MethodGet, apiBase()+"/retention/rules", nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("list retention rules", resp, body)
+		return nil
+	}
+
+	var result struct {
+		Rules []retention.RuleInfo `json:"rules"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if len(result.Rules) == 0 {
+		fmt.Println("📭 No retention rules configured")
+		fmt.Println("💡 Set RETENTION_RULES_FILE on the server to add rules")
+		return nil
+	}
+
+	fmt.Printf("🗓️  Retention rules (%d)\n", len(result.Rules))
+	for _, rule := range result.Rules {
+		fmt.Printf("\n   %s: %s %s items not updated in %s\n",
+			rule.Name, rule.Action, strings.Join(rule.Statuses, "/"), rule.OlderThan)
+		if rule.Schedule == "" {
+			fmt.Printf("   Schedule: on demand only\n")
+		} else {
+			fmt.Printf("   Schedule: %s\n", rule.Schedule)
+		}
+		if rule.NextRun != nil {
+			fmt.Printf("   Next run: %s\n", rule.NextRun.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	return nil
+}
+
+func runPreviewRetention(cmd *cobra.Command, args []string) error {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(retentionLimit))
+	endpoint := fmt.Sprintf("%s/retention/rules/%s/preview?%s", apiBase(), url.PathEscape(retentionRule), params.Encode())
+
+	resp, body, err := sendRequest(http.MethodGet, endpoint, nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("preview retention rule", resp, body)
+		return nil
+	}
+
+	var preview retention.Preview
+	if err := json.Unmarshal(body, &preview); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if preview.Total == 0 {
+		fmt.Printf("✅ Rule %s would not affect any items\n", preview.Rule.Name)
+		return nil
+	}
+
+	verb := "archive"
+	if preview.Rule.Action == storage.RetentionPurge {
+		verb = "permanently delete"
+	}
+	fmt.Printf("🔍 Rule %s would %s %d item(s) last updated before %s\n",
+		preview.Rule.Name, verb, preview.Total, preview.Cutoff.Format("2006-01-02 15:04:05"))
+	for _, item := range preview.Items {
+		fmt.Printf("   %s %s %s (updated %s)\n",
+			statusBadge(item.Status), item.ID, item.Name, item.UpdatedAt.Format("2006-01-02"))
+	}
+	if preview.Total > len(preview.Items) {
+		fmt.Printf("   ... and %d more\n", preview.Total-len(preview.Items))
+	}
+
+	return nil
+}
+
+func runRunRetention(cmd *cobra.Command, args []string) error {
+	if retentionDry {
+		return runPreviewRetention(cmd, args)
+	}
+
+	endpoint := fmt.Sprintf("%s/retention/rules/%s/run", apiBase(), url.PathEscape(retentionRule))
+	resp, body, err := sendRequest(http.MethodPost, endpoint, nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("run retention rule", resp, body)
+		return nil
+	}
+
+	var run storage.RetentionRun
+	if err := json.Unmarshal(body, &run); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	printRetentionRun(run)
+	return nil
+}
+
+func runListRetentionRuns(cmd *cobra.Command, args []string) error {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(retentionLimit))
+	if retentionRule != "" {
+		params.Set("rule", retentionRule)
+	}
+
+	resp, body, err := sendRequest(http.MethodGet, apiBase()+"/retention/runs?"+params.Encode(), nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("list retention runs", resp, body)
+		return nil
+	}
+
+	var result struct {
+		Runs []storage.RetentionRun `json:"runs"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if len(result.Runs) == 0 {
+		fmt.Println("📭 No retention runs yet")
+		return nil
+	}
+
+	for i, run := range result.Runs {
+		if i > 0 {
+			fmt.Println()
+		}
+		printRetentionRun(run)
+	}
+	return nil
+}
+
+func printRetentionRun(run storage.RetentionRun) {
+	badge := "✅"
+	switch run.Status {
+	case storage.RetentionRunFailed:
+		badge = "❌"
+	case storage.RetentionRunRunning:
+		badge = "🔄"
+	}
+
+	fmt.Printf("%s %s (%s, %s): %d item(s) %sd\n", badge, run.RuleName, run.Trigger, run.Status, run.AffectedCount, run.Action)
+	fmt.Printf("   Run ID: %s\n", run.ID)
+	fmt.Printf("   Started: %s\n", run.StartedAt.Format("2006-01-02 15:04:05"))
+	if run.Error != nil {
+		fmt.Printf("   Error: %s\n", *run.Error)
+	}
+	if verbose && len(run.AffectedIDs) > 0 {
+		fmt.Printf("   Items: %s\n", strings.Join(run.AffectedIDs, ", "))
+	}
+}
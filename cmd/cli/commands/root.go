@@ -1,17 +1,32 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
 
 	"github.com/spf13/cobra"
+
+	"github.com/joel-thompson/my-go-service/clients/apiclient"
 )
 
 var (
 	// Global flags
-	serverURL string
-	format    string
-	verbose   bool
+	serverURL  string
+	apiVersion string
+	format     string
+	verbose    bool
+	cacheDir   string
+	noCache    bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -22,6 +37,18 @@ var rootCmd = &cobra.Command{
 	
 This tool provides convenient commands for testing all API endpoints
 without having to write curl commands manually.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if apiVersion != "" && !apiVersionPattern.MatchString(apiVersion) {
+			return fmt.Errorf("invalid --api-version %q (expected v1, v2, ...)", apiVersion)
+		}
+		if _, ok := formatMediaTypes[format]; !ok && format != "pretty" && format != "json" {
+			return fmt.Errorf("invalid --format %q (expected pretty, json, yaml, msgpack or csv)", format)
+		}
+		if err := configureTLS(); err != nil {
+			return fmt.Errorf("invalid TLS flags: %w", err)
+		}
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -32,13 +59,28 @@ func Execute() error {
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&serverURL, "url", "http://localhost:8080", "API server URL")
-	rootCmd.PersistentFlags().StringVar(&format, "format", "pretty", "Output format (pretty|json)")
+	rootCmd.PersistentFlags().StringVar(&apiVersion, "api-version", apiclient.DefaultAPIVersion, "API version to call (empty for the deprecated unversioned routes)")
+	rootCmd.PersistentFlags().StringVar(&format, "format", "pretty", "Output format (pretty|json|yaml|msgpack|csv); yaml, msgpack and csv are rendered by the server for items commands")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.PersistentFlags().IntVar(&rateLimitRetries, "retries", 3, "Times to retry a request rejected with 429 Too Many Requests")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", defaultCacheDir(), "Directory of cached GET responses, revalidated with ETag and Last-Modified")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Neither use nor update the response cache")
+	rootCmd.PersistentFlags().StringVar(&caCertFile, "cacert", "", "PEM file of CA certificates to verify the server with, instead of the system roots")
+	rootCmd.PersistentFlags().StringVar(&clientCertFile, "cert", "", "PEM client certificate for servers that require mutual TLS")
+	rootCmd.PersistentFlags().StringVar(&clientKeyFile, "key", "", "PEM private key for --cert")
 
 	// Add subcommands
 	rootCmd.AddCommand(healthCmd)
 	rootCmd.AddCommand(helloCmd)
 	rootCmd.AddCommand(itemsCmd)
+	rootCmd.AddCommand(webhooksCmd)
+	rootCmd.AddCommand(outboxCmd)
+	rootCmd.AddCommand(jobsCmd)
+	rootCmd.AddCommand(retentionCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(openapiCmd)
+	rootCmd.AddCommand(graphqlCmd)
+	rootCmd.AddCommand(grpcCmd)
 }
 
 // Helper function to handle verbose output
@@ -47,3 +89,183 @@ func verboseLog(message string) {
 		fmt.Fprintf(os.Stderr, "[DEBUG] %s\n", message)
 	}
 }
+
+var apiVersionPattern = regexp.MustCompile(`^v[1-9][0-9]*$`)
+
+// apiBase returns the URL that versioned endpoints live under, such as
+// http://localhost:8080/v1
+func apiBase() string {
+	if apiVersion == "" {
+		return serverURL
+	}
+	return serverURL + "/" + apiVersion
+}
+
+var deprecationOnce sync.Once
+
+// warnDeprecation prints the server's deprecation notice once per run
+func warnDeprecation(d apiclient.Deprecation) {
+	deprecationOnce.Do(func() {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", d)
+	})
+}
+
+// sendRequest performs a request and reports connection failures.
+// It returns a nil body when the server could not be reached.
+func sendRequest(method, url string, payload any) (*http.Response, []byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		verboseLog(fmt.Sprintf("Request body: %s", string(jsonData)))
+		reqBody = bytes.NewReader(jsonData)
+	}
+
+	verboseLog(fmt.Sprintf("Making %s request to: %s", method, url))
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return nil, nil, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+	return resp, body, nil
+}
+
+// newAPIClient creates an API client for --url and --api-version. Retries follow --retries and
+// -v logs each request.
+func newAPIClient() (*apiclient.Client, error) {
+	opts := []apiclient.Option{
+		apiclient.WithHTTPClient(&http.Client{Transport: directTransport}),
+		apiclient.WithRetries(rateLimitRetries),
+		apiclient.WithAPIVersion(apiVersion),
+		apiclient.WithDeprecationHandler(warnDeprecation),
+	}
+	if verbose {
+		opts = append(opts, apiclient.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		}))))
+	}
+	if !noCache && cacheDir != "" {
+		cache, err := apiclient.NewDiskCache(cacheDir)
+		if err != nil {
+			verboseLog(fmt.Sprintf("Response cache disabled: %v", err))
+		} else {
+			opts = append(opts, apiclient.WithCache(cache))
+		}
+	}
+	return apiclient.New(serverURL, opts...)
+}
+
+// defaultCacheDir keeps cached responses under the user's cache directory,
+// or nowhere when there is none
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mycli", "http")
+}
+
+// printClientError reports an API client error: API errors show the server's
+// message (or the raw body with --format json), anything else is treated as
+// a connection failure
+func printClientError(action string, err error) {
+	var apiErr *apiclient.APIError
+	if !errors.As(err, &apiErr) {
+		fmt.Printf("❌ Cannot connect to API server at %s\n", serverURL)
+		if verbose {
+			fmt.Printf("Error: %v\n", err)
+		}
+		fmt.Println("💡 Make sure the server is running with: ./do start")
+		return
+	}
+
+	if format == "json" {
+		fmt.Println(string(apiErr.Body))
+		return
+	}
+	fmt.Printf("❌ Failed to %s: %s\n", action, apiErr.Message)
+	if apiErr.Details != "" {
+		fmt.Printf("   %s\n", apiErr.Details)
+	}
+	for _, field := range apiErr.Fields {
+		fmt.Printf("   • %s\n", field)
+	}
+	if verbose {
+		fmt.Printf("Response: %s\n", string(apiErr.Body))
+	}
+}
+
+// formatMediaTypes maps the --format values the server renders itself to the
+// media type requested for them
+var formatMediaTypes = map[string]string{
+	"yaml":    apiclient.MediaTypeYAML,
+	"msgpack": apiclient.MediaTypeMsgPack,
+	"csv":     apiclient.MediaTypeCSV,
+}
+
+// serverFormat returns the media type to request when --format is one the
+// server renders
+func serverFormat() (string, bool) {
+	mediaType, ok := formatMediaTypes[format]
+	return mediaType, ok
+}
+
+// printServerFormat sends a request asking for mediaType and copies the
+// response body to stdout unchanged
+func printServerFormat(cmd *cobra.Command, client *apiclient.Client, action, mediaType, method, path string, query url.Values, in any) error {
+	body, err := client.DoFormat(cmd.Context(), method, path, query, in, mediaType)
+	if err != nil {
+		printClientError(action, err)
+		return nil
+	}
+	_, err = os.Stdout.Write(body)
+	return err
+}
+
+// printJSON prints v as JSON for --format json
+func printJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// printAPIError prints an unexpected response, preferring the API's error message
+func printAPIError(action string, resp *http.Response, body []byte) {
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error != "" {
+		fmt.Printf("❌ Failed to %s: %s\n", action, apiErr.Error)
+		return
+	}
+	fmt.Printf("❌ Failed to %s (status: %s)\n", action, resp.Status)
+	if verbose {
+		fmt.Printf("Response: %s\n", string(body))
+	}
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/joel-thompson/my-go-service/tlsconfig"
+)
+
+var (
+	// TLS flags for servers with a private CA or that require client certificates
+	caCertFile     string
+	clientCertFile string
+	clientKeyFile  string
+
+	// tlsClientConfig is set by configureTLS when any TLS flag is given
+	tlsClientConfig *tls.Config
+)
+
+// configureTLS applies --cacert, --cert and --key to every HTTP and
+// WebSocket connection the CLI makes
+func configureTLS() error {
+	if caCertFile == "" && clientCertFile == "" && clientKeyFile == "" {
+		return nil
+	}
+	config, err := tlsconfig.Client(caCertFile, clientCertFile, clientKeyFile)
+	if err != nil {
+		return err
+	}
+	tlsClientConfig = config
+
+	transport := directTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	directTransport = transport
+	http.DefaultTransport.(*retryTransport).base = transport
+	return nil
+}
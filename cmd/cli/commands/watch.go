@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/spf13/cobra"
+)
+
+var watchItemsCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream item changes live",
+	Long: `Tail the /items/events Server-Sent Events stream. The stream is resumed
+automatically after a disconnect, without missing events.
+
+Examples:
+  mycli items watch
+  mycli items watch --item-id <item-id>
+  mycli items watch --tag urgent --format json
+  mycli items watch --since 1200`,
+	RunE: runWatchItems,
+}
+
+var (
+	watchItemID string
+	watchTag    string
+	watchSince  int64
+)
+
+func init() {
+	watchItemsCmd.Flags().StringVar(&watchItemID, "item-id", "", "Only show events for this item")
+	watchItemsCmd.Flags().StringVar(&watchTag, "tag", "", "Only show items whose metadata tags contain this tag")
+	watchItemsCmd.Flags().Int64Var(&watchSince, "since", -1, "Replay events after this event ID before streaming")
+
+	itemsCmd.AddCommand(watchItemsCmd)
+}
+
+// sseFrame is one parsed Server-Sent Events message
+type sseFrame struct {
+	id    string
+	event string
+	data  string
+}
+
+func runWatchItems(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	query := url.Values{}
+	if watchItemID != "" {
+		query.Set("item_id", watchItemID)
+	}
+	if watchTag != "" {
+		query.Set("tag", watchTag)
+	}
+	streamURL := apiBase() + "/items/events"
+	if len(query) > 0 {
+		streamURL += "?" + query.Encode()
+	}
+
+	lastEventID := ""
+	if watchSince >= 0 {
+		lastEventID = fmt.Sprint(watchSince)
+	}
+
+	if format != "json" {
+		fmt.Printf("👀 Watching item changes at %s (Ctrl+C to stop)\n", serverURL)
+	}
+
+	delay := time.Second
+	for {
+		connected, err := streamItemEvents(ctx, streamURL, &lastEventID)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			delay = time.Second
+		}
+
+		if err != nil && !connected {
+			fmt.Fprintf(os.Stderr, "❌ Cannot connect to API server at %s (retrying in %s)\n", serverURL, delay)
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+		} else {
+			verboseLog(fmt.Sprintf("Stream ended (%v); reconnecting from event %q", err, lastEventID))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, 30*time.Second)
+	}
+}
+
+// streamItemEvents reads one connection's worth of events, updating lastEventID as it goes.
+// It reports whether the connection was established.
+func streamItemEvents(ctx context.Context, streamURL string, lastEventID *string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if *lastEventID != "" {
+		req.Header.Set("Last-Event-ID", *lastEventID)
+	}
+	verboseLog(fmt.Sprintf("Making GET request to: %s (Last-Event-ID: %q)", streamURL, *lastEventID))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	verboseLog(fmt.Sprintf("Response status: %s", resp.Status))
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var frame sseFrame
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if frame.data != "" {
+				printItemEvent(frame)
+				if frame.id != "" {
+					*lastEventID = frame.id
+				}
+			}
+			frame = sseFrame{}
+		case strings.HasPrefix(line, ":"):
+			// Comment / keep-alive
+		case strings.HasPrefix(line, "id:"):
+			frame.id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "event:"):
+			frame.event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data := strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+			if frame.data != "" {
+				frame.data += "\n"
+			}
+			frame.data += data
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+	return true, errors.New("server closed the stream")
+}
+
+func printItemEvent(frame sseFrame) {
+	if format == "json" {
+		fmt.Println(frame.data)
+		return
+	}
+
+	var event storage.ItemEvent
+	if err := json.Unmarshal([]byte(frame.data), &event); err != nil {
+		fmt.Printf("❓ %s: %s\n", frame.event, frame.data)
+		return
+	}
+
+	icon := "•"
+	switch event.Type {
+	case storage.EventItemCreated:
+		icon = "🆕"
+	case storage.EventItemUpdated:
+		icon = "✏️ "
+	case storage.EventItemDeleted:
+		icon = "🗑️ "
+	}
+
+	fmt.Printf("%s %s  %-7s %s %s\n",
+		icon,
+		event.OccurredAt.Local().Format("15:04:05"),
+		event.Type,
+		event.Item.Name,
+		statusBadge(event.Item.Status))
+	fmt.Printf("   ID: %s  (event %d)\n", event.ItemID, event.ID)
+}
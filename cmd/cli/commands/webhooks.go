@@ -0,0 +1,409 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/spf13/cobra"
+)
+
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Manage webhook subscriptions",
+	Long:  "Commands for registering webhooks and inspecting their deliveries",
+}
+
+var createWebhookCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Register a webhook",
+	Long:  "Register a URL to receive signed item events, optionally filtered by event type",
+	RunE:  runCreateWebhook,
+}
+
+var listWebhooksCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List webhooks",
+	Long:  "List registered webhook subscriptions",
+	RunE:  runListWebhooks,
+}
+
+var deleteWebhookCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a webhook",
+	Long:  "Delete a webhook subscription and its delivery log",
+	RunE:  runDeleteWebhook,
+}
+
+var testWebhookCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a test delivery",
+	Long:  "Send a signed webhook.test event to a webhook and show the result",
+	RunE:  runTestWebhook,
+}
+
+var webhookDeliveriesCmd = &cobra.Command{
+	Use:   "deliveries",
+	Short: "List a webhook's deliveries",
+	Long:  "List recent deliveries for a webhook, or show one delivery's attempt log with --delivery-id",
+	RunE:  runWebhookDeliveries,
+}
+
+var redeliverWebhookCmd = &cobra.Command{
+	Use:   "redeliver",
+	Short: "Redeliver a delivery",
+	Long:  "Requeue a delivery, e.g. a dead-lettered one, with a fresh retry budget",
+	RunE:  runRedeliverWebhook,
+}
+
+var (
+	webhookID        string
+	webhookURL       string
+	webhookEvents    []string
+	webhookSecret    string
+	deliveryID       string
+	deliveriesStatus string
+	deliveriesLimit  int
+)
+
+func init() {
+	createWebhookCmd.Flags().StringVar(&webhookURL, "target", "", "URL that receives events (required)")
+	createWebhookCmd.Flags().StringSliceVar(&webhookEvents, "event", nil, "Event type to send, repeatable (default: all)")
+	createWebhookCmd.Flags().StringVar(&webhookSecret, "secret", "", "Signing secret (default: generated)")
+	createWebhookCmd.MarkFlagRequired("target")
+
+	for _, cmd := range []*cobra.Command{deleteWebhookCmd, testWebhookCmd} {
+		cmd.Flags().StringVar(&webhookID, "id", "", "Webhook ID (required)")
+		cmd.MarkFlagRequired("id")
+	}
+
+	webhookDeliveriesCmd.Flags().StringVar(&webhookID, "id", "", "Webhook ID")
+	webhookDeliveriesCmd.Flags().StringVar(&deliveryID, "delivery-id", "", "Show a single delivery with its attempts")
+	webhookDeliveriesCmd.Flags().StringVar(&deliveriesStatus, "status", "", "Filter by status (pending|succeeded|dead)")
+	webhookDeliveriesCmd.Flags().IntVar(&deliveriesLimit, "limit", 20, "Maximum number of deliveries to return")
+
+	redeliverWebhookCmd.Flags().StringVar(&deliveryID, "delivery-id", "", "Delivery ID (required)")
+	redeliverWebhookCmd.MarkFlagRequired("delivery-id")
+
+	webhooksCmd.AddCommand(createWebhookCmd)
+	webhooksCmd.AddCommand(listWebhooksCmd)
+	webhooksCmd.AddCommand(deleteWebhookCmd)
+	webhooksCmd.AddCommand(testWebhookCmd)
+	webhooksCmd.AddCommand(webhookDeliveriesCmd)
+	webhooksCmd.AddCommand(redeliverWebhookCmd)
+}
+
+func runCreateWebhook(cmd *cobra.Command, args []string) error {
+	resp, body, err := sendRequest(http.MethodPost, apiBase()+"/webhooks", storage.CreateWebhookRequest{
+		URL:        webhookURL,
+		EventTypes: webhookEvents,
+		Secret:     webhookSecret,
+	})
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		printAPIError("create webhook", resp, body)
+		return nil
+	}
+
+	var webhook storage.CreatedWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	fmt.Printf("✅ Webhook created successfully!\n")
+	fmt.Printf("   ID: %s\n", webhook.ID)
+	fmt.Printf("   URL: %s\n", webhook.URL)
+	fmt.Printf("   Events: %s\n", formatEventTypes(webhook.EventTypes))
+	fmt.Printf("   Secret: %s\n", webhook.Secret)
+	fmt.Println("💡 Store the secret now; it is not shown again")
+
+	return nil
+}
+
+func runListWebhooks(cmd *cobra.Command, args []string) error {
+	resp, body, err := sendRequest(http.MethodGet, apiBase()+"/webhooks", nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("list webhooks", resp, body)
+		return nil
+	}
+
+	var result struct {
+		Webhooks []storage.WebhookSubscription `json:"webhooks"`
+		Total    int                           `json:"total"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if result.Total == 0 {
+		fmt.Println("📭 No webhooks registered")
+		return nil
+	}
+
+	fmt.Printf("🔔 Webhooks (%d)\n", result.Total)
+	for _, webhook := range result.Webhooks {
+		state := "active"
+		if !webhook.Active {
+			state = "inactive"
+		}
+		fmt.Printf("\n   %s [%s]\n", webhook.URL, state)
+		fmt.Printf("   ID: %s\n", webhook.ID)
+		fmt.Printf("   Events: %s\n", formatEventTypes(webhook.EventTypes))
+		fmt.Printf("   Created: %s\n", webhook.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	return nil
+}
+
+func runDeleteWebhook(cmd *cobra.Command, args []string) error {
+	resp, body, err := sendRequest(http.MethodDelete, fmt.Sprintf("%s/webhooks/%s", apiBase(), webhookID), nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("delete webhook", resp, body)
+		return nil
+	}
+
+	fmt.Printf("✅ Webhook deleted (ID: %s)\n", webhookID)
+	return nil
+}
+
+func runTestWebhook(cmd *cobra.Command, args []string) error {
+	resp, body, err := sendRequest(http.MethodPost, fmt.Sprintf("%s/webhooks/%s/test", apiBase(), webhookID), nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("send test delivery", resp, body)
+		return nil
+	}
+
+	var delivery storage.WebhookDelivery
+	if err := json.Unmarshal(body, &delivery); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if delivery.Status == storage.DeliverySucceeded {
+		fmt.Printf("✅ Test delivery succeeded (%s)\n", formatStatusCode(delivery.LastStatusCode))
+	} else {
+		fmt.Printf("❌ Test delivery failed (%s)\n", formatStatusCode(delivery.LastStatusCode))
+		if delivery.LastError != nil {
+			fmt.Printf("   Error: %s\n", *delivery.LastError)
+		}
+		if delivery.Status == storage.DeliveryPending {
+			fmt.Printf("   Next attempt: %s\n", delivery.NextAttemptAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+	fmt.Printf("   Delivery ID: %s\n", delivery.ID)
+
+	return nil
+}
+
+func runWebhookDeliveries(cmd *cobra.Command, args []string) error {
+	if deliveryID != "" {
+		return runGetWebhookDelivery()
+	}
+	if webhookID == "" {
+		return fmt.Errorf("either --id or --delivery-id is required")
+	}
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(deliveriesLimit))
+	if deliveriesStatus != "" {
+		params.Set("status", deliveriesStatus)
+	}
+	endpoint := fmt.Sprintf("%s/webhooks/%s/deliveries?%s", apiBase(), webhookID, params.Encode())
+
+	resp, body, err := sendRequest(http.MethodGet, endpoint, nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("list deliveries", resp, body)
+		return nil
+	}
+
+	var result struct {
+		Deliveries []storage.WebhookDelivery `json:"deliveries"`
+		Total      int                       `json:"total"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	if result.Total == 0 {
+		fmt.Println("📭 No deliveries found")
+		return nil
+	}
+
+	fmt.Printf("📬 Deliveries (%d)\n", result.Total)
+	for _, delivery := range result.Deliveries {
+		fmt.Printf("\n   %s %s\n", deliveryBadge(delivery.Status), delivery.EventType)
+		fmt.Printf("   ID: %s\n", delivery.ID)
+		fmt.Printf("   Attempts: %d, last response: %s\n", delivery.Attempts, formatStatusCode(delivery.LastStatusCode))
+		if delivery.LastError != nil {
+			fmt.Printf("   Last error: %s\n", *delivery.LastError)
+		}
+		fmt.Printf("   Created: %s\n", delivery.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	return nil
+}
+
+func runGetWebhookDelivery() error {
+	resp, body, err := sendRequest(http.MethodGet, fmt.Sprintf("%s/webhooks/deliveries/%s", apiBase(), deliveryID), nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		printAPIError("get delivery", resp, body)
+		return nil
+	}
+
+	var result struct {
+		Delivery storage.WebhookDelivery          `json:"delivery"`
+		Attempts []storage.WebhookDeliveryAttempt `json:"attempts"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		fmt.Printf("❌ API returned invalid response (not JSON)\n")
+		if verbose {
+			fmt.Printf("Response: %s\n", string(body))
+		}
+		return nil
+	}
+
+	delivery := result.Delivery
+	fmt.Printf("%s %s\n", deliveryBadge(delivery.Status), delivery.EventType)
+	fmt.Printf("   ID: %s\n", delivery.ID)
+	fmt.Printf("   Webhook: %s\n", delivery.SubscriptionID)
+	if delivery.Status == storage.DeliveryPending {
+		fmt.Printf("   Next attempt: %s\n", delivery.NextAttemptAt.Format("2006-01-02 15:04:05"))
+	}
+	if delivery.DeliveredAt != nil {
+		fmt.Printf("   Delivered: %s\n", delivery.DeliveredAt.Format("2006-01-02 15:04:05"))
+	}
+
+	if len(result.Attempts) == 0 {
+		fmt.Println("   No attempts yet")
+		return nil
+	}
+
+	fmt.Println("   Attempts:")
+	for _, attempt := range result.Attempts {
+		line := fmt.Sprintf("     #%d %s %s in %dms", attempt.Attempt,
+			attempt.AttemptedAt.Format("2006-01-02 15:04:05"), formatStatusCode(attempt.StatusCode), attempt.DurationMS)
+		if attempt.Error != nil {
+			line += " - " + *attempt.Error
+		}
+		fmt.Println(line)
+	}
+
+	return nil
+}
+
+func runRedeliverWebhook(cmd *cobra.Command, args []string) error {
+	resp, body, err := sendRequest(http.MethodPost, fmt.Sprintf("%s/webhooks/deliveries/%s/redeliver", apiBase(), deliveryID), nil)
+	if err != nil || body == nil {
+		return err
+	}
+
+	if format == "json" {
+		fmt.Println(string(body))
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusAccepted {
+		printAPIError("redeliver", resp, body)
+		return nil
+	}
+
+	fmt.Printf("✅ Delivery requeued (ID: %s)\n", deliveryID)
+	return nil
+}
+
+func formatEventTypes(eventTypes []string) string {
+	if len(eventTypes) == 0 {
+		return "all"
+	}
+	return strings.Join(eventTypes, ", ")
+}
+
+func formatStatusCode(code *int) string {
+	if code == nil {
+		return "no response"
+	}
+	return fmt.Sprintf("HTTP %d", *code)
+}
+
+func deliveryBadge(status string) string {
+	switch status {
+	case storage.DeliverySucceeded:
+		return "✅"
+	case storage.DeliveryDead:
+		return "💀"
+	default:
+		return "⏳"
+	}
+}
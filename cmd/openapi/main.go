@@ -0,0 +1,37 @@
+// Command openapi prints the server's OpenAPI document.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/joel-thompson/my-go-service/api/server"
+)
+
+func main() {
+	output := flag.String("o", "", "Write the document to this file instead of stdout")
+	flag.Parse()
+
+	// The document does not depend on the database, so no connection is made
+	api := server.New(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, server.Options{})
+
+	data, err := json.MarshalIndent(api.OpenAPI(), "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to encode document:", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to write document:", err)
+		os.Exit(1)
+	}
+}
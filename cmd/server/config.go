@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/joel-thompson/my-go-service/cmd/server/setup"
+)
+
+// configFile is the --config flag, defaulting to CONFIG_FILE
+var configFile string
+
+// settingFlags holds one flag per setup.Setting that is not secret, keyed by
+// environment variable name
+var settingFlags = map[string]*string{}
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "server",
+		Short: "Run the my-go-service API server",
+		Long: `Run the my-go-service API server.
+
+Settings come from defaults, then the --config file (YAML or TOML), then
+environment variables, then flags, each overriding the one before. Every
+setting has an environment variable (SERVER_ADDR), a config file key
+(server_addr) and a flag (--server-addr), except that secrets such as
+DATABASE_URL have no flag.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := loadOptions(cmd)
+			loaded, err := setup.Load(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			run(opts, loaded.Config)
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&configFile, "config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file (default $CONFIG_FILE)")
+	for _, setting := range setup.Settings() {
+		// Command lines show up in ps and shell history, so secrets are
+		// only read from the environment and the config file
+		if setting.Secret {
+			continue
+		}
+		usage := "Overrides " + setting.Env
+		if setting.Default != "" {
+			usage += fmt.Sprintf(" (default %q)", setting.Default)
+		}
+		settingFlags[setting.Env] = flags.String(setting.FlagName(), "", usage)
+	}
+	// List settings in Config order, which keeps related ones together
+	flags.SortFlags = false
+	root.Flags().SortFlags = false
+	root.CompletionOptions.DisableDefaultCmd = true
+
+	root.AddCommand(newConfigCmd())
+	return root
+}
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and check the server configuration",
+	}
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration with secrets redacted",
+		Long: `Print the effective configuration as a YAML config file, after applying
+the config file, environment variables and flags. Secrets are redacted and
+each value is annotated with where it came from.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loaded, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+			out, err := loaded.Redacted()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	})
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration without starting the server",
+		Long: `Check the effective configuration, as the server would load it, and
+report every problem found. Exits non-zero if any are found. Nothing is
+connected to, so it is safe to run before a deploy.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loaded, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+			if err := loaded.Config.Validate(); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid")
+			return nil
+		},
+	})
+
+	return configCmd
+}
+
+// loadOptions collects the config file and the setting flags set on cmd
+func loadOptions(cmd *cobra.Command) setup.LoadOptions {
+	flags := map[string]string{}
+	for env, value := range settingFlags {
+		if cmd.Flags().Changed(setup.Setting{Env: env}.FlagName()) {
+			flags[env] = *value
+		}
+	}
+	return setup.LoadOptions{File: configFile, Flags: flags}
+}
+
+// loadConfig loads the configuration with the flags set on cmd
+func loadConfig(cmd *cobra.Command) (*setup.Loaded, error) {
+	return setup.Load(cmd.Context(), loadOptions(cmd))
+}
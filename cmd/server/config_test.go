@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/joel-thompson/my-go-service/cmd/server/setup"
+)
+
+func TestSecretSettingsHaveNoFlag(t *testing.T) {
+	flags := newRootCmd().PersistentFlags()
+	for _, setting := range setup.Settings() {
+		flag := flags.Lookup(setting.FlagName())
+		if setting.Secret && flag != nil {
+			t.Errorf("secret %s has flag --%s", setting.Env, setting.FlagName())
+		}
+		if !setting.Secret && flag == nil {
+			t.Errorf("%s has no flag --%s", setting.Env, setting.FlagName())
+		}
+	}
+}
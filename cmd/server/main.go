@@ -3,60 +3,302 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/joel-thompson/my-go-service/api/graphql"
 	"github.com/joel-thompson/my-go-service/api/server"
+	"github.com/joel-thompson/my-go-service/cache"
 	"github.com/joel-thompson/my-go-service/cmd/server/setup"
+	"github.com/joel-thompson/my-go-service/events"
+	"github.com/joel-thompson/my-go-service/jobs"
+	"github.com/joel-thompson/my-go-service/outbox"
+	"github.com/joel-thompson/my-go-service/ratelimit"
+	"github.com/joel-thompson/my-go-service/retention"
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/joel-thompson/my-go-service/webhooks"
 )
 
 func main() {
+	if err := newRootCmd().ExecuteContext(context.Background()); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run serves the API with config, loaded with opts, until SIGINT or
+// SIGTERM; SIGHUP reloads the configuration from opts
+func run(opts setup.LoadOptions, config *setup.Config) {
 	ctx := context.Background()
 
 	// Initialize application
-	app, err := setup.NewApp(ctx)
+	app, err := setup.NewApp(ctx, config)
 	if err != nil {
 		log.Fatal("Failed to initialize app:", err)
 	}
 	defer app.Close()
 
+	// Background workers stop when this context is cancelled during shutdown
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+
+	// Start the item event listener used by the SSE stream
+	broker := events.NewBroker(app.Logger, storage.New(app.DB), app.Config.DatabaseURL)
+	go broker.Run(workerCtx)
+
+	// Start the webhook dispatcher; shutdown waits for in-flight deliveries
+	dispatcher := webhooks.NewDispatcher(app.Logger, storage.New(app.DB), webhooks.Config{
+		MaxAttempts:  app.Config.WebhookMaxAttempts,
+		Timeout:      app.Config.WebhookTimeout,
+		PollInterval: app.Config.WebhookPollInterval,
+		Workers:      app.Config.WebhookWorkers,
+	})
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		dispatcher.Run(workerCtx)
+	}()
+
+	// Start the outbox relay unless publishing is disabled
+	if app.Outbox != nil {
+		relay := outbox.NewRelay(app.Logger, storage.New(app.DB), app.Outbox, outbox.Config{
+			BatchSize:    app.Config.OutboxBatchSize,
+			PollInterval: app.Config.OutboxPollInterval,
+			Retention:    app.Config.OutboxRetention,
+			Lease:        app.Config.OutboxLease,
+		})
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			relay.Run(workerCtx)
+		}()
+	}
+
+	// Start background job workers; shutdown lets running jobs drain
+	pool := jobs.NewPool(app.Logger, storage.New(app.DB), jobs.Config{
+		Workers:           app.Config.JobWorkers,
+		PollInterval:      app.Config.JobPollInterval,
+		HeartbeatInterval: app.Config.JobHeartbeatInterval,
+		StaleAfter:        app.Config.JobStaleAfter,
+		DrainTimeout:      app.Config.JobDrainTimeout,
+	})
+	pool.Register(jobs.KindExportItems, jobs.ExportItems(storage.New(app.DB), app.Blobs))
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		pool.Run(workerCtx)
+	}()
+
+	// Start the retention scheduler
+	retentionService, err := retention.NewService(app.Logger, storage.New(app.DB), app.Blobs, app.RetentionRules)
+	if err != nil {
+		log.Fatal("Failed to schedule retention rules:", err)
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		retentionService.Run(workerCtx)
+	}()
+
+	// Cache item reads; the broker carries invalidations from other instances
+	var itemCache *cache.Store
+	if app.Config.CacheEnabled {
+		itemCache = cache.NewStore(app.Logger, storage.New(app.DB), broker, cache.Config{
+			Size:      app.Config.CacheSize,
+			TTL:       app.Config.CacheTTL,
+			ListTTL:   app.Config.CacheListTTL,
+			Remote:    app.CacheRemote,
+			RemoteTTL: app.Config.CacheRemoteTTL,
+		})
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			itemCache.Run(workerCtx)
+		}()
+	}
+
+	// The reloader applies new settings to the API, which reports the reloader's status
+	var reloader *setup.Reloader
+	live := liveSettings(app.Config, app.ClientIdentities)
+
 	// Setup API server
-	api := server.New(app.Logger, app.DB)
-	router := api.SetupRoutes()
+	api := server.New(app.Logger, app.DB, server.Options{
+		MetadataSchema:     app.MetadataSchema,
+		Validator:          app.Validator,
+		Blobs:              app.Blobs,
+		MaxAttachmentBytes: app.Config.MaxAttachmentBytes,
+		Events:             broker,
+		Webhooks:           dispatcher,
+		Jobs:               pool,
+		Retention:          retentionService,
+		Cache:              itemCache,
+		RateLimiter:        app.RateLimiter,
+		RateLimits:         live.RateLimits,
+		APIKeyHeader:       app.Config.RateLimitKeyHeader,
+		APIKeys:            app.Config.RateLimitAPIKeys,
+		TrustedProxies:     app.Config.TrustedProxies,
+		LegacyRoutes: server.LegacyRoutes{
+			Disabled: !app.Config.LegacyRoutesEnabled,
+			Sunset:   app.LegacyRoutesSunset,
+		},
+		GraphQLLimits: graphql.Limits{
+			MaxDepth:      app.Config.GraphQLMaxDepth,
+			MaxComplexity: app.Config.GraphQLMaxComplexity,
+		},
+		CacheControl: live.CacheControl,
+		Compression: server.Compression{
+			Disabled:             !app.Config.CompressionEnabled,
+			MinSize:              app.Config.CompressionMinSize,
+			ContentTypes:         app.Config.CompressionContentTypes,
+			Level:                app.CompressionLevel,
+			MaxDecompressedBytes: app.Config.CompressionMaxDecompressedBytes,
+		},
+		MaxBodyBytes:     live.MaxBodyBytes,
+		HandlerTimeout:   live.HandlerTimeout,
+		ClientIdentities: live.ClientIdentities,
+		ConfigStatus: func() server.ConfigStatus {
+			status := reloader.Status()
+			return server.ConfigStatus{
+				Generation:     status.Generation,
+				LoadedAt:       status.LoadedAt,
+				LastReloadAt:   status.LastReloadAt,
+				LastError:      status.LastError,
+				PendingRestart: status.PendingRestart,
+			}
+		},
+	})
+	reloader = setup.NewReloader(app, opts, func(config *setup.Config, identities map[string]string) {
+		api.Reload(liveSettings(config, identities))
+	})
+	router, err := api.SetupRoutes()
+	if err != nil {
+		log.Fatal("Failed to set up routes:", err)
+	}
 
 	// Create HTTP server
 	srv := &http.Server{
-		Addr:    app.Config.ServerAddr,
-		Handler: router,
+		Addr:              app.Config.ServerAddr,
+		Handler:           router,
+		ReadHeaderTimeout: app.Config.ServerReadHeaderTimeout,
+		ReadTimeout:       app.Config.ServerReadTimeout,
+		WriteTimeout:      app.Config.ServerWriteTimeout,
+		IdleTimeout:       app.Config.ServerIdleTimeout,
+		MaxHeaderBytes:    app.Config.ServerMaxHeaderBytes,
+		TLSConfig:         app.TLS,
 	}
 
+	// Pick up renewed certificates without a restart
+	if app.Certificate != nil {
+		go app.Certificate.Run(workerCtx, app.Logger, app.Config.TLSReloadInterval)
+	}
+
+	// Shutdown waits for active connections, so end open event streams first
+	srv.RegisterOnShutdown(broker.Shutdown)
+
 	// Start server in a goroutine
 	go func() {
-		app.Logger.Info("Starting server", "addr", app.Config.ServerAddr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		app.Logger.Info("Starting server", "addr", app.Config.ServerAddr, "tls", app.TLS != nil)
+		var err error
+		if app.TLS != nil {
+			// The certificate comes from TLSConfig.GetCertificate
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			app.Logger.Error("Server failed to start", "error", err)
 			os.Exit(1)
 		}
 	}()
 
+	// Serve ItemsService over gRPC on its own port
+	var grpcServer *server.GRPCServer
+	if app.Config.GRPCAddr != "" {
+		lis, err := net.Listen("tcp", app.Config.GRPCAddr)
+		if err != nil {
+			log.Fatal("Failed to listen for gRPC:", err)
+		}
+		grpcServer = api.NewGRPCServer(app.Config.GRPCAPIKeys)
+		go func() {
+			app.Logger.Info("Starting gRPC server", "addr", app.Config.GRPCAddr)
+			if err := grpcServer.Serve(lis); err != nil {
+				app.Logger.Error("gRPC server failed", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// Reload configuration on SIGHUP; connections stay open throughout
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			app.Logger.Info("Received SIGHUP, reloading configuration")
+			// Reload logs its outcome
+			reloader.Reload(workerCtx)
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	signal.Stop(hup)
 
 	app.Logger.Info("Shutting down server...")
 
+	// Stop claiming new background work; running jobs and deliveries drain
+	// while the HTTP server finishes outstanding requests
+	stopWorkers()
+
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Drain gRPC calls alongside HTTP requests; closing the broker on HTTP
+	// shutdown also ends WatchItems streams
+	var grpcDone sync.WaitGroup
+	if grpcServer != nil {
+		grpcDone.Add(1)
+		go func() {
+			defer grpcDone.Done()
+			if err := grpcServer.Shutdown(ctx); err != nil {
+				app.Logger.Error("gRPC server forced to shutdown", "error", err)
+			}
+		}()
+	}
+
 	if err := srv.Shutdown(ctx); err != nil {
 		app.Logger.Error("Server forced to shutdown", "error", err)
 		os.Exit(1)
 	}
+	grpcDone.Wait()
+
+	workers.Wait()
 
 	app.Logger.Info("Server exited")
 }
+
+// liveSettings maps the reloadable settings onto the API's live settings
+func liveSettings(config *setup.Config, identities map[string]string) server.Live {
+	return server.Live{
+		RateLimits: server.RateLimits{
+			Read:  ratelimit.Limit{Rate: config.RateLimitReadRate, Burst: config.RateLimitReadBurst},
+			Write: ratelimit.Limit{Rate: config.RateLimitWriteRate, Burst: config.RateLimitWriteBurst},
+			Bulk:  ratelimit.Limit{Rate: config.RateLimitBulkRate, Burst: config.RateLimitBulkBurst},
+		},
+		CacheControl: server.CacheControl{
+			Item: config.CacheControlItem,
+			List: config.CacheControlList,
+		},
+		MaxBodyBytes:        config.ServerMaxBodyBytes,
+		HandlerTimeout:      config.HandlerTimeout,
+		ClientIdentities:    identities,
+		CompressionDisabled: !config.CompressionEnabled,
+	}
+}
@@ -0,0 +1,485 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/sethvargo/go-envconfig"
+	"gopkg.in/yaml.v3"
+
+	"github.com/joel-thompson/my-go-service/httpcompress"
+	"github.com/joel-thompson/my-go-service/tlsconfig"
+	"github.com/joel-thompson/my-go-service/validation"
+)
+
+// Sources of a setting's value, in increasing precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Setting describes one Config field. Its environment variable is its
+// canonical name; the config file uses the lower-case form (server_addr)
+// and flags the lower-case, dashed form (--server-addr).
+type Setting struct {
+	Env      string
+	Default  string
+	Required bool
+	// Secret values are redacted by Redacted and have no flag
+	Secret bool
+	// Reloadable settings are applied by Reloader without a restart
+	Reloadable bool
+
+	index int
+}
+
+// FileKey is the setting's key in a config file
+func (s Setting) FileKey() string {
+	return strings.ToLower(s.Env)
+}
+
+// FlagName is the setting's command-line flag, without the leading dashes
+func (s Setting) FlagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.Env), "_", "-")
+}
+
+// Settings lists every Config field in declaration order
+func Settings() []Setting {
+	t := reflect.TypeFor[Config]()
+	settings := make([]Setting, 0, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		setting := Setting{
+			Env:        name,
+			Secret:     field.Tag.Get("secret") == "true",
+			Reloadable: field.Tag.Get("reload") == "true",
+			index:      i,
+		}
+		if opts == "required" {
+			setting.Required = true
+		} else if value, ok := strings.CutPrefix(opts, "default="); ok {
+			setting.Default = value
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// LoadOptions says where Load finds configuration besides the environment
+type LoadOptions struct {
+	// File is a YAML (.yaml, .yml) or TOML (.toml) file of settings; optional
+	File string
+
+	// Flags holds values given on the command line, keyed by environment variable name
+	Flags map[string]string
+
+	// Environ looks up environment variables (default os.LookupEnv)
+	Environ func(string) (string, bool)
+}
+
+// Loaded is a configuration together with where each value came from
+type Loaded struct {
+	Config *Config
+
+	// Sources maps each setting's environment variable name to one of the Source constants
+	Sources map[string]string
+}
+
+// Load builds the configuration from defaults, then File, then the
+// environment, then Flags, each overriding the one before. It does not
+// validate the result; call Config.Validate for that.
+func Load(ctx context.Context, opts LoadOptions) (*Loaded, error) {
+	fileValues := map[string]string{}
+	if opts.File != "" {
+		var err error
+		fileValues, err = readConfigFile(opts.File)
+		if err != nil {
+			return nil, err
+		}
+	}
+	environ := opts.Environ
+	if environ == nil {
+		environ = os.LookupEnv
+	}
+
+	sources := map[string]string{}
+	layers := []struct {
+		source string
+		lookup func(string) (string, bool)
+	}{
+		{SourceFlag, func(key string) (string, bool) { v, ok := opts.Flags[key]; return v, ok }},
+		{SourceEnv, environ},
+		{SourceFile, func(key string) (string, bool) { v, ok := fileValues[key]; return v, ok }},
+	}
+	lookuper := envconfig.LookuperFunc(func(key string) (string, bool) {
+		for _, layer := range layers {
+			if value, ok := layer.lookup(key); ok {
+				sources[key] = layer.source
+				return value, true
+			}
+		}
+		sources[key] = SourceDefault
+		return "", false
+	})
+
+	var config Config
+	if err := envconfig.ProcessWith(ctx, &envconfig.Config{Target: &config, Lookuper: lookuper}); err != nil {
+		return nil, err
+	}
+	return &Loaded{Config: &config, Sources: sources}, nil
+}
+
+// readConfigFile reads a flat YAML or TOML file into values keyed by
+// environment variable name. Unknown keys are errors, so typos are caught
+// rather than silently ignored.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("%s: unknown config file type %q (expected .yaml, .yml or .toml)", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	known := map[string]bool{}
+	for _, setting := range Settings() {
+		known[setting.Env] = true
+	}
+
+	values := make(map[string]string, len(raw))
+	var errs []error
+	for key, value := range raw {
+		env := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if !known[env] {
+			errs = append(errs, fmt.Errorf("unknown setting %q", key))
+			continue
+		}
+		s, err := fileValue(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		values[env] = s
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// fileValue renders a decoded file value the way it would be written in
+// an environment variable; lists become comma-separated
+func fileValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.DateOnly), nil
+	case toml.LocalDate:
+		return v.String(), nil
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			s, err := fileValue(item)
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(s, ",") {
+				return "", fmt.Errorf("list entry %q contains a comma", s)
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T (expected a string, number, boolean or list)", value)
+	}
+}
+
+// Validate checks every setting that can be checked without connecting to
+// anything, and reports all the problems it finds at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	_, err := parseLogLevel(c.LogLevel)
+	check(err)
+	check(validateAddr("SERVER_ADDR", c.ServerAddr, false))
+	check(validateAddr("GRPC_ADDR", c.GRPCAddr, true))
+	check(validateAddr("CACHE_REDIS_ADDR", c.CacheRedisAddr, true))
+	check(validateDatabaseURL(c.DatabaseURL))
+	if c.OutboxHTTPURL != "" {
+		check(validateURL("OUTBOX_HTTP_URL", c.OutboxHTTPURL, "http", "https"))
+	}
+
+	check(oneOf("BLOB_STORE", c.BlobStore, "local", "s3"))
+	if c.BlobStore == "s3" && c.S3Endpoint == "" {
+		check(errors.New("S3_ENDPOINT is required when BLOB_STORE=s3"))
+	}
+	check(oneOf("OUTBOX_PUBLISHER", c.OutboxPublisher, "file", "stdout", "http", "none"))
+	if c.OutboxPublisher == "http" && c.OutboxHTTPURL == "" {
+		check(errors.New("OUTBOX_HTTP_URL is required when OUTBOX_PUBLISHER=http"))
+	}
+	check(oneOf("RATE_LIMIT_BACKEND", c.RateLimitBackend, "memory", "postgres"))
+
+	limits := validation.Limits{
+		NameMaxLength:        c.ItemNameMaxLength,
+		DescriptionMaxLength: c.ItemDescriptionMaxLength,
+	}
+	if err := limits.Validate(); err != nil {
+		check(fmt.Errorf("invalid ITEM_*_MAX_LENGTH: %w", err))
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				check(fmt.Errorf("invalid TRUSTED_PROXIES entry %q", proxy))
+			}
+		}
+	}
+
+	if _, err := time.Parse(time.DateOnly, c.LegacyRoutesSunset); err != nil {
+		check(fmt.Errorf("invalid LEGACY_ROUTES_SUNSET %q (expected YYYY-MM-DD)", c.LegacyRoutesSunset))
+	}
+	if _, err := httpcompress.ParseLevel(c.CompressionLevel); err != nil {
+		check(fmt.Errorf("COMPRESSION_LEVEL: %w", err))
+	}
+	if c.GraphQLMaxDepth <= 0 || c.GraphQLMaxComplexity <= 0 {
+		check(errors.New("GRAPHQL_MAX_DEPTH and GRAPHQL_MAX_COMPLEXITY must be positive"))
+	}
+
+	clientAuth, err := tlsconfig.ParseClientAuth(c.TLSClientAuth)
+	if err != nil {
+		check(fmt.Errorf("TLS_CLIENT_AUTH: %w", err))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		check(errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if clientAuth != tlsconfig.ClientAuthNone && err == nil {
+		if c.TLSCertFile == "" {
+			check(errors.New("TLS_CLIENT_AUTH requires TLS_CERT_FILE and TLS_KEY_FILE"))
+		}
+		if c.TLSClientCAFile == "" {
+			check(errors.New("TLS_CLIENT_CA_FILE is required when TLS_CLIENT_AUTH is request or require"))
+		}
+	}
+
+	for _, n := range []struct {
+		name  string
+		value int
+	}{
+		{"WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts},
+		{"WEBHOOK_WORKERS", c.WebhookWorkers},
+		{"OUTBOX_BATCH_SIZE", c.OutboxBatchSize},
+		{"JOB_WORKERS", c.JobWorkers},
+		{"CACHE_SIZE", c.CacheSize},
+		{"DB_MAX_OPEN_CONNS", c.DBMaxOpenConns},
+	} {
+		if n.value <= 0 {
+			check(fmt.Errorf("%s must be positive, got %d", n.name, n.value))
+		}
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HANDLER_TIMEOUT", c.HandlerTimeout},
+		{"WEBHOOK_POLL_INTERVAL", c.WebhookPollInterval},
+		{"OUTBOX_POLL_INTERVAL", c.OutboxPollInterval},
+		{"JOB_POLL_INTERVAL", c.JobPollInterval},
+		{"DB_CONNECT_TIMEOUT", c.DBConnectTimeout},
+	} {
+		if d.value <= 0 {
+			check(fmt.Errorf("%s must be positive, got %s", d.name, d.value))
+		}
+	}
+
+	if c.DBMaxIdleConns < 0 || c.DBMaxIdleConns > c.DBMaxOpenConns {
+		check(fmt.Errorf("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS, got %d", c.DBMaxIdleConns))
+	}
+	if c.DBConnMaxLifetime < 0 || c.DBConnMaxIdleTime < 0 || c.DBStatementTimeout < 0 {
+		check(errors.New("DB_CONN_MAX_LIFETIME, DB_CONN_MAX_IDLE_TIME and DB_STATEMENT_TIMEOUT cannot be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// parseLogLevel maps LOG_LEVEL to a slog level
+func parseLogLevel(s string) (slog.Level, error) {
+	switch s {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid LOG_LEVEL %q (expected debug, info, warn or error)", s)
+	}
+}
+
+// validateAddr checks a host:port listen or dial address; the host may be empty
+func validateAddr(name, addr string, optional bool) error {
+	if addr == "" && optional {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid %s %q: port must be a number from 0 to 65535", name, addr)
+	}
+	return nil
+}
+
+// validateURL checks that s is an absolute URL with a host and one of schemes
+func validateURL(name, s string, schemes ...string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		// url.Error repeats the URL, which may hold a password
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("invalid %s: scheme must be %s", name, strings.Join(schemes, " or "))
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid %s: missing host", name)
+	}
+	return nil
+}
+
+// validateDatabaseURL checks DATABASE_URL, which may be a postgres:// URL or
+// a keyword/value string such as "host=db user=app dbname=app"; only the URL
+// form must name a host, since pgx defaults the other to the local socket
+func validateDatabaseURL(s string) error {
+	if strings.Contains(s, "://") {
+		return validateURL("DATABASE_URL", s, "postgres", "postgresql")
+	}
+	// pgx redacts the password from its parse errors
+	if _, err := pgx.ParseConfig(s); err != nil {
+		return fmt.Errorf("invalid DATABASE_URL: %w", err)
+	}
+	return nil
+}
+
+// oneOf checks that value is one of allowed
+func oneOf(name, value string, allowed ...string) error {
+	if slices.Contains(allowed, value) {
+		return nil
+	}
+	return fmt.Errorf("unknown %s %q (expected %s)", name, value, strings.Join(allowed, ", "))
+}
+
+// Redacted renders the configuration as a YAML config file, with secrets
+// replaced by "REDACTED" (URLs keep everything but their password) and
+// each value's source noted in a comment
+func (l *Loaded) Redacted() ([]byte, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	v := reflect.ValueOf(l.Config).Elem()
+	for _, setting := range Settings() {
+		value := configValue(v.Field(setting.index), setting.Secret)
+		value.LineComment = l.Sources[setting.Env]
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: setting.FileKey()}, value)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// configValue converts a Config field to a YAML node
+func configValue(field reflect.Value, secret bool) *yaml.Node {
+	if field.Kind() == reflect.Slice {
+		list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := range field.Len() {
+			list.Content = append(list.Content, configValue(field.Index(i), secret))
+		}
+		return list
+	}
+
+	node := &yaml.Node{Kind: yaml.ScalarNode}
+	switch value := field.Interface().(type) {
+	case time.Duration:
+		node.Value = value.String()
+	case string:
+		node.Value = value
+		if secret && value != "" {
+			node.Value = redact(value)
+		}
+		// Quote strings that would otherwise read back as another type
+		node.Tag = "!!str"
+	default:
+		node.Value = fmt.Sprint(value)
+	}
+	return node
+}
+
+// redact hides a secret; URLs keep everything but their password
+func redact(value string) string {
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	return "REDACTED"
+}
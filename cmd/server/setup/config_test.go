@@ -0,0 +1,30 @@
+package setup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDatabaseURL(t *testing.T) {
+	for _, tc := range []struct {
+		url string
+		ok  bool
+	}{
+		{testDatabaseURL, true},
+		{"postgresql://app@db/app?sslmode=disable", true},
+		{"host=db user=app password=hunter2 dbname=app", true},
+		{"user=app dbname=app", true},
+		{"mysql://app:hunter2@db/app", false},
+		{"postgres:///app", false},
+		{"host=db user", false},
+		{"host='db password=hunter2", false},
+	} {
+		err := validateDatabaseURL(tc.url)
+		if (err == nil) != tc.ok {
+			t.Errorf("validateDatabaseURL(%q) = %v, want ok %v", tc.url, err, tc.ok)
+		}
+		if err != nil && strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("validateDatabaseURL(%q) error %q shows the password", tc.url, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package setup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// Backoff between database connection attempts at startup
+const (
+	connectBackoffMin = 250 * time.Millisecond
+	connectBackoffMax = 5 * time.Second
+)
+
+// connectDB opens the connection pool described by config and waits for
+// the database to answer, retrying with jittered exponential backoff until
+// DB_CONNECT_TIMEOUT so the server can start alongside a database that is
+// still coming up. Errors that retrying cannot fix, such as a rejected
+// password or a missing database, fail at once.
+func connectDB(ctx context.Context, config *Config, logger *slog.Logger) (*sqlx.DB, error) {
+	connConfig, err := pgx.ParseConfig(config.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("DATABASE_URL: %w", err)
+	}
+	if config.DBStatementTimeout > 0 {
+		// Sent as a startup parameter, so every pooled connection gets it
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.DBStatementTimeout.Milliseconds(), 10)
+	}
+
+	db := sqlx.NewDb(stdlib.OpenDB(*connConfig), "pgx")
+	configurePool(db, config)
+
+	ctx, cancel := context.WithTimeout(ctx, config.DBConnectTimeout)
+	defer cancel()
+
+	backoff := connectBackoffMin
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+		if permanentConnectError(err) || ctx.Err() != nil {
+			db.Close()
+			return nil, fmt.Errorf("connect to database: %w", err)
+		}
+
+		delay := backoff/2 + rand.N(backoff/2)
+		deadline, _ := ctx.Deadline()
+		if time.Until(deadline) < delay {
+			db.Close()
+			return nil, fmt.Errorf("connect to database: gave up after %d attempts in %s: %w",
+				attempt, config.DBConnectTimeout, err)
+		}
+		logger.Warn("Database not ready, retrying", "attempt", attempt, "retry_in", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		backoff = min(backoff*2, connectBackoffMax)
+	}
+}
+
+// permanentConnectError reports whether Postgres refused the connection for
+// a reason that will not go away on its own
+func permanentConnectError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || len(pgErr.Code) != 5 {
+		return false
+	}
+	switch pgErr.Code[:2] {
+	case "28", "3D": // invalid authorization, invalid catalog name
+		return true
+	}
+	return false
+}
+
+// configurePool applies the DB_* pool settings; Reloader calls it again on
+// reload, and database/sql applies new limits to a pool that is in use
+func configurePool(db *sqlx.DB, config *Config) {
+	db.SetMaxOpenConns(config.DBMaxOpenConns)
+	db.SetMaxIdleConns(config.DBMaxIdleConns)
+	db.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
+}
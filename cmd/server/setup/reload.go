@@ -0,0 +1,219 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/joel-thompson/my-go-service/tlsconfig"
+)
+
+// Change is a setting whose value differs between two configurations.
+// Values are redacted like Loaded.Redacted.
+type Change struct {
+	Setting    string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+// Diff lists the settings that differ between old and new, in Config order
+func Diff(old, new *Config) []Change {
+	oldValue := reflect.ValueOf(old).Elem()
+	newValue := reflect.ValueOf(new).Elem()
+
+	var changes []Change
+	for _, setting := range Settings() {
+		before, after := oldValue.Field(setting.index), newValue.Field(setting.index)
+		if reflect.DeepEqual(before.Interface(), after.Interface()) {
+			continue
+		}
+		changes = append(changes, Change{
+			Setting:    setting.Env,
+			Old:        displayValue(before, setting.Secret),
+			New:        displayValue(after, setting.Secret),
+			Reloadable: setting.Reloadable,
+		})
+	}
+	return changes
+}
+
+// displayValue renders a Config field on one line, redacting secrets
+func displayValue(field reflect.Value, secret bool) string {
+	node := configValue(field, secret)
+	if node.Kind != yaml.SequenceNode {
+		return node.Value
+	}
+	values := make([]string, len(node.Content))
+	for i, item := range node.Content {
+		values[i] = item.Value
+	}
+	return strings.Join(values, ",")
+}
+
+// ReloadResult describes a successful reload
+type ReloadResult struct {
+	// Generation is the configuration generation now running
+	Generation int
+
+	// Changes lists every setting that changed since the previous generation
+	Changes []Change
+
+	// PendingRestart lists settings that differ from the ones the server
+	// started with but only take effect after a restart
+	PendingRestart []string
+}
+
+// ReloadStatus describes the running configuration
+type ReloadStatus struct {
+	Generation     int
+	LoadedAt       time.Time
+	LastReloadAt   *time.Time
+	LastError      string
+	PendingRestart []string
+}
+
+// ApplyFunc applies the reloadable settings of config to the running server.
+// identities is the re-read TLS_CLIENT_IDENTITIES_FILE, or nil.
+type ApplyFunc func(config *Config, identities map[string]string)
+
+// Reloader re-reads the configuration from the same file, environment and
+// flags the server started with, and applies the settings tagged reload
+// while the server keeps serving. A reload is all or nothing: if the new
+// configuration fails to load or validate, or a file it names cannot be
+// read, nothing changes. It is safe for concurrent use.
+type Reloader struct {
+	app   *App
+	opts  LoadOptions
+	apply ApplyFunc
+
+	mu           sync.Mutex
+	started      *Config
+	current      *Config
+	generation   int
+	loadedAt     time.Time
+	lastReloadAt *time.Time
+	lastError    string
+	pending      []string
+}
+
+// NewReloader creates a reloader for app, whose configuration was loaded
+// with opts; generation 1 is the configuration the app started with
+func NewReloader(app *App, opts LoadOptions, apply ApplyFunc) *Reloader {
+	return &Reloader{
+		app:        app,
+		opts:       opts,
+		apply:      apply,
+		started:    app.Config,
+		current:    app.Config,
+		generation: 1,
+		loadedAt:   time.Now(),
+	}
+}
+
+// Reload loads the configuration again and applies what changed. The TLS
+// certificate is re-read whether or not its file names changed, so a
+// renewal can be picked up at once.
+func (r *Reloader) Reload(ctx context.Context) (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.lastReloadAt = &now
+
+	result, err := r.reload(ctx)
+	if err != nil {
+		r.lastError = err.Error()
+		r.app.Logger.Error("Failed to reload configuration", "generation", r.generation, "error", err)
+		return nil, err
+	}
+	r.lastError = ""
+	return result, nil
+}
+
+func (r *Reloader) reload(ctx context.Context) (*ReloadResult, error) {
+	loaded, err := Load(ctx, r.opts)
+	if err != nil {
+		return nil, err
+	}
+	config := loaded.Config
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	level, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	var identities map[string]string
+	if config.TLSClientIdentitiesFile != "" {
+		identities, err = tlsconfig.LoadIdentities(config.TLSClientIdentitiesFile)
+		if err != nil {
+			return nil, fmt.Errorf("TLS_CLIENT_IDENTITIES_FILE: %w", err)
+		}
+	}
+	if r.app.Certificate != nil {
+		reloaded, err := r.app.Certificate.Reload()
+		if err != nil {
+			return nil, err
+		}
+		if reloaded {
+			r.app.Logger.Info("Reloaded TLS certificate", "not_after", r.app.Certificate.NotAfter())
+		}
+	}
+
+	// Everything is loaded, so nothing below can fail
+	r.app.LogLevel.Set(level)
+	configurePool(r.app.DB, config)
+	r.apply(config, identities)
+
+	changes := Diff(r.current, config)
+	r.current = config
+	r.generation++
+	r.loadedAt = *r.lastReloadAt
+	r.pending = r.pending[:0]
+	for _, change := range Diff(r.started, config) {
+		if !change.Reloadable {
+			r.pending = append(r.pending, change.Setting)
+		}
+	}
+
+	for _, change := range changes {
+		if change.Reloadable {
+			r.app.Logger.Info("Setting changed", "setting", change.Setting, "old", change.Old, "new", change.New)
+		} else {
+			r.app.Logger.Warn("Setting changed, restart to apply", "setting", change.Setting, "old", change.Old, "new", change.New)
+		}
+	}
+	r.app.Logger.Info("Reloaded configuration",
+		"generation", r.generation, "changes", len(changes), "pending_restart", r.pending)
+
+	return &ReloadResult{
+		Generation:     r.generation,
+		Changes:        changes,
+		PendingRestart: slices.Clone(r.pending),
+	}, nil
+}
+
+// Status reports the running generation and the outcome of the last reload
+func (r *Reloader) Status() ReloadStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := slices.Clone(r.pending)
+	if pending == nil {
+		pending = []string{}
+	}
+	return ReloadStatus{
+		Generation:     r.generation,
+		LoadedAt:       r.loadedAt,
+		LastReloadAt:   r.lastReloadAt,
+		LastError:      r.lastError,
+		PendingRestart: pending,
+	}
+}
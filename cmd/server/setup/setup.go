@@ -2,22 +2,166 @@ package setup
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
-	"github.com/sethvargo/go-envconfig"
+
+	"github.com/joel-thompson/my-go-service/cache"
+	"github.com/joel-thompson/my-go-service/httpcompress"
+	"github.com/joel-thompson/my-go-service/outbox"
+	"github.com/joel-thompson/my-go-service/ratelimit"
+	"github.com/joel-thompson/my-go-service/retention"
+	"github.com/joel-thompson/my-go-service/storage"
+	"github.com/joel-thompson/my-go-service/tlsconfig"
+	"github.com/joel-thompson/my-go-service/validation"
 )
 
-// Config holds all configuration for the application
+// Config holds all configuration for the application. Each field's env tag
+// names its environment variable and, through Settings, its config file key
+// and flag; fields tagged secret are redacted when printed, and fields
+// tagged reload are applied by Reloader without a restart.
 type Config struct {
 	ServerAddr  string `env:"SERVER_ADDR,default=:8080"`
-	DatabaseURL string `env:"DATABASE_URL,required"`
-	LogLevel    string `env:"LOG_LEVEL,default=info"`
+	DatabaseURL string `env:"DATABASE_URL,required" secret:"true"`
+	LogLevel    string `env:"LOG_LEVEL,default=info" reload:"true"`
 	LogFile     string `env:"LOG_FILE"`
+
+	// Database connection pool. Startup retries the connection until DB_CONNECT_TIMEOUT, and
+	// DB_STATEMENT_TIMEOUT (0 disables it) cancels any single statement that runs longer.
+	DBMaxOpenConns     int           `env:"DB_MAX_OPEN_CONNS,default=25" reload:"true"`
+	DBMaxIdleConns     int           `env:"DB_MAX_IDLE_CONNS,default=10" reload:"true"`
+	DBConnMaxLifetime  time.Duration `env:"DB_CONN_MAX_LIFETIME,default=30m" reload:"true"`
+	DBConnMaxIdleTime  time.Duration `env:"DB_CONN_MAX_IDLE_TIME,default=5m" reload:"true"`
+	DBConnectTimeout   time.Duration `env:"DB_CONNECT_TIMEOUT,default=30s"`
+	DBStatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT,default=30s"`
+
+	// HTTP server limits. Streaming routes (the change stream, GraphQL subscriptions and
+	// attachment transfers) are exempt from the write and handler timeouts.
+	ServerReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT,default=5s"`
+	ServerReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT,default=30s"`
+	ServerWriteTimeout      time.Duration `env:"SERVER_WRITE_TIMEOUT,default=60s"`
+	ServerIdleTimeout       time.Duration `env:"SERVER_IDLE_TIMEOUT,default=2m"`
+	ServerMaxHeaderBytes    int           `env:"SERVER_MAX_HEADER_BYTES,default=65536"`
+	ServerMaxBodyBytes      int64         `env:"SERVER_MAX_BODY_BYTES,default=1048576" reload:"true"`
+	HandlerTimeout          time.Duration `env:"HANDLER_TIMEOUT,default=30s" reload:"true"`
+
+	// Native TLS, enabled by TLS_CERT_FILE and TLS_KEY_FILE; the pair is reloaded when the
+	// files change. TLS_CLIENT_AUTH (none, request or require) verifies client certificates
+	// against TLS_CLIENT_CA_FILE, and TLS_CLIENT_IDENTITIES_FILE maps their subjects to identities.
+	TLSCertFile             string        `env:"TLS_CERT_FILE"`
+	TLSKeyFile              string        `env:"TLS_KEY_FILE"`
+	TLSReloadInterval       time.Duration `env:"TLS_RELOAD_INTERVAL,default=30s"`
+	TLSClientAuth           string        `env:"TLS_CLIENT_AUTH,default=none"`
+	TLSClientCAFile         string        `env:"TLS_CLIENT_CA_FILE"`
+	TLSClientIdentitiesFile string        `env:"TLS_CLIENT_IDENTITIES_FILE" reload:"true"`
+
+	// MetadataSchemaFile is an optional JSON Schema that item metadata must satisfy
+	MetadataSchemaFile string `env:"METADATA_SCHEMA_FILE"`
+
+	// Item field limits in characters; names cannot exceed the VARCHAR(255) column
+	ItemNameMaxLength        int `env:"ITEM_NAME_MAX_LENGTH,default=255"`
+	ItemDescriptionMaxLength int `env:"ITEM_DESCRIPTION_MAX_LENGTH,default=10000"`
+
+	// Attachment storage: "local" keeps files under BlobDir, "s3" uses an S3-compatible bucket
+	BlobStore          string `env:"BLOB_STORE,default=local"`
+	BlobDir            string `env:"BLOB_DIR,default=./data/attachments"`
+	S3Endpoint         string `env:"S3_ENDPOINT"`
+	S3Region           string `env:"S3_REGION"`
+	S3Bucket           string `env:"S3_BUCKET,default=attachments"`
+	S3AccessKey        string `env:"S3_ACCESS_KEY" secret:"true"`
+	S3SecretKey        string `env:"S3_SECRET_KEY" secret:"true"`
+	S3UseSSL           bool   `env:"S3_USE_SSL,default=true"`
+	MaxAttachmentBytes int64  `env:"MAX_ATTACHMENT_BYTES,default=26214400"`
+
+	// Outgoing webhook delivery
+	WebhookMaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS,default=8"`
+	WebhookTimeout      time.Duration `env:"WEBHOOK_TIMEOUT,default=10s"`
+	WebhookPollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL,default=1s"`
+	WebhookWorkers      int           `env:"WEBHOOK_WORKERS,default=4"`
+
+	// Outbox relay: "file", "stdout", "http" or "none" to leave messages in the table
+	OutboxPublisher    string        `env:"OUTBOX_PUBLISHER,default=file"`
+	OutboxFile         string        `env:"OUTBOX_FILE,default=./data/outbox.jsonl"`
+	OutboxHTTPURL      string        `env:"OUTBOX_HTTP_URL"`
+	OutboxHTTPTimeout  time.Duration `env:"OUTBOX_HTTP_TIMEOUT,default=10s"`
+	OutboxBatchSize    int           `env:"OUTBOX_BATCH_SIZE,default=100"`
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL,default=1s"`
+	OutboxRetention    time.Duration `env:"OUTBOX_RETENTION,default=168h"`
+	OutboxLease        time.Duration `env:"OUTBOX_LEASE,default=5m"`
+
+	// Background job workers
+	JobWorkers           int           `env:"JOB_WORKERS,default=4"`
+	JobPollInterval      time.Duration `env:"JOB_POLL_INTERVAL,default=1s"`
+	JobHeartbeatInterval time.Duration `env:"JOB_HEARTBEAT_INTERVAL,default=10s"`
+	JobStaleAfter        time.Duration `env:"JOB_STALE_AFTER,default=1m"`
+	JobDrainTimeout      time.Duration `env:"JOB_DRAIN_TIMEOUT,default=25s"`
+
+	// RetentionRulesFile is an optional JSON file of retention rules
+	RetentionRulesFile string `env:"RETENTION_RULES_FILE"`
+
+	// Item read cache; the Redis-protocol remote cache is used when CACHE_REDIS_ADDR is set.
+	// CACHE_ENABLED needs a restart: while the cache is off, writes do not invalidate the
+	// remote cache, so switching it back on live could serve stale shared entries.
+	CacheEnabled       bool          `env:"CACHE_ENABLED,default=true"`
+	CacheSize          int           `env:"CACHE_SIZE,default=10000"`
+	CacheTTL           time.Duration `env:"CACHE_TTL,default=30s"`
+	CacheListTTL       time.Duration `env:"CACHE_LIST_TTL,default=5s"`
+	CacheRedisAddr     string        `env:"CACHE_REDIS_ADDR"`
+	CacheRedisPassword string        `env:"CACHE_REDIS_PASSWORD" secret:"true"`
+	CacheRedisDB       int           `env:"CACHE_REDIS_DB,default=0"`
+	CacheRemoteTTL     time.Duration `env:"CACHE_REMOTE_TTL,default=5m"`
+
+	// Rate limiting: "memory" buckets per instance or "postgres" buckets shared by all instances.
+	// Rates are tokens per second; a rate or burst of 0 leaves that route group unlimited.
+	RateLimitEnabled    bool     `env:"RATE_LIMIT_ENABLED,default=true"`
+	RateLimitBackend    string   `env:"RATE_LIMIT_BACKEND,default=memory"`
+	RateLimitReadRate   float64  `env:"RATE_LIMIT_READ_RATE,default=20" reload:"true"`
+	RateLimitReadBurst  int      `env:"RATE_LIMIT_READ_BURST,default=40" reload:"true"`
+	RateLimitWriteRate  float64  `env:"RATE_LIMIT_WRITE_RATE,default=5" reload:"true"`
+	RateLimitWriteBurst int      `env:"RATE_LIMIT_WRITE_BURST,default=10" reload:"true"`
+	RateLimitBulkRate   float64  `env:"RATE_LIMIT_BULK_RATE,default=0.2" reload:"true"`
+	RateLimitBulkBurst  int      `env:"RATE_LIMIT_BULK_BURST,default=3" reload:"true"`
+	RateLimitKeyHeader  string   `env:"RATE_LIMIT_KEY_HEADER,default=X-API-Key"`
+	RateLimitAPIKeys    []string `env:"RATE_LIMIT_API_KEYS" secret:"true"`
+
+	// TrustedProxies lists proxy IPs or CIDRs allowed to set X-Forwarded-For (comma-separated)
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+
+	// Deprecated unversioned aliases of the /v1 routes, and the date (YYYY-MM-DD) they are due to be removed.
+	// LEGACY_ROUTES_ENABLED needs a restart, since it decides which routes the router is built with.
+	LegacyRoutesEnabled bool   `env:"LEGACY_ROUTES_ENABLED,default=true"`
+	LegacyRoutesSunset  string `env:"LEGACY_ROUTES_SUNSET,default=2027-04-18"`
+
+	// Cache-Control sent with GET /items/:id and GET /items; responses carry ETag and
+	// Last-Modified either way, so clients can revalidate with a 304
+	CacheControlItem string `env:"CACHE_CONTROL_ITEM,default=private, no-cache" reload:"true"`
+	CacheControlList string `env:"CACHE_CONTROL_LIST,default=private, no-cache" reload:"true"`
+
+	// Response compression (gzip, zstd or brotli, negotiated from Accept-Encoding) for
+	// bodies of at least COMPRESSION_MIN_SIZE bytes in one of COMPRESSION_CONTENT_TYPES
+	// (comma-separated, empty for the defaults). Gzip request bodies are accepted either
+	// way, up to COMPRESSION_MAX_DECOMPRESSED_BYTES once decompressed.
+	CompressionEnabled              bool     `env:"COMPRESSION_ENABLED,default=true" reload:"true"`
+	CompressionMinSize              int      `env:"COMPRESSION_MIN_SIZE,default=1024"`
+	CompressionContentTypes         []string `env:"COMPRESSION_CONTENT_TYPES"`
+	CompressionLevel                string   `env:"COMPRESSION_LEVEL,default=default"`
+	CompressionMaxDecompressedBytes int64    `env:"COMPRESSION_MAX_DECOMPRESSED_BYTES,default=33554432"`
+
+	// Limits on /graphql operations: how deeply fields may nest and the estimated fields resolved
+	GraphQLMaxDepth      int `env:"GRAPHQL_MAX_DEPTH,default=10"`
+	GraphQLMaxComplexity int `env:"GRAPHQL_MAX_COMPLEXITY,default=1000"`
+
+	// gRPC listener for ItemsService (empty to disable) and the API keys it
+	// accepts (comma-separated); with no keys every call is accepted
+	GRPCAddr    string   `env:"GRPC_ADDR,default=:9090"`
+	GRPCAPIKeys []string `env:"GRPC_API_KEYS" secret:"true"`
 }
 
 // App holds all dependencies for the application
@@ -26,30 +170,61 @@ type App struct {
 	logFile *os.File
 	Logger  *slog.Logger
 	DB      *sqlx.DB
+
+	// LogLevel is the logger's level, changed by Reloader
+	LogLevel *slog.LevelVar
+
+	// MetadataSchema is nil unless METADATA_SCHEMA_FILE is set
+	MetadataSchema *storage.MetadataSchema
+
+	// Validator enforces the ITEM_*_MAX_LENGTH limits
+	Validator *validation.Validator
+
+	// Blobs stores attachment contents
+	Blobs storage.BlobStore
+
+	// Outbox publishes relayed item changes; nil when OUTBOX_PUBLISHER=none
+	Outbox outbox.Publisher
+
+	// RetentionRules is empty unless RETENTION_RULES_FILE is set
+	RetentionRules []retention.Rule
+
+	// CacheRemote is nil unless caching is enabled and CACHE_REDIS_ADDR is set
+	CacheRemote cache.Remote
+
+	// RateLimiter is nil when RATE_LIMIT_ENABLED=false
+	RateLimiter ratelimit.Limiter
+
+	// LegacyRoutesSunset is LEGACY_ROUTES_SUNSET, announced on the unversioned routes
+	LegacyRoutesSunset time.Time
+
+	// CompressionLevel is COMPRESSION_LEVEL
+	CompressionLevel httpcompress.Level
+
+	// TLS is nil unless TLS_CERT_FILE and TLS_KEY_FILE are set
+	TLS *tls.Config
+
+	// Certificate reloads the TLS certificate; nil without TLS
+	Certificate *tlsconfig.Reloader
+
+	// ClientIdentities is nil unless TLS_CLIENT_IDENTITIES_FILE is set
+	ClientIdentities map[string]string
 }
 
-// NewApp creates a new application instance with all dependencies
-func NewApp(ctx context.Context) (*App, error) {
-	// Load configuration
-	var config Config
-	if err := envconfig.Process(ctx, &config); err != nil {
+// NewApp creates a new application instance with all dependencies from a
+// configuration returned by Load
+func NewApp(ctx context.Context, config *Config) (*App, error) {
+	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 
 	// Setup logger
-	var logLevel slog.Level
-	switch config.LogLevel {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "info":
-		logLevel = slog.LevelInfo
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
+	level, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
 	}
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(level)
 
 	var writer io.Writer = os.Stdout
 	var logFile *os.File
@@ -68,22 +243,218 @@ func NewApp(ctx context.Context) (*App, error) {
 		Level: logLevel,
 	}))
 
+	// Load the optional metadata schema before connecting so a bad file fails fast
+	var metadataSchema *storage.MetadataSchema
+	if config.MetadataSchemaFile != "" {
+		var err error
+		metadataSchema, err = storage.LoadMetadataSchema(config.MetadataSchemaFile)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Loaded metadata schema", "file", config.MetadataSchemaFile)
+	}
+
+	limits := validation.Limits{
+		NameMaxLength:        config.ItemNameMaxLength,
+		DescriptionMaxLength: config.ItemDescriptionMaxLength,
+	}
+
+	var retentionRules []retention.Rule
+	if config.RetentionRulesFile != "" {
+		var err error
+		retentionRules, err = retention.LoadRules(config.RetentionRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Loaded retention rules", "file", config.RetentionRulesFile, "rules", len(retentionRules))
+	}
+
+	legacySunset, err := time.Parse(time.DateOnly, config.LegacyRoutesSunset)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LEGACY_ROUTES_SUNSET %q (expected YYYY-MM-DD)", config.LegacyRoutesSunset)
+	}
+
+	compressionLevel, err := httpcompress.ParseLevel(config.CompressionLevel)
+	if err != nil {
+		return nil, fmt.Errorf("COMPRESSION_LEVEL: %w", err)
+	}
+
+	tlsConfig, certificate, clientIdentities, err := newTLS(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		logger.Info("Configured TLS", "cert_file", config.TLSCertFile, "client_auth", config.TLSClientAuth, "not_after", certificate.NotAfter())
+	}
+
+	blobs, err := newBlobStore(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Configured attachment storage", "blob_store", config.BlobStore)
+
+	publisher, err := newOutboxPublisher(config)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Configured outbox publisher", "outbox_publisher", config.OutboxPublisher)
+
+	var cacheRemote cache.Remote
+	if config.CacheEnabled && config.CacheRedisAddr != "" {
+		cacheRemote = cache.NewRedis(cache.RedisConfig{
+			Addr:     config.CacheRedisAddr,
+			Password: config.CacheRedisPassword,
+			DB:       config.CacheRedisDB,
+		})
+		logger.Info("Configured remote cache", "addr", config.CacheRedisAddr)
+	}
+
 	// Setup database connection
-	db, err := sqlx.Connect("pgx", config.DatabaseURL)
+	db, err := connectDB(ctx, config, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	logger.Info("Connected to database")
+	logger.Info("Connected to database", "max_open_conns", config.DBMaxOpenConns, "statement_timeout", config.DBStatementTimeout)
+
+	rateLimiter, err := newRateLimiter(config, logger, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if rateLimiter != nil {
+		logger.Info("Configured rate limiting", "rate_limit_backend", config.RateLimitBackend)
+	}
 
 	return &App{
-		Config:  &config,
-		Logger:  logger,
-		DB:      db,
-		logFile: logFile,
+		Config:             config,
+		Logger:             logger,
+		LogLevel:           logLevel,
+		DB:                 db,
+		MetadataSchema:     metadataSchema,
+		Validator:          validation.New(limits),
+		Blobs:              blobs,
+		Outbox:             publisher,
+		RetentionRules:     retentionRules,
+		CacheRemote:        cacheRemote,
+		RateLimiter:        rateLimiter,
+		LegacyRoutesSunset: legacySunset,
+		CompressionLevel:   compressionLevel,
+		TLS:                tlsConfig,
+		Certificate:        certificate,
+		ClientIdentities:   clientIdentities,
+		logFile:            logFile,
 	}, nil
 }
 
+// newBlobStore creates the attachment blob store selected by BLOB_STORE
+func newBlobStore(ctx context.Context, config *Config) (storage.BlobStore, error) {
+	switch config.BlobStore {
+	case "local":
+		return storage.NewLocalBlobStore(config.BlobDir)
+	case "s3":
+		if config.S3Endpoint == "" {
+			return nil, errors.New("S3_ENDPOINT is required when BLOB_STORE=s3")
+		}
+		return storage.NewS3BlobStore(ctx, storage.S3Config{
+			Endpoint:  config.S3Endpoint,
+			Region:    config.S3Region,
+			Bucket:    config.S3Bucket,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			UseSSL:    config.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q (expected local or s3)", config.BlobStore)
+	}
+}
+
+// newOutboxPublisher creates the outbox publisher selected by OUTBOX_PUBLISHER
+func newOutboxPublisher(config *Config) (outbox.Publisher, error) {
+	switch config.OutboxPublisher {
+	case "none":
+		return nil, nil
+	case "file":
+		return outbox.NewFilePublisher(config.OutboxFile)
+	case "stdout":
+		return outbox.NewStdoutPublisher(), nil
+	case "http":
+		if config.OutboxHTTPURL == "" {
+			return nil, errors.New("OUTBOX_HTTP_URL is required when OUTBOX_PUBLISHER=http")
+		}
+		return outbox.NewHTTPPublisher(config.OutboxHTTPURL, config.OutboxHTTPTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown OUTBOX_PUBLISHER %q (expected file, stdout, http or none)", config.OutboxPublisher)
+	}
+}
+
+// newTLS loads the server certificate, client CA and identity map named by
+// the TLS_* settings. Everything is nil when TLS is not configured.
+func newTLS(config *Config) (*tls.Config, *tlsconfig.Reloader, map[string]string, error) {
+	clientAuth, err := tlsconfig.ParseClientAuth(config.TLSClientAuth)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("TLS_CLIENT_AUTH: %w", err)
+	}
+	if config.TLSCertFile == "" && config.TLSKeyFile == "" {
+		if clientAuth != tlsconfig.ClientAuthNone {
+			return nil, nil, nil, errors.New("TLS_CLIENT_AUTH requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil, nil, nil, nil
+	}
+	if config.TLSCertFile == "" || config.TLSKeyFile == "" {
+		return nil, nil, nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	certificate, err := tlsconfig.NewReloader(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var clientCAs *x509.CertPool
+	if clientAuth != tlsconfig.ClientAuthNone {
+		if config.TLSClientCAFile == "" {
+			return nil, nil, nil, errors.New("TLS_CLIENT_CA_FILE is required when TLS_CLIENT_AUTH is request or require")
+		}
+		clientCAs, err = tlsconfig.LoadCertPool(config.TLSClientCAFile)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("TLS_CLIENT_CA_FILE: %w", err)
+		}
+	}
+
+	var identities map[string]string
+	if config.TLSClientIdentitiesFile != "" {
+		identities, err = tlsconfig.LoadIdentities(config.TLSClientIdentitiesFile)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("TLS_CLIENT_IDENTITIES_FILE: %w", err)
+		}
+	}
+
+	tlsConfig, err := tlsconfig.Server(tlsconfig.Config{
+		Certificate: certificate,
+		ClientCAs:   clientCAs,
+		ClientAuth:  clientAuth,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tlsConfig, certificate, identities, nil
+}
+
+// newRateLimiter creates the rate limiter selected by RATE_LIMIT_BACKEND
+func newRateLimiter(config *Config, logger *slog.Logger, db *sqlx.DB) (ratelimit.Limiter, error) {
+	if !config.RateLimitEnabled {
+		return nil, nil
+	}
+	switch config.RateLimitBackend {
+	case "memory":
+		return ratelimit.NewMemory(), nil
+	case "postgres":
+		return ratelimit.NewPostgres(logger, storage.New(db)), nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q (expected memory or postgres)", config.RateLimitBackend)
+	}
+}
+
 // Close cleans up application resources
 func (a *App) Close() error {
 
@@ -94,6 +465,16 @@ func (a *App) Close() error {
 			errs = append(errs, err)
 		}
 	}
+	if a.Outbox != nil {
+		if err := a.Outbox.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if a.CacheRemote != nil {
+		if err := a.CacheRemote.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if a.DB != nil {
 		if err := a.DB.Close(); err != nil {
 			errs = append(errs, err)
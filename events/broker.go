@@ -0,0 +1,244 @@
+// Package events fans out item changes to in-process subscribers.
+//
+// Changes are recorded in the item_events table by a database trigger, which
+// also NOTIFYs the new event id. Every server instance LISTENs on that channel,
+// so subscribers see changes made through any instance.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const (
+	// catchUpBatch is how many events are read per query when catching up after a reconnect
+	catchUpBatch = 500
+	// recentIDs is how many dispatched event ids are remembered for de-duplication
+	recentIDs = 1024
+	// maxReconnectDelay caps the backoff between LISTEN reconnect attempts
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Broker listens for item events and delivers them to subscribers
+type Broker struct {
+	logger      *slog.Logger
+	store       *storage.Store
+	databaseURL string
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+	lastID      int64
+
+	// recent remembers dispatched ids so an event seen by both catch-up and
+	// a notification after a reconnect is only delivered once
+	recent      map[int64]struct{}
+	recentOrder []int64
+}
+
+// NewBroker creates a broker; call Run to start listening
+func NewBroker(logger *slog.Logger, store *storage.Store, databaseURL string) *Broker {
+	return &Broker{
+		logger:      logger,
+		store:       store,
+		databaseURL: databaseURL,
+		subscribers: make(map[*Subscription]struct{}),
+		recent:      make(map[int64]struct{}),
+	}
+}
+
+// Subscription receives events that match its filter.
+// The channel is closed when the subscriber falls too far behind or the broker shuts down.
+type Subscription struct {
+	filter storage.ItemEventFilter
+	events chan storage.ItemEvent
+	once   sync.Once
+}
+
+// Events returns the channel of matching events
+func (s *Subscription) Events() <-chan storage.ItemEvent {
+	return s.events
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() { close(s.events) })
+}
+
+// Subscribe registers a subscriber with room for buffer undelivered events
+func (b *Broker) Subscribe(filter storage.ItemEventFilter, buffer int) *Subscription {
+	sub := &Subscription{
+		filter: filter,
+		events: make(chan storage.ItemEvent, buffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		sub.close()
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+	sub.close()
+}
+
+// Replay returns logged events after afterID that match filter, oldest first
+func (b *Broker) Replay(ctx context.Context, afterID int64, filter storage.ItemEventFilter, limit int) ([]storage.ItemEvent, error) {
+	return b.store.ListItemEventsAfter(ctx, afterID, filter, limit)
+}
+
+// Shutdown closes every subscription so streaming handlers return promptly
+func (b *Broker) Shutdown() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		sub.close()
+	}
+}
+
+// Run listens for notifications until ctx is cancelled, reconnecting with backoff
+func (b *Broker) Run(ctx context.Context) {
+	lastID, err := b.store.LatestItemEventID(ctx)
+	if err != nil {
+		b.logger.Error("Failed to read latest item event", "error", err)
+	}
+	b.setLastID(lastID)
+
+	delay := time.Second
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		b.logger.Error("Item event listener disconnected", "error", err, "retry_in", delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// listen holds a dedicated connection in LISTEN mode and dispatches notifications
+func (b *Broker) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+storage.ItemEventsChannel); err != nil {
+		return err
+	}
+	b.logger.Info("Listening for item events", "channel", storage.ItemEventsChannel)
+
+	// Anything committed while we were disconnected was not notified to us
+	if err := b.catchUp(ctx); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			b.logger.Warn("Ignoring malformed item event notification", "payload", notification.Payload)
+			continue
+		}
+
+		// Fetch by id rather than by range: ids are assigned before commit, so
+		// a range query could skip an event whose transaction commits late
+		events, err := b.store.GetItemEventsByID(ctx, []int64{id})
+		if err != nil {
+			return err
+		}
+		b.dispatch(events)
+	}
+}
+
+// catchUp delivers events logged since the last one this broker saw
+func (b *Broker) catchUp(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		after := b.lastID
+		b.mu.Unlock()
+
+		events, err := b.store.ListItemEventsAfter(ctx, after, storage.ItemEventFilter{}, catchUpBatch)
+		if err != nil {
+			return err
+		}
+		b.dispatch(events)
+		if len(events) < catchUpBatch {
+			return nil
+		}
+	}
+}
+
+// Publish delivers events to matching subscribers as if they had been read
+// from the item_events table, so subscribers can be driven without Postgres
+func (b *Broker) Publish(events ...storage.ItemEvent) {
+	b.dispatch(events)
+}
+
+// dispatch sends events to matching subscribers. A subscriber whose buffer is
+// full is dropped rather than blocking everyone else; it can reconnect and
+// resume from its last event id.
+func (b *Broker) dispatch(events []storage.ItemEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		if _, seen := b.recent[event.ID]; seen {
+			continue
+		}
+		b.recent[event.ID] = struct{}{}
+		b.recentOrder = append(b.recentOrder, event.ID)
+		if len(b.recentOrder) > recentIDs {
+			delete(b.recent, b.recentOrder[0])
+			b.recentOrder = b.recentOrder[1:]
+		}
+
+		if event.ID > b.lastID {
+			b.lastID = event.ID
+		}
+		for sub := range b.subscribers {
+			if !sub.filter.Matches(event) {
+				continue
+			}
+			select {
+			case sub.events <- event:
+			default:
+				b.logger.Warn("Dropping slow item event subscriber", "event_id", event.ID)
+				delete(b.subscribers, sub)
+				sub.close()
+			}
+		}
+	}
+}
+
+func (b *Broker) setLastID(id int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID = id
+}
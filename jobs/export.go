@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// KindExportItems exports items as JSON lines to the blob store
+const KindExportItems = "items.export"
+
+// exportPageSize is how many items are read per query during an export
+const exportPageSize = 100
+
+// ExportItemsPayload is the payload of an items.export job
+type ExportItemsPayload struct {
+	// Status restricts the export to the given statuses (default: all)
+	Status []string `json:"status"`
+}
+
+// ExportItemsResult is the result of an items.export job
+type ExportItemsResult struct {
+	StorageKey string `json:"storage_key"`
+	Items      int    `json:"items"`
+}
+
+// ExportKey returns the blob store key of a job's export
+func ExportKey(job storage.Job) string {
+	return fmt.Sprintf("exports/%s.jsonl", job.ID)
+}
+
+// ExportItems returns the handler for items.export. The export is streamed to
+// the blob store, so memory use does not grow with the number of items.
+func ExportItems(store *storage.Store, blobs storage.BlobStore) Handler {
+	return func(ctx context.Context, job storage.Job) (any, error) {
+		var payload ExportItemsPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("invalid payload: %w", err)
+		}
+		statuses, err := storage.ParseStatusList(payload.Status)
+		if err != nil {
+			return nil, err
+		}
+
+		key := ExportKey(job)
+		pr, pw := io.Pipe()
+		count := 0
+
+		go func() {
+			encoder := json.NewEncoder(pw)
+			req := storage.ListItemsRequest{Limit: exportPageSize, Status: statuses, IncludeTotal: new(bool)}
+			for {
+				page, err := store.ListItems(ctx, req)
+				if err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+				for _, item := range page.Items {
+					if err := encoder.Encode(item); err != nil {
+						pw.CloseWithError(err)
+						return
+					}
+					count++
+				}
+				if !page.HasMore {
+					pw.Close()
+					return
+				}
+				req.Offset += exportPageSize
+			}
+		}()
+
+		if err := blobs.Put(ctx, key, pr, "application/x-ndjson"); err != nil {
+			pr.CloseWithError(err)
+			return nil, fmt.Errorf("failed to write export: %w", err)
+		}
+
+		return ExportItemsResult{StorageKey: key, Items: count}, nil
+	}
+}
@@ -0,0 +1,319 @@
+// Package jobs runs background work queued in the Postgres jobs table.
+//
+// Jobs are enqueued with a kind, a JSON payload, a priority and an optional
+// run-at time. Workers claim the highest-priority ready job with
+// FOR UPDATE SKIP LOCKED, so any number of server instances can share the
+// queue. A running job heartbeats; a reaper requeues jobs whose worker
+// stopped heartbeating, and failed attempts are retried with exponential
+// backoff until the job runs out of attempts.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const (
+	// baseRetryDelay is the wait before the first retry; it doubles per attempt
+	baseRetryDelay = 5 * time.Second
+	// maxRetryDelay caps the wait between attempts
+	maxRetryDelay = 10 * time.Minute
+)
+
+// Handler does the work for one job. The context is cancelled when the job
+// times out, is cancelled, or the pool stops draining. A non-nil result is
+// stored as the job's JSON result.
+type Handler func(ctx context.Context, job storage.Job) (result any, err error)
+
+// Config holds pool settings
+type Config struct {
+	// Workers is how many jobs run concurrently
+	Workers int
+	// PollInterval is how often an idle worker checks the queue
+	PollInterval time.Duration
+	// HeartbeatInterval is how often a running job reports it is alive
+	HeartbeatInterval time.Duration
+	// StaleAfter is how long without a heartbeat before a running job is requeued
+	StaleAfter time.Duration
+	// DrainTimeout is how long running jobs may continue after shutdown starts;
+	// jobs still running afterwards are interrupted and requeued
+	DrainTimeout time.Duration
+}
+
+// Pool runs registered job handlers
+type Pool struct {
+	logger   *slog.Logger
+	store    *storage.Store
+	config   Config
+	id       string
+	handlers map[string]Handler
+}
+
+// NewPool creates a worker pool; register handlers, then call Run
+func NewPool(logger *slog.Logger, store *storage.Store, config Config) *Pool {
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.HeartbeatInterval <= 0 {
+		config.HeartbeatInterval = 10 * time.Second
+	}
+	if config.StaleAfter <= config.HeartbeatInterval {
+		config.StaleAfter = 6 * config.HeartbeatInterval
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = 25 * time.Second
+	}
+
+	hostname, _ := os.Hostname()
+	return &Pool{
+		logger:   logger,
+		store:    store,
+		config:   config,
+		id:       fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), uuid.NewString()[:8]),
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register sets the handler for a job kind. It must be called before Run.
+func (p *Pool) Register(kind string, handler Handler) {
+	p.handlers[kind] = handler
+}
+
+// Kinds returns the registered job kinds, sorted
+func (p *Pool) Kinds() []string {
+	kinds := make([]string, 0, len(p.handlers))
+	for kind := range p.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Handles reports whether kind has a registered handler
+func (p *Pool) Handles(kind string) bool {
+	_, ok := p.handlers[kind]
+	return ok
+}
+
+// Run starts the workers and the reaper, and blocks until ctx is cancelled
+// and running jobs have drained
+func (p *Pool) Run(ctx context.Context) {
+	p.logger.Info("Job workers started", "workers", p.config.Workers, "kinds", p.Kinds())
+	defer p.logger.Info("Job workers stopped")
+
+	// Jobs run on their own context so shutdown can give them DrainTimeout
+	// to finish before interrupting them
+	jobCtx, interrupt := context.WithCancel(context.WithoutCancel(ctx))
+	defer interrupt()
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.config.Workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			p.work(ctx, jobCtx, fmt.Sprintf("%s/%d", p.id, worker))
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.reap(ctx)
+	}()
+
+	<-ctx.Done()
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(p.config.DrainTimeout):
+		p.logger.Warn("Interrupting jobs still running after drain timeout")
+		interrupt()
+		<-drained
+	}
+}
+
+// work claims and runs jobs until ctx is cancelled
+func (p *Pool) work(ctx, jobCtx context.Context, workerID string) {
+	kinds := p.Kinds()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := p.store.ClaimJob(ctx, workerID, kinds)
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error("Failed to claim job", "worker", workerID, "error", err)
+		}
+		if job != nil {
+			p.run(jobCtx, workerID, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.config.PollInterval):
+		}
+	}
+}
+
+// errLostLease marks a job that was cancelled or reaped while running
+var errLostLease = errors.New("job is no longer held by this worker")
+
+// run executes one claimed job and records its outcome
+func (p *Pool) run(ctx context.Context, workerID string, job *storage.Job) {
+	logger := p.logger.With(
+		"job_id", job.ID,
+		"kind", job.Kind,
+		"attempt", job.Attempts,
+		"worker", workerID,
+	)
+	logger.Info("Job started")
+	start := time.Now()
+
+	runCtx, cancel := context.WithTimeoutCause(ctx, job.Timeout(),
+		fmt.Errorf("job timed out after %s", job.Timeout()))
+	defer cancel()
+
+	// Heartbeat until the handler returns; stop the handler if the job is
+	// cancelled or reaped in the meantime
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(p.config.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				held, err := p.store.HeartbeatJob(context.WithoutCancel(ctx), job.ID, workerID)
+				if err != nil {
+					logger.Warn("Failed to heartbeat job", "error", err)
+					continue
+				}
+				if !held {
+					close(lost)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	result, jobErr := p.invoke(runCtx, *job)
+	close(done)
+
+	// Outcomes are recorded even while shutting down
+	storeCtx := context.WithoutCancel(ctx)
+	elapsed := time.Since(start)
+
+	select {
+	case <-lost:
+		logger.Warn("Job was cancelled or reaped while running", "duration_ms", elapsed.Milliseconds())
+		return
+	default:
+	}
+
+	if jobErr != nil && ctx.Err() != nil {
+		// Interrupted by shutdown, not a failure of the job itself
+		logger.Warn("Job interrupted by shutdown; requeuing", "duration_ms", elapsed.Milliseconds())
+		if err := p.store.ReleaseJob(storeCtx, job.ID, workerID); err != nil {
+			logger.Error("Failed to release job", "error", err)
+		}
+		return
+	}
+
+	if jobErr != nil {
+		if cause := context.Cause(runCtx); cause != nil && errors.Is(jobErr, context.DeadlineExceeded) {
+			jobErr = cause
+		}
+		delay := Backoff(job.Attempts)
+		logger.Warn("Job failed", "error", jobErr, "duration_ms", elapsed.Milliseconds(),
+			"final", job.Attempts >= job.MaxAttempts)
+		if err := p.store.FailJob(storeCtx, job.ID, workerID, jobErr, delay); err != nil {
+			logger.Error("Failed to record job failure", "error", err)
+		}
+		return
+	}
+
+	var encoded json.RawMessage
+	if result != nil {
+		encoded, jobErr = json.Marshal(result)
+		if jobErr != nil {
+			logger.Error("Failed to encode job result", "error", jobErr)
+			encoded = nil
+		}
+	}
+	if err := p.store.CompleteJob(storeCtx, job.ID, workerID, encoded); err != nil {
+		logger.Error("Failed to record job success", "error", err)
+		return
+	}
+	logger.Info("Job succeeded", "duration_ms", elapsed.Milliseconds())
+}
+
+// invoke calls the handler, turning a panic into a job failure
+func (p *Pool) invoke(ctx context.Context, job storage.Job) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return p.handlers[job.Kind](ctx, job)
+}
+
+// reap periodically requeues jobs whose worker stopped heartbeating
+func (p *Pool) reap(ctx context.Context) {
+	ticker := time.NewTicker(p.config.StaleAfter / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reaped, err := p.store.ReapStaleJobs(ctx, p.config.StaleAfter)
+			if err != nil {
+				if ctx.Err() == nil {
+					p.logger.Error("Failed to reap stale jobs", "error", err)
+				}
+				continue
+			}
+			if reaped > 0 {
+				p.logger.Warn("Requeued jobs with stale heartbeats", "count", reaped)
+			}
+		}
+	}
+}
+
+// Backoff returns the wait before retrying after the given attempt:
+// 5s doubling per attempt, capped at ten minutes
+func Backoff(attempt int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,4 @@
+ALTER TABLE items ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- jsonb_path_ops supports the @> and @? operators used by metadata filters
+CREATE INDEX idx_items_metadata ON items USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS item_status_events;
+DROP INDEX IF EXISTS idx_items_status;
+ALTER TABLE items DROP COLUMN IF EXISTS status;
@@ -0,0 +1,16 @@
+ALTER TABLE items
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft'
+    CONSTRAINT items_status_check CHECK (status IN ('draft', 'published', 'archived'));
+
+CREATE INDEX idx_items_status ON items (status);
+
+CREATE TABLE item_status_events (
+    id BIGSERIAL PRIMARY KEY,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    transition VARCHAR(20) NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_item_status_events_item ON item_status_events (item_id, occurred_at);
@@ -0,0 +1,13 @@
+CREATE TABLE item_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    relation VARCHAR(20) NOT NULL
+        CONSTRAINT item_links_relation_check CHECK (relation IN ('depends_on', 'blocks', 'duplicates', 'relates_to')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT item_links_no_self_link CHECK (source_id <> target_id),
+    CONSTRAINT item_links_unique UNIQUE (source_id, target_id, relation)
+);
+
+-- The unique constraint covers lookups by source; this covers incoming links
+CREATE INDEX idx_item_links_target ON item_links (target_id, relation);
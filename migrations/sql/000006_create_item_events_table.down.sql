@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS items_record_event ON items;
+DROP FUNCTION IF EXISTS record_item_event();
+DROP TABLE IF EXISTS item_events;
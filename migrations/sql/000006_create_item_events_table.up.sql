@@ -0,0 +1,40 @@
+-- Append-only log of item changes, written by a trigger so every code path
+-- (and every server instance) produces events
+CREATE TABLE item_events (
+    id BIGSERIAL PRIMARY KEY,
+    item_id UUID NOT NULL,
+    event_type VARCHAR(20) NOT NULL
+        CONSTRAINT item_events_type_check CHECK (event_type IN ('created', 'updated', 'deleted')),
+    item JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_item_events_item ON item_events (item_id, id);
+
+CREATE FUNCTION record_item_event() RETURNS trigger AS $$
+DECLARE
+    event_id BIGINT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO item_events (item_id, event_type, item)
+        VALUES (NEW.id, 'created', to_jsonb(NEW))
+        RETURNING id INTO event_id;
+    ELSIF TG_OP = 'UPDATE' THEN
+        INSERT INTO item_events (item_id, event_type, item)
+        VALUES (NEW.id, 'updated', to_jsonb(NEW))
+        RETURNING id INTO event_id;
+    ELSE
+        INSERT INTO item_events (item_id, event_type, item)
+        VALUES (OLD.id, 'deleted', to_jsonb(OLD))
+        RETURNING id INTO event_id;
+    END IF;
+
+    -- NOTIFY is delivered on commit, so listeners never see rolled-back changes
+    PERFORM pg_notify('item_events', event_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER items_record_event
+    AFTER INSERT OR UPDATE OR DELETE ON items
+    FOR EACH ROW EXECUTE FUNCTION record_item_event();
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS item_events_enqueue_webhooks ON item_events;
+DROP FUNCTION IF EXISTS enqueue_webhook_deliveries();
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,18 @@
+-- Item changes written in the same transaction as the change itself and
+-- relayed to an external publisher. Rows outlive their item, so there is no
+-- foreign key.
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    item_id UUID NOT NULL,
+    event_type VARCHAR(20) NOT NULL,
+    item JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+-- The relay looks up the oldest unpublished message per item
+CREATE INDEX idx_outbox_unpublished ON outbox (item_id, id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_published_at ON outbox (published_at) WHERE published_at IS NOT NULL;
@@ -0,0 +1,28 @@
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued'
+        CONSTRAINT jobs_status_check CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled')),
+    -- Higher priorities run first; equal priorities run in run_at order
+    priority INT NOT NULL DEFAULT 0,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5 CONSTRAINT jobs_max_attempts_check CHECK (max_attempts > 0),
+    timeout_seconds INT NOT NULL DEFAULT 300 CONSTRAINT jobs_timeout_check CHECK (timeout_seconds > 0),
+    result JSONB,
+    last_error TEXT,
+    -- The worker holding a running job, and when it last proved it is alive
+    locked_by TEXT,
+    heartbeat_at TIMESTAMP WITH TIME ZONE,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Workers pick the next ready job from this index
+CREATE INDEX idx_jobs_ready ON jobs (priority DESC, run_at) WHERE status = 'queued';
+-- The reaper scans running jobs for stale heartbeats
+CREATE INDEX idx_jobs_running ON jobs (heartbeat_at) WHERE status = 'running';
+CREATE INDEX idx_jobs_created_at ON jobs (created_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_items_status_updated_at;
+DROP TABLE IF EXISTS retention_runs;
@@ -0,0 +1,21 @@
+CREATE TABLE retention_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_name VARCHAR(100) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    -- How the run was started: by the scheduler or through the API
+    trigger VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running'
+        CONSTRAINT retention_runs_status_check CHECK (status IN ('running', 'succeeded', 'failed')),
+    cutoff TIMESTAMP WITH TIME ZONE NOT NULL,
+    affected_count INT NOT NULL DEFAULT 0,
+    -- IDs of the items archived or purged by the run
+    affected_ids JSONB NOT NULL DEFAULT '[]'::jsonb,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_retention_runs_rule ON retention_runs (rule_name, started_at DESC);
+
+-- Retention rules select items by status and age
+CREATE INDEX idx_items_status_updated_at ON items (status, updated_at);
@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS take_rate_limit_token(TEXT, DOUBLE PRECISION, DOUBLE PRECISION);
+DROP TABLE IF EXISTS rate_limit_buckets;
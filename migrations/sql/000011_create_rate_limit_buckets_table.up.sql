@@ -0,0 +1,33 @@
+-- Token buckets shared by every server instance when RATE_LIMIT_BACKEND=postgres
+CREATE UNLOGGED TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
+
+-- Refills the bucket for elapsed time, then takes one token if available.
+-- The upsert holds the row lock until the function returns, so concurrent
+-- callers for the same key are serialised.
+CREATE FUNCTION take_rate_limit_token(bucket_key TEXT, rate DOUBLE PRECISION, burst DOUBLE PRECISION)
+RETURNS TABLE (allowed BOOLEAN, remaining DOUBLE PRECISION) AS $$
+DECLARE
+    now_ts TIMESTAMP WITH TIME ZONE := clock_timestamp();
+    available DOUBLE PRECISION;
+BEGIN
+    INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at)
+    VALUES (bucket_key, burst, now_ts)
+    ON CONFLICT (key) DO UPDATE
+        SET tokens = LEAST(burst, b.tokens + GREATEST(EXTRACT(EPOCH FROM now_ts - b.updated_at), 0) * rate),
+            updated_at = now_ts
+    RETURNING b.tokens INTO available;
+
+    IF available >= 1 THEN
+        UPDATE rate_limit_buckets SET tokens = available - 1 WHERE key = bucket_key;
+        RETURN QUERY SELECT TRUE, available - 1;
+    ELSE
+        RETURN QUERY SELECT FALSE, available;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+-- Attempts made before the delivery was last redelivered. Attempt numbers keep
+-- counting up across redeliveries; the retry budget counts from here.
+ALTER TABLE webhook_deliveries ADD COLUMN retry_base INT NOT NULL DEFAULT 0;
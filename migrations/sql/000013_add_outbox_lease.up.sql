@@ -0,0 +1,4 @@
+-- The relay leases claimed rows and publishes after committing the claim, so a
+-- slow publisher does not hold row locks. An expired lease makes the row
+-- claimable again.
+ALTER TABLE outbox ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
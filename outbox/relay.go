@@ -0,0 +1,186 @@
+// Package outbox relays item changes recorded in the outbox table to an
+// external publisher.
+//
+// Store mutations write an outbox row in the same transaction as the change,
+// so an event exists if and only if the change committed. The relay claims
+// rows with FOR UPDATE SKIP LOCKED, leases them and commits, publishes them in
+// id order, and marks them published in a second short transaction. Only the
+// oldest unpublished message of each item is claimable, so per-item order
+// holds across relays and retries.
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const (
+	// maxRetryDelay caps the wait before republishing a failed message
+	maxRetryDelay = 5 * time.Minute
+	// purgeInterval is how often published rows past Retention are deleted
+	purgeInterval = 15 * time.Second
+)
+
+// Relay counters for this process, reported by GET /outbox/stats
+var (
+	publishedTotal atomic.Int64
+	failedTotal    atomic.Int64
+	lastLag        atomic.Int64
+)
+
+// Metrics are the relay counters of this process since it started
+type Metrics struct {
+	PublishedTotal int64
+	FailedTotal    int64
+	// Lag is the commit-to-publish time of the newest message published
+	Lag time.Duration
+}
+
+// CurrentMetrics returns the relay counters of this process
+func CurrentMetrics() Metrics {
+	return Metrics{
+		PublishedTotal: publishedTotal.Load(),
+		FailedTotal:    failedTotal.Load(),
+		Lag:            time.Duration(lastLag.Load()),
+	}
+}
+
+// Config holds relay settings
+type Config struct {
+	// BatchSize is how many messages are claimed per pass
+	BatchSize int
+	// PollInterval is how often the outbox is checked when it was last empty
+	PollInterval time.Duration
+	// Retention is how long published messages are kept; zero keeps them forever
+	Retention time.Duration
+	// Lease is how long claimed messages are hidden from other relays while a
+	// batch is published; it should outlast a batch of slow publishes
+	Lease time.Duration
+}
+
+// Relay moves messages from the outbox to a publisher
+type Relay struct {
+	logger    *slog.Logger
+	store     *storage.Store
+	publisher Publisher
+	config    Config
+}
+
+// NewRelay creates a relay; call Run to start publishing
+func NewRelay(logger *slog.Logger, store *storage.Store, publisher Publisher, config Config) *Relay {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+
+	return &Relay{
+		logger:    logger,
+		store:     store,
+		publisher: publisher,
+		config:    config,
+	}
+}
+
+// Run publishes messages until ctx is cancelled. A batch in progress is
+// finished first so published messages are not sent again after a restart.
+func (r *Relay) Run(ctx context.Context) {
+	r.logger.Info("Outbox relay started", "batch_size", r.config.BatchSize)
+	defer r.logger.Info("Outbox relay stopped")
+
+	r.purge(ctx)
+	lastPurge := time.Now()
+
+	for {
+		batch, err := r.store.RelayOutbox(context.WithoutCancel(ctx), r.config.BatchSize, r.config.Lease, retryDelay, r.publish)
+		if err != nil {
+			r.logger.Error("Failed to relay outbox", "error", err)
+		} else {
+			r.record(batch)
+		}
+
+		if time.Since(lastPurge) >= purgeInterval {
+			r.purge(ctx)
+			lastPurge = time.Now()
+		}
+
+		// A full batch suggests more work is waiting, so only sleep when the outbox ran dry
+		full := batch != nil && len(batch.Published)+batch.Failed == r.config.BatchSize
+		if full && batch.Failed == 0 {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.config.PollInterval):
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, msg storage.OutboxMessage) error {
+	if err := r.publisher.Publish(ctx, msg); err != nil {
+		r.logger.Warn("Failed to publish outbox message",
+			"id", msg.ID,
+			"item_id", msg.ItemID,
+			"type", msg.Type,
+			"attempt", msg.Attempts+1,
+			"error", err,
+		)
+		return err
+	}
+	return nil
+}
+
+// record updates metrics after a batch
+func (r *Relay) record(batch *storage.OutboxBatch) {
+	failedTotal.Add(int64(batch.Failed))
+	if len(batch.Published) == 0 {
+		return
+	}
+
+	publishedTotal.Add(int64(len(batch.Published)))
+	// Lag is measured from commit to publish for the newest message in the batch
+	last := batch.Published[len(batch.Published)-1]
+	lastLag.Store(int64(time.Since(last.CreatedAt)))
+}
+
+// purge deletes published messages older than Retention
+func (r *Relay) purge(ctx context.Context) {
+	if r.config.Retention <= 0 {
+		return
+	}
+	purged, err := r.store.PurgeOutbox(ctx, r.config.Retention)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to purge outbox", "error", err)
+		}
+		return
+	}
+	if purged > 0 {
+		r.logger.Info("Purged published outbox messages", "count", purged)
+	}
+}
+
+// retryDelay backs off from one second, doubling per attempt up to five minutes
+func retryDelay(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
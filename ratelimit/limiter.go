@@ -0,0 +1,67 @@
+// Package ratelimit implements token-bucket rate limiting.
+//
+// Each bucket holds up to Burst tokens and refills at Rate tokens per second;
+// a request takes one token or is rejected. Memory keeps buckets in process,
+// which is exact for a single instance. Postgres keeps them in a shared table
+// so every instance draws from the same bucket, at the cost of one query per
+// request.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket's refill rate (tokens per second) and capacity
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Window is how long an empty bucket takes to refill completely
+func (l Limit) Window() time.Duration {
+	return seconds(float64(l.Burst) / l.Rate)
+}
+
+// Result describes the outcome of taking a token
+type Result struct {
+	// Allowed is false when the bucket was empty
+	Allowed bool
+	// Limit is the bucket capacity
+	Limit int
+	// Remaining is the number of whole tokens left
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until a token is available; zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Limiter takes tokens from buckets identified by key
+type Limiter interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// newResult builds a Result from the tokens left in a bucket after a take
+func newResult(allowed bool, tokens float64, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(max(s, 0) * float64(time.Second))
+}
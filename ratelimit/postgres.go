@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const (
+	// purgeInterval is how often idle shared buckets are deleted
+	purgeInterval = 5 * time.Minute
+	// purgeIdle is how long a shared bucket must be untouched before it is deleted
+	purgeIdle = time.Hour
+)
+
+// Postgres is a Limiter whose buckets live in the rate_limit_buckets table,
+// so all instances share them. If the database is unavailable it falls back
+// to a per-instance Memory limiter rather than failing requests.
+type Postgres struct {
+	logger   *slog.Logger
+	store    *storage.Store
+	fallback *Memory
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// NewPostgres creates a shared limiter
+func NewPostgres(logger *slog.Logger, store *storage.Store) *Postgres {
+	return &Postgres{
+		logger:    logger,
+		store:     store,
+		fallback:  NewMemory(),
+		lastPurge: time.Now(),
+	}
+}
+
+// Take implements Limiter
+func (p *Postgres) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	p.maybePurge(ctx)
+
+	allowed, tokens, err := p.store.TakeRateLimitToken(ctx, key, limit.Rate, limit.Burst)
+	if err != nil {
+		p.logger.Warn("Shared rate limiter unavailable, using local buckets", "error", err)
+		return p.fallback.Take(ctx, key, limit)
+	}
+	return newResult(allowed, tokens, limit), nil
+}
+
+// maybePurge deletes idle buckets in the background at most once per purgeInterval
+func (p *Postgres) maybePurge(ctx context.Context) {
+	p.mu.Lock()
+	if time.Since(p.lastPurge) < purgeInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastPurge = time.Now()
+	p.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if _, err := p.store.PurgeRateLimitBuckets(ctx, purgeIdle); err != nil {
+			p.logger.Warn("Failed to purge idle rate limit buckets", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,155 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// defaultBatchSize is how many items a run changes per transaction
+const defaultBatchSize = 500
+
+// Rule selects items by status and age and archives or purges them on a schedule
+type Rule struct {
+	Name   string                  `json:"name"`
+	Action storage.RetentionAction `json:"action"`
+	// Statuses defaults to draft and published for archive, and archived for purge
+	Statuses []string `json:"statuses"`
+	// OlderThan is how long an item must go without updates to be selected
+	OlderThan Age `json:"older_than"`
+	// Schedule is a cron expression ("0 3 * * *") or descriptor ("@daily");
+	// an empty schedule means the rule only runs on demand
+	Schedule  string `json:"schedule"`
+	BatchSize int    `json:"batch_size,omitempty"`
+}
+
+// Selector returns the item selector for the rule as of now
+func (r Rule) Selector(now time.Time) storage.RetentionSelector {
+	return storage.RetentionSelector{
+		Statuses: r.Statuses,
+		Cutoff:   now.Add(-time.Duration(r.OlderThan)),
+	}
+}
+
+// validate applies defaults and checks the rule
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule is missing a name")
+	}
+	if !r.Action.Valid() {
+		return fmt.Errorf("rule %q: invalid action %q (expected archive or purge)", r.Name, r.Action)
+	}
+	if r.OlderThan <= 0 {
+		return fmt.Errorf("rule %q: older_than must be positive", r.Name)
+	}
+	if r.BatchSize <= 0 {
+		r.BatchSize = defaultBatchSize
+	}
+
+	if len(r.Statuses) == 0 {
+		switch r.Action {
+		case storage.RetentionArchive:
+			r.Statuses = []string{string(storage.StatusDraft), string(storage.StatusPublished)}
+		case storage.RetentionPurge:
+			r.Statuses = []string{string(storage.StatusArchived)}
+		}
+	}
+	statuses, err := storage.ParseStatusList(r.Statuses)
+	if err != nil {
+		return fmt.Errorf("rule %q: %w", r.Name, err)
+	}
+	r.Statuses = statuses
+	if r.Action == storage.RetentionArchive {
+		for _, status := range r.Statuses {
+			if status == string(storage.StatusArchived) {
+				return fmt.Errorf("rule %q: archive rules cannot select archived items", r.Name)
+			}
+		}
+	}
+
+	if r.Schedule != "" {
+		if _, err := cron.ParseStandard(r.Schedule); err != nil {
+			return fmt.Errorf("rule %q: invalid schedule: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// LoadRules reads a JSON array of rules from path
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retention rules: %w", err)
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse retention rules: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return nil, err
+		}
+		if seen[rules[i].Name] {
+			return nil, fmt.Errorf("duplicate retention rule %q", rules[i].Name)
+		}
+		seen[rules[i].Name] = true
+	}
+	return rules, nil
+}
+
+// Age is a duration that also accepts a day suffix, e.g. "180d" or "36h"
+type Age time.Duration
+
+// ParseAge parses a Go duration or a whole number of days such as "30d"
+func ParseAge(s string) (Age, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return Age(time.Duration(n) * 24 * time.Hour), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return Age(d), nil
+}
+
+// String formats whole days with a "d" suffix
+func (a Age) String() string {
+	d := time.Duration(a)
+	if d > 0 && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (a *Age) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("age must be a string such as \"30d\"")
+	}
+	parsed, err := ParseAge(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (a Age) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
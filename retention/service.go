@@ -0,0 +1,240 @@
+// Package retention archives or purges old items according to configured rules.
+//
+// Rules run on cron schedules inside every server instance. Each run takes a
+// Postgres advisory lock for its rule first, so when several replicas fire at
+// the same time only one does the work; the others skip the run. Every run is
+// recorded in retention_runs along with the IDs of the items it affected.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+// Run triggers
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// ErrUnknownRule is returned for a rule name that is not configured
+var ErrUnknownRule = errors.New("retention rule not found")
+
+// ErrRunInProgress is returned when another replica is already running the rule
+var ErrRunInProgress = errors.New("retention rule is already running")
+
+// Preview describes what a rule would affect if it ran now
+type Preview struct {
+	Rule   Rule           `json:"rule"`
+	Cutoff time.Time      `json:"cutoff"`
+	Total  int            `json:"total"`
+	Items  []storage.Item `json:"items"`
+}
+
+// RuleInfo is a rule together with its next scheduled run
+type RuleInfo struct {
+	Rule
+	NextRun *time.Time `json:"next_run"`
+}
+
+// Service runs retention rules on demand and on schedule
+type Service struct {
+	logger *slog.Logger
+	store  *storage.Store
+	blobs  storage.BlobStore
+	rules  []Rule
+
+	cron    *cron.Cron
+	entries map[string]cron.EntryID
+	// ctx is the scheduler's lifetime, set by Run before the first scheduled run
+	ctx context.Context
+}
+
+// NewService creates a retention service for rules, which must have been
+// validated by LoadRules; call Run to start the scheduler
+func NewService(logger *slog.Logger, store *storage.Store, blobs storage.BlobStore, rules []Rule) (*Service, error) {
+	s := &Service{
+		logger:  logger,
+		store:   store,
+		blobs:   blobs,
+		rules:   rules,
+		cron:    cron.New(),
+		entries: make(map[string]cron.EntryID),
+	}
+
+	for _, rule := range rules {
+		if rule.Schedule == "" {
+			continue
+		}
+		name := rule.Name
+		id, err := s.cron.AddFunc(rule.Schedule, func() { s.scheduled(name) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to schedule retention rule %q: %w", name, err)
+		}
+		s.entries[name] = id
+	}
+	return s, nil
+}
+
+// Rules returns the configured rules with their next scheduled run
+func (s *Service) Rules() []RuleInfo {
+	infos := make([]RuleInfo, 0, len(s.rules))
+	for _, rule := range s.rules {
+		info := RuleInfo{Rule: rule}
+		if id, ok := s.entries[rule.Name]; ok {
+			if next := s.cron.Entry(id).Next; !next.IsZero() {
+				info.NextRun = &next
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func (s *Service) rule(name string) (Rule, error) {
+	for _, rule := range s.rules {
+		if rule.Name == name {
+			return rule, nil
+		}
+	}
+	return Rule{}, ErrUnknownRule
+}
+
+// Preview reports how many items the rule would affect now and lists up to limit of them
+func (s *Service) Preview(ctx context.Context, name string, limit int) (*Preview, error) {
+	rule, err := s.rule(name)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	sel := rule.Selector(time.Now())
+	items, total, err := s.store.ListRetentionCandidates(ctx, sel, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Preview{
+		Rule:   rule,
+		Cutoff: sel.Cutoff,
+		Total:  total,
+		Items:  items,
+	}, nil
+}
+
+// RunRule executes a rule now. It returns ErrRunInProgress if another
+// replica holds the rule's lock.
+func (s *Service) RunRule(ctx context.Context, name, trigger string) (*storage.RetentionRun, error) {
+	rule, err := s.rule(name)
+	if err != nil {
+		return nil, err
+	}
+
+	release, ok, err := s.store.TryRetentionLock(ctx, rule.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRunInProgress
+	}
+	defer release()
+
+	// The cutoff is fixed at the start so items touched during the run are left alone
+	sel := rule.Selector(time.Now())
+	run, err := s.store.CreateRetentionRun(ctx, rule.Name, rule.Action, trigger, sel.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := s.logger.With("rule", rule.Name, "action", rule.Action, "run_id", run.ID, "trigger", trigger)
+	logger.Info("Retention run started", "cutoff", sel.Cutoff)
+
+	affected, runErr := s.apply(ctx, rule, sel)
+
+	run, err = s.store.FinishRetentionRun(context.WithoutCancel(ctx), run.ID, affected, runErr)
+	if err != nil {
+		return nil, err
+	}
+
+	if runErr != nil {
+		logger.Error("Retention run failed", "affected", len(affected), "error", runErr)
+	} else {
+		logger.Info("Retention run finished", "affected", len(affected))
+	}
+	return run, nil
+}
+
+// apply processes the rule in batches until nothing is left to select
+func (s *Service) apply(ctx context.Context, rule Rule, sel storage.RetentionSelector) ([]string, error) {
+	affected := []string{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return affected, err
+		}
+
+		var items []storage.Item
+		var err error
+		switch rule.Action {
+		case storage.RetentionArchive:
+			items, err = s.store.ArchiveRetentionBatch(ctx, sel, rule.BatchSize)
+		case storage.RetentionPurge:
+			var blobKeys []string
+			items, blobKeys, err = s.store.PurgeRetentionBatch(ctx, sel, rule.BatchSize)
+			s.deleteBlobs(ctx, blobKeys)
+		}
+		if err != nil {
+			return affected, err
+		}
+
+		for _, item := range items {
+			affected = append(affected, item.ID.String())
+		}
+		// Rows locked by in-flight requests are skipped, so a short batch means done
+		if len(items) < rule.BatchSize {
+			return affected, nil
+		}
+	}
+}
+
+// deleteBlobs removes attachment blobs of purged items; failures only leave orphaned files
+func (s *Service) deleteBlobs(ctx context.Context, keys []string) {
+	if s.blobs == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := s.blobs.Delete(context.WithoutCancel(ctx), key); err != nil {
+			s.logger.Warn("Failed to delete attachment blob", "key", key, "error", err)
+		}
+	}
+}
+
+// Run starts the scheduler and blocks until ctx is cancelled and any
+// scheduled run in progress has finished
+func (s *Service) Run(ctx context.Context) {
+	s.ctx = ctx
+	s.cron.Start()
+	s.logger.Info("Retention scheduler started", "rules", len(s.rules), "scheduled", len(s.entries))
+
+	<-ctx.Done()
+	<-s.cron.Stop().Done()
+	s.logger.Info("Retention scheduler stopped")
+}
+
+// scheduled runs a rule from the scheduler
+func (s *Service) scheduled(name string) {
+	_, err := s.RunRule(s.ctx, name, TriggerSchedule)
+	if errors.Is(err, ErrRunInProgress) {
+		s.logger.Info("Skipping retention run; another replica holds the lock", "rule", name)
+	} else if err != nil {
+		s.logger.Error("Retention run could not start", "rule", name, "error", err)
+	}
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment describes a file attached to an item; the contents live in a BlobStore
+type Attachment struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	ItemID      uuid.UUID `db:"item_id" json:"item_id"`
+	Filename    string    `db:"filename" json:"filename"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	SizeBytes   int64     `db:"size_bytes" json:"size_bytes"`
+	SHA256      string    `db:"sha256" json:"sha256"`
+	StorageKey  string    `db:"storage_key" json:"-"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// AttachmentKey returns the blob store key for an attachment
+func AttachmentKey(itemID, attachmentID uuid.UUID) string {
+	return fmt.Sprintf("items/%s/%s", itemID, attachmentID)
+}
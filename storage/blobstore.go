@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when a blob does not exist in the blob store
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores attachment contents outside of Postgres.
+// Implementations must stream: Put reads r until EOF without holding the
+// whole blob in memory, and Get returns a reader over the stored bytes.
+type BlobStore interface {
+	// Put stores the contents of r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get opens the blob stored under key; callers must close the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
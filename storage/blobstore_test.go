@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// errReaderLimit stands in for the attachment size limit tripping mid-upload
+var errReaderLimit = errors.New("reader limit exceeded")
+
+// limitedReader returns n bytes of data and then fails, like the upload
+// handler's reader does once MAX_ATTACHMENT_BYTES is exceeded
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, errReaderLimit
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBlobStore(t, store)
+
+	t.Run("RejectsEscapingKeys", func(t *testing.T) {
+		for _, key := range []string{"../outside", "/etc/passwd", ".."} {
+			if err := store.Put(context.Background(), key, bytes.NewReader(nil), ""); err == nil {
+				t.Errorf("Put(%q) succeeded, want an error", key)
+			}
+		}
+	})
+}
+
+func TestS3BlobStore(t *testing.T) {
+	cfg := S3Config{
+		Endpoint:  newFakeS3(t),
+		Region:    "us-east-1",
+		Bucket:    "attachments",
+		AccessKey: "test",
+		SecretKey: "test-secret",
+	}
+	store, err := NewS3BlobStore(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBlobStore(t, store)
+
+	// The second connection finds the bucket the first one created
+	if _, err := NewS3BlobStore(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestS3BlobStoreMinIO runs the suite against a real S3 service, such as
+// the MinIO container from docker-compose.yml, when TEST_S3_ENDPOINT is set
+func TestS3BlobStoreMinIO(t *testing.T) {
+	endpoint := os.Getenv("TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_S3_ENDPOINT is not set")
+	}
+	store, err := NewS3BlobStore(context.Background(), S3Config{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    "blobstore-test",
+		AccessKey: os.Getenv("TEST_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("TEST_S3_SECRET_KEY"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBlobStore(t, store)
+}
+
+// testBlobStore checks the BlobStore contract that attachment handlers rely on
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+
+	t.Run("PutGet", func(t *testing.T) {
+		for _, size := range []int{0, 1, sniffLenForTest, s3PartSize + 1024} {
+			data := randomBytes(t, size)
+			key := "items/roundtrip/" + string(rune('a'+size%26))
+			if err := store.Put(ctx, key, bytes.NewReader(data), "application/octet-stream"); err != nil {
+				t.Fatalf("Put %d bytes: %v", size, err)
+			}
+			got := readBlob(t, store, key)
+			if sha256.Sum256(got) != sha256.Sum256(data) {
+				t.Fatalf("Get returned %d bytes with a different SHA-256 than the %d stored", len(got), size)
+			}
+		}
+	})
+
+	t.Run("PutReplaces", func(t *testing.T) {
+		key := "items/replace/blob"
+		for _, content := range []string{"first version", "second"} {
+			if err := store.Put(ctx, key, bytes.NewReader([]byte(content)), "text/plain"); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(readBlob(t, store, key)); got != content {
+				t.Fatalf("Get = %q, want %q", got, content)
+			}
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		if _, err := store.Get(ctx, "items/missing/blob"); !errors.Is(err, ErrBlobNotFound) {
+			t.Fatalf("Get missing blob: err = %v, want ErrBlobNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		key := "items/delete/blob"
+		if err := store.Put(ctx, key, bytes.NewReader([]byte("doomed")), "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Get(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+			t.Fatalf("Get after Delete: err = %v, want ErrBlobNotFound", err)
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete missing blob: %v", err)
+		}
+	})
+
+	t.Run("FailedPutLeavesNothing", func(t *testing.T) {
+		key := "items/limit/blob"
+		r := &limitedReader{r: bytes.NewReader(randomBytes(t, 4096)), n: 1000}
+		if err := store.Put(ctx, key, r, "application/octet-stream"); err == nil {
+			t.Fatal("Put succeeded although the reader failed")
+		}
+		if _, err := store.Get(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+			t.Fatalf("Get after failed Put: err = %v, want ErrBlobNotFound", err)
+		}
+	})
+
+	t.Run("CancelledPut", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := store.Put(cancelled, "items/cancelled/blob", bytes.NewReader([]byte("data")), "text/plain"); err == nil {
+			t.Fatal("Put succeeded with a cancelled context")
+		}
+	})
+}
+
+// sniffLenForTest matches the bytes the upload handler sniffs, the boundary
+// where it stitches the head of a file back onto the rest
+const sniffLenForTest = 512
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func readBlob(t *testing.T, store BlobStore, key string) []byte {
+	t.Helper()
+	rc, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get %s: %v", key, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+	return b
+}
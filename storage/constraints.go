@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error classes for values the database refused to store
+const (
+	pgDataExceptionClass      = "22"
+	pgIntegrityViolationClass = "23"
+	pgStringDataRightTrunc    = "22001"
+)
+
+// ConstraintError is returned when Postgres rejects a written value because
+// of a column type or constraint. It describes a bad request rather than a
+// server failure.
+type ConstraintError struct {
+	// Column is the column at fault, when Postgres or the constraint name reveals it
+	Column     string
+	Constraint string
+	Code       string
+	Message    string
+}
+
+func (e *ConstraintError) Error() string {
+	return "constraint violation: " + e.Message
+}
+
+// asConstraintError wraps data exceptions and integrity violations on table
+// in a *ConstraintError and returns other errors unchanged
+func asConstraintError(err error, table string) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || len(pgErr.Code) != 5 {
+		return err
+	}
+	class := pgErr.Code[:2]
+	if class != pgDataExceptionClass && class != pgIntegrityViolationClass {
+		return err
+	}
+
+	column := pgErr.ColumnName
+	if column == "" && strings.HasPrefix(pgErr.ConstraintName, table+"_") {
+		// Postgres names constraints <table>_<column>_<kind> by default
+		column = strings.TrimPrefix(pgErr.ConstraintName, table+"_")
+		if i := strings.LastIndex(column, "_"); i > 0 {
+			column = column[:i]
+		}
+	}
+
+	return &ConstraintError{
+		Column:     column,
+		Constraint: pgErr.ConstraintName,
+		Code:       pgErr.Code,
+		Message:    pgErr.Message,
+	}
+}
+
+// itemConstraintError is asConstraintError for items. Length errors carry no
+// column name, but name is the only length-limited column.
+func itemConstraintError(err error) error {
+	err = asConstraintError(err, "items")
+	var constraintErr *ConstraintError
+	if errors.As(err, &constraintErr) && constraintErr.Column == "" && constraintErr.Code == pgStringDataRightTrunc {
+		constraintErr.Column = "name"
+	}
+	return err
+}
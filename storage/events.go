@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemEventsChannel is the Postgres NOTIFY channel that carries new item_events ids
+const ItemEventsChannel = "item_events"
+
+// ItemEventType is the kind of change an ItemEvent records
+type ItemEventType string
+
+const (
+	EventItemCreated ItemEventType = "created"
+	EventItemUpdated ItemEventType = "updated"
+	EventItemDeleted ItemEventType = "deleted"
+)
+
+// ItemEvent is one entry of the item change log. Item is a snapshot of the
+// row after the change (or before it, for deletes).
+type ItemEvent struct {
+	ID         int64         `db:"id" json:"id"`
+	Type       ItemEventType `db:"event_type" json:"type"`
+	ItemID     uuid.UUID     `db:"item_id" json:"item_id"`
+	Item       Item          `db:"-" json:"item"`
+	OccurredAt time.Time     `db:"occurred_at" json:"occurred_at"`
+
+	ItemJSON []byte `db:"item" json:"-"`
+}
+
+// decodeItem fills Item from the raw JSONB snapshot
+func (e *ItemEvent) decodeItem() error {
+	return json.Unmarshal(e.ItemJSON, &e.Item)
+}
+
+// ItemEventFilter narrows a stream of item events
+type ItemEventFilter struct {
+	// ItemID matches events for a single item when set
+	ItemID *uuid.UUID
+	// Tag matches items whose metadata "tags" array contains the value
+	Tag string
+}
+
+// Matches reports whether the event passes the filter
+func (f ItemEventFilter) Matches(e ItemEvent) bool {
+	if f.ItemID != nil && e.ItemID != *f.ItemID {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(ItemTags(e.Item), f.Tag) {
+		return false
+	}
+	return true
+}
+
+// ItemTags returns the string entries of an item's metadata "tags" array
+func ItemTags(item Item) []string {
+	raw, ok := item.Metadata["tags"].([]any)
+	if !ok {
+		return nil
+	}
+	tags := make([]string, 0, len(raw))
+	for _, tag := range raw {
+		if s, ok := tag.(string); ok {
+			tags = append(tags, s)
+		}
+	}
+	return tags
+}
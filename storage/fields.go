@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ItemColumns lists the item fields a sparse fieldset may select, in the
+// order responses and SELECT lists use
+var ItemColumns = []string{"id", "name", "description", "metadata", "status", "created_at", "updated_at"}
+
+// ParseItemFields normalises fields query values, which may be repeated or
+// comma-separated, into ItemColumns order without duplicates. It rejects
+// unknown fields and returns nil, meaning every field, when none are given.
+func ParseItemFields(values []string) ([]string, error) {
+	selected := map[string]bool{}
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if !slices.Contains(ItemColumns, field) {
+				return nil, fmt.Errorf("invalid field %q (expected %s)", field, strings.Join(ItemColumns, ", "))
+			}
+			selected[field] = true
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	fields := make([]string, 0, len(selected))
+	for _, column := range ItemColumns {
+		if selected[column] {
+			fields = append(fields, column)
+		}
+	}
+	return fields, nil
+}
+
+// itemSelectList returns the SELECT list for fields, as parsed by
+// ParseItemFields; nil selects every column
+func itemSelectList(fields []string) string {
+	if len(fields) == 0 {
+		fields = ItemColumns
+	}
+	return strings.Join(fields, ", ")
+}
+
+// ItemField returns the value of one of ItemColumns on item
+func ItemField(item Item, field string) any {
+	switch field {
+	case "id":
+		return item.ID
+	case "name":
+		return item.Name
+	case "description":
+		return item.Description
+	case "metadata":
+		return item.Metadata
+	case "status":
+		return item.Status
+	case "created_at":
+		return item.CreatedAt
+	case "updated_at":
+		return item.UpdatedAt
+	}
+	return nil
+}
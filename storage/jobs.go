@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Valid reports whether s is a known job status
+func (s JobStatus) Valid() bool {
+	switch s {
+	case JobQueued, JobRunning, JobSucceeded, JobFailed, JobCancelled:
+		return true
+	}
+	return false
+}
+
+// ErrJobFinished is returned when cancelling a job that has already finished
+var ErrJobFinished = errors.New("job has already finished")
+
+// Job is a unit of background work stored in the jobs table
+type Job struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	Kind           string          `db:"kind" json:"kind"`
+	Payload        json.RawMessage `db:"-" json:"payload"`
+	Status         JobStatus       `db:"status" json:"status"`
+	Priority       int             `db:"priority" json:"priority"`
+	RunAt          time.Time       `db:"run_at" json:"run_at"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	MaxAttempts    int             `db:"max_attempts" json:"max_attempts"`
+	TimeoutSeconds int             `db:"timeout_seconds" json:"timeout_seconds"`
+	Result         json.RawMessage `db:"-" json:"result,omitempty"`
+	LastError      *string         `db:"last_error" json:"last_error"`
+	LockedBy       *string         `db:"locked_by" json:"locked_by,omitempty"`
+	HeartbeatAt    *time.Time      `db:"heartbeat_at" json:"heartbeat_at,omitempty"`
+	StartedAt      *time.Time      `db:"started_at" json:"started_at"`
+	FinishedAt     *time.Time      `db:"finished_at" json:"finished_at"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+
+	PayloadJSON []byte `db:"payload" json:"-"`
+	ResultJSON  []byte `db:"result" json:"-"`
+}
+
+// decodeJSON fills Payload and Result from their raw columns
+func (j *Job) decodeJSON() {
+	j.Payload = j.PayloadJSON
+	if j.ResultJSON != nil {
+		j.Result = j.ResultJSON
+	}
+}
+
+// Timeout returns how long a single attempt may run
+func (j *Job) Timeout() time.Duration {
+	return time.Duration(j.TimeoutSeconds) * time.Second
+}
+
+// EnqueueJobRequest represents the request payload for enqueuing a job
+type EnqueueJobRequest struct {
+	Kind    string          `json:"kind" binding:"required"`
+	Payload json.RawMessage `json:"payload"`
+	// Priority orders ready jobs, highest first (default 0)
+	Priority int `json:"priority"`
+	// RunAt delays the job until the given time (default now)
+	RunAt *time.Time `json:"run_at"`
+	// MaxAttempts limits retries (default 5)
+	MaxAttempts int `json:"max_attempts"`
+	// TimeoutSeconds bounds each attempt (default 300)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// Validate applies defaults and checks limits
+func (r *EnqueueJobRequest) Validate() error {
+	if len(r.Payload) == 0 {
+		r.Payload = json.RawMessage("{}")
+	}
+	if !json.Valid(r.Payload) {
+		return fmt.Errorf("payload must be valid JSON")
+	}
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = 5
+	}
+	if r.MaxAttempts < 1 || r.MaxAttempts > 100 {
+		return fmt.Errorf("max_attempts must be between 1 and 100")
+	}
+	if r.TimeoutSeconds == 0 {
+		r.TimeoutSeconds = 300
+	}
+	if r.TimeoutSeconds < 1 || r.TimeoutSeconds > 86400 {
+		return fmt.Errorf("timeout_seconds must be between 1 and 86400")
+	}
+	return nil
+}
+
+// ListJobsRequest represents filters for listing jobs
+type ListJobsRequest struct {
+	Status string `form:"status" json:"status"`
+	Kind   string `form:"kind" json:"kind"`
+	Limit  int    `form:"limit" json:"limit"`
+	Offset int    `form:"offset" json:"offset"`
+}
+
+// ListJobsResponse represents the response for listing jobs
+type ListJobsResponse struct {
+	Jobs   []Job `json:"jobs"`
+	Total  int   `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LinkRelation describes how a link's source item relates to its target item
+type LinkRelation string
+
+const (
+	// RelationDependsOn means the source cannot be finished before the target
+	RelationDependsOn LinkRelation = "depends_on"
+	// RelationBlocks means the target cannot be finished before the source
+	RelationBlocks LinkRelation = "blocks"
+	// RelationDuplicates marks the source as a duplicate of the target
+	RelationDuplicates LinkRelation = "duplicates"
+	// RelationRelatesTo is an informational link with no ordering
+	RelationRelatesTo LinkRelation = "relates_to"
+)
+
+// Valid reports whether r is a known relation
+func (r LinkRelation) Valid() bool {
+	switch r {
+	case RelationDependsOn, RelationBlocks, RelationDuplicates, RelationRelatesTo:
+		return true
+	}
+	return false
+}
+
+// IsDependency reports whether the relation takes part in the dependency graph
+func (r LinkRelation) IsDependency() bool {
+	return r == RelationDependsOn || r == RelationBlocks
+}
+
+// ErrLinkExists is returned when the same link is added twice
+var ErrLinkExists = errors.New("link already exists")
+
+// LinkCycleError is returned when a dependency link would make the graph cyclic
+type LinkCycleError struct {
+	SourceID uuid.UUID
+	TargetID uuid.UUID
+	Relation LinkRelation
+}
+
+func (e *LinkCycleError) Error() string {
+	return fmt.Sprintf("linking %s %s %s would create a dependency cycle", e.SourceID, e.Relation, e.TargetID)
+}
+
+// ItemLink is a typed, directed link between two items
+type ItemLink struct {
+	ID        uuid.UUID    `db:"id" json:"id"`
+	SourceID  uuid.UUID    `db:"source_id" json:"source_id"`
+	TargetID  uuid.UUID    `db:"target_id" json:"target_id"`
+	Relation  LinkRelation `db:"relation" json:"relation"`
+	CreatedAt time.Time    `db:"created_at" json:"created_at"`
+}
+
+// CreateLinkRequest represents the request payload for linking an item to another
+type CreateLinkRequest struct {
+	TargetID uuid.UUID    `json:"target_id" binding:"required"`
+	Relation LinkRelation `json:"relation" binding:"required"`
+}
+
+// ItemNeighbor is an item directly linked to another item, in either direction
+type ItemNeighbor struct {
+	LinkID     uuid.UUID    `db:"link_id" json:"link_id"`
+	Relation   LinkRelation `db:"relation" json:"relation"`
+	Direction  string       `db:"direction" json:"direction"` // "outgoing" or "incoming"
+	ItemID     uuid.UUID    `db:"item_id" json:"item_id"`
+	ItemName   string       `db:"item_name" json:"item_name"`
+	ItemStatus ItemStatus   `db:"item_status" json:"item_status"`
+	LinkedAt   time.Time    `db:"linked_at" json:"linked_at"`
+}
+
+// GraphDirection selects which way a dependency graph is walked
+type GraphDirection string
+
+const (
+	// GraphDependencies walks from an item to everything it depends on
+	GraphDependencies GraphDirection = "dependencies"
+	// GraphDependents walks from an item to everything that depends on it
+	GraphDependents GraphDirection = "dependents"
+)
+
+// DependencyEdge is one edge of a dependency graph; From depends on To when
+// walking dependencies and To depends on From when walking dependents
+type DependencyEdge struct {
+	From  uuid.UUID `db:"from_id" json:"from"`
+	To    uuid.UUID `db:"to_id" json:"to"`
+	Depth int       `db:"depth" json:"depth"`
+}
+
+// DependencyGraph is the transitive dependency graph rooted at an item
+type DependencyGraph struct {
+	RootID    uuid.UUID        `json:"root_id"`
+	Direction GraphDirection   `json:"direction"`
+	MaxDepth  int              `json:"max_depth"`
+	Nodes     []Item           `json:"nodes"`
+	Edges     []DependencyEdge `json:"edges"`
+}
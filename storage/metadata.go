@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// MetadataQueryPrefix is the query parameter prefix used to filter items by metadata
+const MetadataQueryPrefix = "meta."
+
+// Metadata holds arbitrary JSON fields attached to an item
+type Metadata map[string]any
+
+// Value implements driver.Valuer so Metadata can be written to a JSONB column
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner so Metadata can be read from a JSONB column
+func (m *Metadata) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = Metadata{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Metadata", src)
+	}
+
+	result := Metadata{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	*m = result
+	return nil
+}
+
+// Set stores value at a dotted path such as "dims.width", creating nested objects as needed
+func (m Metadata) Set(path string, value any) {
+	keys := strings.Split(path, ".")
+	current := m
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+}
+
+// ParseMetadataValue converts a raw string into a JSON value.
+// Numbers, booleans and null are recognised; everything else is a string.
+// A value in double quotes is always a string, so "007" stays "007" where
+// 007 would become the number 7.
+func ParseMetadataValue(raw string) any {
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		var s string
+		if err := json.Unmarshal([]byte(raw), &s); err == nil {
+			return s
+		}
+		return raw[1 : len(raw)-1]
+	}
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		return n
+	}
+	return raw
+}
+
+// MetadataFilter is a single condition on a metadata path, e.g. color=red or size>10
+type MetadataFilter struct {
+	Path     []string
+	Operator string
+	Value    any
+}
+
+// metadataOperators lists supported operators, two-character operators first
+var metadataOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// ParseMetadataExpr parses an expression such as "color=red" or "dims.width>10"
+func ParseMetadataExpr(expr string) (MetadataFilter, error) {
+	for i := 0; i < len(expr); i++ {
+		for _, op := range metadataOperators {
+			if !strings.HasPrefix(expr[i:], op) {
+				continue
+			}
+
+			path := expr[:i]
+			if path == "" {
+				return MetadataFilter{}, fmt.Errorf("metadata filter %q is missing a path", expr)
+			}
+			keys := strings.Split(path, ".")
+			for _, key := range keys {
+				if key == "" {
+					return MetadataFilter{}, fmt.Errorf("metadata filter %q has an empty path segment", expr)
+				}
+			}
+
+			return MetadataFilter{
+				Path:     keys,
+				Operator: op,
+				Value:    ParseMetadataValue(expr[i+len(op):]),
+			}, nil
+		}
+	}
+	return MetadataFilter{}, fmt.Errorf("metadata filter %q has no operator", expr)
+}
+
+// ParseMetadataQuery parses a "meta." query parameter into a filter.
+// Operators other than "=" end up in the key when a URL is parsed, so
+// meta.size>10 arrives as key "meta.size>10" and meta.size>=10 as key
+// "meta.size>" with value "10"; both forms are accepted here.
+func ParseMetadataQuery(key, value string) (MetadataFilter, error) {
+	expr := strings.TrimPrefix(key, MetadataQueryPrefix)
+	if value != "" || !strings.ContainsAny(expr, "<>") {
+		expr += "=" + value
+	}
+	return ParseMetadataExpr(expr)
+}
+
+// QueryParam returns the query parameter key and value that encode this filter
+func (f MetadataFilter) QueryParam() (string, string) {
+	key := MetadataQueryPrefix + strings.Join(f.Path, ".")
+	value := formatMetadataValue(f.Value)
+	switch f.Operator {
+	case "=":
+		return key, value
+	case ">", "<":
+		return key + f.Operator + value, ""
+	default:
+		// ">=", "<=" and "!=" keep their trailing "=" as the key/value separator
+		return key + strings.TrimSuffix(f.Operator, "="), value
+	}
+}
+
+// String returns the filter in expression form
+func (f MetadataFilter) String() string {
+	return strings.Join(f.Path, ".") + f.Operator + formatMetadataValue(f.Value)
+}
+
+// sqlCondition renders the filter as a SQL condition using parameter $argIndex.
+// Equality uses JSONB containment and comparisons use a jsonpath predicate so
+// both can be served by the GIN (jsonb_path_ops) index on items.metadata.
+// "!=" negates containment, which the index cannot serve, so it filters the
+// rows the other conditions select or scans the table.
+func (f MetadataFilter) sqlCondition(argIndex int) (string, any, error) {
+	switch f.Operator {
+	case "=", "!=":
+		doc := Metadata{}
+		doc.Set(strings.Join(f.Path, "."), f.Value)
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return "", nil, err
+		}
+		condition := fmt.Sprintf("metadata @> $%d::jsonb", argIndex)
+		if f.Operator == "!=" {
+			condition = "NOT (" + condition + ")"
+		}
+		return condition, string(b), nil
+	default:
+		var path strings.Builder
+		path.WriteString("$")
+		for _, key := range f.Path {
+			quoted, err := json.Marshal(key)
+			if err != nil {
+				return "", nil, err
+			}
+			path.WriteString(".")
+			path.Write(quoted)
+		}
+		literal, err := json.Marshal(f.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		jsonPath := fmt.Sprintf("%s ? (@ %s %s)", path.String(), f.Operator, literal)
+		return fmt.Sprintf("metadata @? $%d::jsonpath", argIndex), jsonPath, nil
+	}
+}
+
+// formatMetadataValue renders v so that ParseMetadataValue reads it back
+// unchanged; strings that would parse as another type, or that start and end
+// with a quote, are quoted
+func formatMetadataValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		if parsed, ok := ParseMetadataValue(val).(string); !ok || parsed != val {
+			b, _ := json.Marshal(val)
+			return string(b)
+		}
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+// MetadataSchema validates item metadata against a JSON Schema document
+type MetadataSchema struct {
+	schema *jsonschema.Schema
+}
+
+// LoadMetadataSchema compiles the JSON Schema file at path
+func LoadMetadataSchema(path string) (*MetadataSchema, error) {
+	compiler := jsonschema.NewCompiler()
+	schema, err := compiler.Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile metadata schema: %w", err)
+	}
+	return &MetadataSchema{schema: schema}, nil
+}
+
+// Validate checks metadata against the schema
+func (s *MetadataSchema) Validate(m Metadata) error {
+	if s == nil || m == nil {
+		return nil
+	}
+
+	// Round-trip through the schema library's decoder so numbers keep their precision
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	return s.schema.Validate(doc)
+}
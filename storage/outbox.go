@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// OutboxMessage is an item change waiting to be relayed to a publisher.
+// Item is a snapshot of the row after the change (or before it, for deletes).
+type OutboxMessage struct {
+	ID        int64         `db:"id" json:"id"`
+	Type      ItemEventType `db:"event_type" json:"type"`
+	ItemID    uuid.UUID     `db:"item_id" json:"item_id"`
+	Item      Item          `db:"-" json:"item"`
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+	Attempts  int           `db:"attempts" json:"-"`
+
+	ItemJSON []byte `db:"item" json:"-"`
+}
+
+// OutboxHandler publishes one message. Returning an error leaves the message
+// unpublished, and holds back later messages for the same item, until a retry succeeds.
+type OutboxHandler func(ctx context.Context, msg OutboxMessage) error
+
+// OutboxBatch summarises one relay pass
+type OutboxBatch struct {
+	Published []OutboxMessage
+	Failed    int
+}
+
+// OutboxStats describes the relay backlog
+type OutboxStats struct {
+	Pending int `db:"pending" json:"pending"`
+	// Retrying counts pending messages that have failed at least once
+	Retrying        int        `db:"retrying" json:"retrying"`
+	OldestPendingAt *time.Time `db:"oldest_pending_at" json:"oldest_pending_at"`
+	LastPublishedAt *time.Time `db:"last_published_at" json:"last_published_at"`
+}
+
+// enqueueOutbox records an item change in the outbox as part of tx. Every
+// mutation takes the item's row lock before calling this, so outbox ids for a
+// single item are assigned in commit order.
+func enqueueOutbox(ctx context.Context, tx *sqlx.Tx, eventType ItemEventType, item *Item) error {
+	snapshot, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, insertOutboxQuery, item.ID, eventType, string(snapshot))
+	return err
+}
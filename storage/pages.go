@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemCursor marks a position in the newest-first item order. Items are
+// ordered by creation time and then id, so a cursor stays valid when items
+// are added or removed before it.
+type ItemCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// CursorFor returns the cursor that resumes listing after item
+func CursorFor(item Item) ItemCursor {
+	return ItemCursor{CreatedAt: item.CreatedAt, ID: item.ID}
+}
+
+// String encodes the cursor as an opaque token
+func (c ItemCursor) String() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseItemCursor decodes a token produced by ItemCursor.String
+func ParseItemCursor(token string) (ItemCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ItemCursor{}, errors.New("invalid cursor")
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return ItemCursor{}, errors.New("invalid cursor")
+	}
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return ItemCursor{}, errors.New("invalid cursor")
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return ItemCursor{}, errors.New("invalid cursor")
+	}
+	return ItemCursor{CreatedAt: time.UnixMicro(us).UTC(), ID: parsed}, nil
+}
+
+// ItemPageRequest selects a page of items, newest first, for cursor pagination
+type ItemPageRequest struct {
+	// First is the page size (default 10, at most 100)
+	First int
+
+	// After resumes the listing after this cursor
+	After *ItemCursor
+
+	// Status restricts results to the given statuses
+	Status []string
+
+	// MetadataFilters restrict results by metadata, as in ListItemsRequest
+	MetadataFilters []MetadataFilter
+
+	// Search matches items whose name or description contains the text, ignoring case
+	Search string
+}
+
+// ItemPage is one page of items
+type ItemPage struct {
+	Items       []Item
+	HasNextPage bool
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetentionAction is what a retention rule does to the items it selects
+type RetentionAction string
+
+const (
+	// RetentionArchive moves items to archived
+	RetentionArchive RetentionAction = "archive"
+	// RetentionPurge permanently deletes items along with their attachments and links
+	RetentionPurge RetentionAction = "purge"
+)
+
+// Valid reports whether a is a known retention action
+func (a RetentionAction) Valid() bool {
+	return a == RetentionArchive || a == RetentionPurge
+}
+
+// Retention run statuses
+const (
+	RetentionRunRunning   = "running"
+	RetentionRunSucceeded = "succeeded"
+	RetentionRunFailed    = "failed"
+)
+
+// RetentionRun records one execution of a retention rule
+type RetentionRun struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	RuleName      string          `db:"rule_name" json:"rule_name"`
+	Action        RetentionAction `db:"action" json:"action"`
+	Trigger       string          `db:"trigger" json:"trigger"`
+	Status        string          `db:"status" json:"status"`
+	Cutoff        time.Time       `db:"cutoff" json:"cutoff"`
+	AffectedCount int             `db:"affected_count" json:"affected_count"`
+	AffectedIDs   StringList      `db:"affected_ids" json:"affected_ids"`
+	Error         *string         `db:"error" json:"error"`
+	StartedAt     time.Time       `db:"started_at" json:"started_at"`
+	FinishedAt    *time.Time      `db:"finished_at" json:"finished_at"`
+}
+
+// RetentionSelector picks the items a retention rule applies to: those in
+// one of Statuses that have not been updated since Cutoff
+type RetentionSelector struct {
+	Statuses []string
+	Cutoff   time.Time
+}
+
+// ListRetentionRunsRequest represents filters for listing retention runs
+type ListRetentionRunsRequest struct {
+	Rule  string `form:"rule" json:"rule"`
+	Limit int    `form:"limit" json:"limit"`
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes for failures that leave nothing committed
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgAdminShutdown        = "57P01"
+	pgCannotConnectNow     = "57P03"
+	pgConnectionException  = "08"
+)
+
+// Retry defaults for transient database errors
+const (
+	DefaultRetryAttempts = 3
+	DefaultRetryBackoff  = 25 * time.Millisecond
+)
+
+// retries counts operations run again after a transient error
+var retries atomic.Int64
+
+// Retries returns how many times storage operations have been retried since start
+func Retries() int64 {
+	return retries.Load()
+}
+
+// retryable reports whether err is a transient failure worth another try.
+//
+// Serialization failures, deadlocks and refused connections are always
+// retryable: Postgres rolled the transaction back, so nothing was applied.
+// A broken connection is only retryable when the operation is idempotent or
+// pgx knows the failure happened before anything reached the server; a write
+// whose connection dropped mid-commit may have been applied, and running it
+// again could apply it twice.
+func retryable(err error, idempotent bool) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgSerializationFailure, pgDeadlockDetected, pgCannotConnectNow:
+			return true
+		case pgAdminShutdown:
+			return idempotent
+		}
+		return idempotent && len(pgErr.Code) == 5 && pgErr.Code[:2] == pgConnectionException
+	}
+
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+	if !idempotent {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.As(err, &netErr)
+}
+
+// withRetry runs op, running it again with jittered exponential backoff
+// while it fails with a retryable error. Reads and other operations that
+// are safe to repeat pass idempotent; writes must not, so that a connection
+// lost during commit is reported rather than retried.
+func withRetry[T any](ctx context.Context, idempotent bool, op func() (T, error)) (T, error) {
+	backoff := DefaultRetryBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := op()
+		if attempt == DefaultRetryAttempts || !retryable(err, idempotent) {
+			return result, err
+		}
+
+		// Full jitter keeps retrying clients from colliding again
+		delay := rand.N(backoff) + time.Millisecond
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(delay):
+		}
+		backoff *= 2
+		retries.Add(1)
+	}
+}
@@ -7,48 +7,84 @@ import (
 
 // Item represents an item in the database
 type Item struct {
-	ID          uuid.UUID `db:"id" json:"id"`
-	Name        string    `db:"name" json:"name"`
-	Description *string   `db:"description" json:"description"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ID          uuid.UUID  `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	Description *string    `db:"description" json:"description"`
+	Metadata    Metadata   `db:"metadata" json:"metadata"`
+	Status      ItemStatus `db:"status" json:"status"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 // CreateItemRequest represents the request payload for creating an item
 type CreateItemRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description *string `json:"description"`
+	Name        string   `json:"name" binding:"required"`
+	Description *string  `json:"description"`
+	Metadata    Metadata `json:"metadata,omitempty"`
 }
 
 // UpdateItemRequest represents the request payload for updating an item
 type UpdateItemRequest struct {
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Metadata    *Metadata `json:"metadata,omitempty"`
 }
 
 // ListItemsRequest represents pagination parameters for listing items
 type ListItemsRequest struct {
 	Limit  int `form:"limit" json:"limit"`
 	Offset int `form:"offset" json:"offset"`
+
+	// Status restricts results to the given statuses (repeatable or comma-separated)
+	Status []string `form:"status" json:"status,omitempty"`
+
+	// Fields narrows the columns read to these ItemColumns (repeatable or
+	// comma-separated, normalised by ParseItemFields); empty reads them all
+	Fields []string `form:"fields" json:"fields,omitempty"`
+
+	// IncludeTotal set to false skips counting the matching items
+	IncludeTotal *bool `form:"include_total" json:"include_total,omitempty"`
+
+	// MetadataFilters are parsed from "meta." query parameters by the handler
+	MetadataFilters []MetadataFilter `form:"-" json:"-"`
+}
+
+// CountTotal reports whether the matching items should be counted
+func (r ListItemsRequest) CountTotal() bool {
+	return r.IncludeTotal == nil || *r.IncludeTotal
 }
 
 // ListItemsResponse represents the response for listing items
 type ListItemsResponse struct {
-	Items  []Item `json:"items"`
-	Total  int    `json:"total"`
-	Limit  int    `json:"limit"`
-	Offset int    `json:"offset"`
+	Items []Item `json:"items"`
+	// Total is nil when the request set IncludeTotal to false
+	Total   *int `json:"total,omitempty"`
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	HasMore bool `json:"has_more"`
+	// Version is read in the same snapshot as Items when the total is
+	// counted, and nil otherwise. It is kept with cached pages so their
+	// validators describe the cached items.
+	Version *ItemsVersion `json:"version,omitempty"`
+}
+
+// ItemsVersion summarises the items a list request matches; it changes
+// whenever one of them is created, updated or deleted
+type ItemsVersion struct {
+	Count int `db:"count" json:"count"`
+	// LastModified is the latest updated_at, nil when nothing matches
+	LastModified *time.Time `db:"last_modified" json:"last_modified"`
 }
 
 const (
 	createItemQuery = `
-		INSERT INTO items (name, description)
-		VALUES ($1, $2)
-		RETURNING id, name, description, created_at, updated_at
+		INSERT INTO items (name, description, metadata)
+		VALUES ($1, $2, COALESCE($3::jsonb, '{}'::jsonb))
+		RETURNING id, name, description, metadata, status, created_at, updated_at
 	`
 
 	getItemQuery = `
-		SELECT id, name, description, created_at, updated_at
+		SELECT id, name, description, metadata, status, created_at, updated_at
 		FROM items
 		WHERE id = $1
 	`
@@ -57,26 +93,663 @@ const (
 		UPDATE items
 		SET name = COALESCE($2, name),
 			description = COALESCE($3, description),
+			metadata = COALESCE($4::jsonb, metadata),
 			updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, name, description, created_at, updated_at
+		RETURNING id, name, description, metadata, status, created_at, updated_at
 	`
 
 	deleteItemQuery = `
 		DELETE FROM items
 		WHERE id = $1
-		RETURNING id, name, description, created_at, updated_at
+		RETURNING id, name, description, metadata, status, created_at, updated_at
 	`
 
+	// listItemsQuery takes the SELECT list, a WHERE clause and the LIMIT/OFFSET placeholders
 	listItemsQuery = `
-		SELECT id, name, description, created_at, updated_at
+		SELECT %s
 		FROM items
+		%s
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $%d OFFSET $%d
+	`
+
+	lockItemStatusQuery = `
+		SELECT status
+		FROM items
+		WHERE id = $1
+		FOR UPDATE
 	`
 
+	setItemStatusQuery = `
+		UPDATE items
+		SET status = $2,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, name, description, metadata, status, created_at, updated_at
+	`
+
+	insertItemStatusEventQuery = `
+		INSERT INTO item_status_events (item_id, transition, from_status, to_status)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	listItemStatusEventsQuery = `
+		SELECT id, item_id, transition, from_status, to_status, occurred_at
+		FROM item_status_events
+		WHERE item_id = $1
+		ORDER BY occurred_at, id
+	`
+
+	// countItemsQuery takes the same WHERE clause as listItemsQuery
 	countItemsQuery = `
 		SELECT COUNT(*)
 		FROM items
+		%s
+	`
+
+	// itemsVersionQuery takes the same WHERE clause as listItemsQuery
+	itemsVersionQuery = `
+		SELECT COUNT(*) AS count, MAX(updated_at) AS last_modified
+		FROM items
+		%s
+	`
+
+	// listItemsPageQuery takes a WHERE clause and the LIMIT placeholder.
+	// The id tiebreak gives cursors a total order.
+	listItemsPageQuery = `
+		SELECT id, name, description, metadata, status, created_at, updated_at
+		FROM items
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d
+	`
+
+	listItemStatusEventsByItemsQuery = `
+		SELECT id, item_id, transition, from_status, to_status, occurred_at
+		FROM item_status_events
+		WHERE item_id = ANY($1::uuid[])
+		ORDER BY occurred_at, id
+	`
+)
+
+// dependencyEdgesCTE normalises depends_on and blocks links into
+// (dependent_id, dependency_id) pairs so the graph queries only deal with one shape
+const dependencyEdgesCTE = `
+	dependency_edges AS (
+		SELECT source_id AS dependent_id, target_id AS dependency_id
+		FROM item_links
+		WHERE relation = 'depends_on'
+		UNION
+		SELECT target_id AS dependent_id, source_id AS dependency_id
+		FROM item_links
+		WHERE relation = 'blocks'
+	)`
+
+const (
+	// lockDependencyGraphQuery serialises dependency link inserts so two
+	// concurrent requests cannot each pass the cycle check and close a loop
+	lockDependencyGraphQuery = `SELECT pg_advisory_xact_lock(hashtext('item_links_dependency_graph'))`
+
+	countItemsByIDQuery = `
+		SELECT COUNT(*)
+		FROM items
+		WHERE id = ANY($1::uuid[])
+	`
+
+	// dependencyReachableQuery reports whether $2 is reachable from $1 by following dependencies
+	dependencyReachableQuery = `
+		WITH RECURSIVE` + dependencyEdgesCTE + `,
+		reachable AS (
+			SELECT dependency_id AS id
+			FROM dependency_edges
+			WHERE dependent_id = $1
+			UNION
+			SELECT e.dependency_id
+			FROM dependency_edges e
+			JOIN reachable r ON e.dependent_id = r.id
+		)
+		SELECT EXISTS (SELECT 1 FROM reachable WHERE id = $2)
+	`
+
+	createItemLinkQuery = `
+		INSERT INTO item_links (source_id, target_id, relation)
+		VALUES ($1, $2, $3)
+		RETURNING id, source_id, target_id, relation, created_at
+	`
+
+	deleteItemLinkQuery = `
+		DELETE FROM item_links
+		WHERE id = $2 AND (source_id = $1 OR target_id = $1)
+		RETURNING id, source_id, target_id, relation, created_at
+	`
+
+	listItemNeighborsQuery = `
+		SELECT l.id AS link_id, l.relation, 'outgoing' AS direction,
+			i.id AS item_id, i.name AS item_name, i.status AS item_status, l.created_at AS linked_at
+		FROM item_links l
+		JOIN items i ON i.id = l.target_id
+		WHERE l.source_id = $1
+		UNION ALL
+		SELECT l.id AS link_id, l.relation, 'incoming' AS direction,
+			i.id AS item_id, i.name AS item_name, i.status AS item_status, l.created_at AS linked_at
+		FROM item_links l
+		JOIN items i ON i.id = l.source_id
+		WHERE l.target_id = $1
+		ORDER BY linked_at
+	`
+
+	// listItemNeighborsByItemsQuery is listItemNeighborsQuery for many items at
+	// once; owner_id is the item each neighbor was found for
+	listItemNeighborsByItemsQuery = `
+		SELECT l.source_id AS owner_id, l.id AS link_id, l.relation, 'outgoing' AS direction,
+			i.id AS item_id, i.name AS item_name, i.status AS item_status, l.created_at AS linked_at
+		FROM item_links l
+		JOIN items i ON i.id = l.target_id
+		WHERE l.source_id = ANY($1::uuid[])
+		UNION ALL
+		SELECT l.target_id AS owner_id, l.id AS link_id, l.relation, 'incoming' AS direction,
+			i.id AS item_id, i.name AS item_name, i.status AS item_status, l.created_at AS linked_at
+		FROM item_links l
+		JOIN items i ON i.id = l.source_id
+		WHERE l.target_id = ANY($1::uuid[])
+		ORDER BY linked_at
+	`
+
+	// dependencyGraphQuery takes the "from" and "to" columns of dependency_edges.
+	// The path array stops the walk on cycles that predate cycle detection.
+	dependencyGraphQuery = `
+		WITH RECURSIVE` + dependencyEdgesCTE + `,
+		edges AS (
+			SELECT %s AS from_id, %s AS to_id
+			FROM dependency_edges
+		),
+		walk AS (
+			SELECT from_id, to_id, 1 AS depth, ARRAY[from_id, to_id] AS path
+			FROM edges
+			WHERE from_id = $1
+			UNION ALL
+			SELECT e.from_id, e.to_id, w.depth + 1, w.path || e.to_id
+			FROM edges e
+			JOIN walk w ON e.from_id = w.to_id
+			WHERE e.to_id <> ALL(w.path) AND w.depth < $2
+		)
+		SELECT from_id, to_id, MIN(depth) AS depth
+		FROM walk
+		GROUP BY from_id, to_id
+		ORDER BY depth, from_id, to_id
+	`
+
+	getItemsByIDQuery = `
+		SELECT id, name, description, metadata, status, created_at, updated_at
+		FROM items
+		WHERE id = ANY($1::uuid[])
+		ORDER BY name
+	`
+)
+
+const (
+	createAttachmentQuery = `
+		INSERT INTO item_attachments (id, item_id, filename, content_type, size_bytes, sha256, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, item_id, filename, content_type, size_bytes, sha256, storage_key, created_at
+	`
+
+	getAttachmentQuery = `
+		SELECT id, item_id, filename, content_type, size_bytes, sha256, storage_key, created_at
+		FROM item_attachments
+		WHERE item_id = $1 AND id = $2
+	`
+
+	listAttachmentsQuery = `
+		SELECT id, item_id, filename, content_type, size_bytes, sha256, storage_key, created_at
+		FROM item_attachments
+		WHERE item_id = $1
+		ORDER BY created_at, id
+	`
+
+	listAttachmentsByItemsQuery = `
+		SELECT id, item_id, filename, content_type, size_bytes, sha256, storage_key, created_at
+		FROM item_attachments
+		WHERE item_id = ANY($1::uuid[])
+		ORDER BY created_at, id
+	`
+
+	deleteAttachmentQuery = `
+		DELETE FROM item_attachments
+		WHERE item_id = $1 AND id = $2
+		RETURNING id, item_id, filename, content_type, size_bytes, sha256, storage_key, created_at
+	`
+)
+
+const (
+	getItemEventsByIDQuery = `
+		SELECT id, event_type, item_id, item, occurred_at
+		FROM item_events
+		WHERE id = ANY($1::bigint[])
+		ORDER BY id
+	`
+
+	// listItemEventsAfterQuery takes an optional item filter ($2) and tag filter ($3)
+	listItemEventsAfterQuery = `
+		SELECT id, event_type, item_id, item, occurred_at
+		FROM item_events
+		WHERE id > $1
+			AND ($2::uuid IS NULL OR item_id = $2)
+			AND ($3::text IS NULL OR item->'metadata'->'tags' ? $3)
+		ORDER BY id
+		LIMIT $4
+	`
+
+	latestItemEventIDQuery = `
+		SELECT COALESCE(MAX(id), 0)
+		FROM item_events
+	`
+)
+
+const (
+	createWebhookQuery = `
+		INSERT INTO webhook_subscriptions (url, secret, event_types)
+		VALUES ($1, $2, $3::jsonb)
+		RETURNING id, url, secret, event_types, active, created_at, updated_at
+	`
+
+	getWebhookQuery = `
+		SELECT id, url, secret, event_types, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	listWebhooksQuery = `
+		SELECT id, url, secret, event_types, active, created_at, updated_at
+		FROM webhook_subscriptions
+		ORDER BY created_at
+	`
+
+	deleteWebhookQuery = `
+		DELETE FROM webhook_subscriptions
+		WHERE id = $1
+		RETURNING id, url, secret, event_types, active, created_at, updated_at
+	`
+
+	createTestDeliveryQuery = `
+		INSERT INTO webhook_deliveries (subscription_id, event_type, payload)
+		VALUES ($1, $2, $3::jsonb)
+		RETURNING id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_status_code, last_error, delivered_at, created_at, updated_at
+	`
+
+	// claimDeliveriesQuery leases up to $1 due deliveries for $2 seconds by
+	// pushing next_attempt_at forward; if the worker dies mid-send the
+	// delivery becomes due again once the lease expires
+	claimDeliveriesQuery = `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2),
+			updated_at = NOW()
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at, d.updated_at,
+			d.retry_base, s.url, s.secret
+	`
+
+	claimDeliveryQuery = `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2),
+			updated_at = NOW()
+		FROM webhook_subscriptions s
+		WHERE d.id = $1 AND d.status = 'pending' AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+			d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at, d.updated_at,
+			d.retry_base, s.url, s.secret
+	`
+
+	insertDeliveryAttemptQuery = `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	updateDeliveryResultQuery = `
+		UPDATE webhook_deliveries
+		SET status = $2,
+			attempts = $3,
+			next_attempt_at = $4,
+			last_status_code = $5,
+			last_error = $6,
+			delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() ELSE delivered_at END,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+
+	getDeliveryQuery = `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_status_code, last_error, delivered_at, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE id = $1
+	`
+
+	listDeliveriesQuery = `
+		SELECT id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_status_code, last_error, delivered_at, created_at, updated_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+			AND ($2::text IS NULL OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	listDeliveryAttemptsQuery = `
+		SELECT id, delivery_id, attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt, id
+	`
+
+	// redeliverQuery starts a fresh retry budget without resetting attempts,
+	// so attempt numbers in the log stay unique
+	redeliverQuery = `
+		UPDATE webhook_deliveries
+		SET status = 'pending',
+			retry_base = attempts,
+			next_attempt_at = NOW(),
+			last_error = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_status_code, last_error, delivered_at, created_at, updated_at
+	`
+)
+
+const (
+	insertOutboxQuery = `
+		INSERT INTO outbox (item_id, event_type, item)
+		VALUES ($1, $2, $3::jsonb)
+	`
+
+	// claimOutboxQuery leases the oldest unpublished message of each item
+	// until $2 seconds from now. Later messages wait until every earlier one
+	// for the same item is published, which keeps per-item order even with
+	// several relays.
+	claimOutboxQuery = `
+		WITH claimed AS (
+			UPDATE outbox
+			SET locked_until = NOW() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT o.id
+				FROM outbox o
+				WHERE o.published_at IS NULL
+					AND o.available_at <= NOW()
+					AND (o.locked_until IS NULL OR o.locked_until <= NOW())
+					AND NOT EXISTS (
+						SELECT 1
+						FROM outbox earlier
+						WHERE earlier.item_id = o.item_id
+							AND earlier.published_at IS NULL
+							AND earlier.id < o.id
+					)
+				ORDER BY o.id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, item_id, event_type, item, created_at, attempts
+		)
+		SELECT * FROM claimed ORDER BY id
+	`
+
+	markOutboxPublishedQuery = `
+		UPDATE outbox
+		SET published_at = NOW(), last_error = NULL, locked_until = NULL
+		WHERE id = $1 AND published_at IS NULL
+	`
+
+	markOutboxFailedQuery = `
+		UPDATE outbox
+		SET attempts = attempts + 1,
+			last_error = $2,
+			available_at = NOW() + make_interval(secs => $3),
+			locked_until = NULL
+		WHERE id = $1 AND published_at IS NULL
+	`
+
+	outboxStatsQuery = `
+		SELECT
+			COUNT(*) FILTER (WHERE published_at IS NULL) AS pending,
+			COUNT(*) FILTER (WHERE published_at IS NULL AND attempts > 0) AS retrying,
+			MIN(created_at) FILTER (WHERE published_at IS NULL) AS oldest_pending_at,
+			MAX(published_at) AS last_published_at
+		FROM outbox
+	`
+
+	purgeOutboxQuery = `
+		DELETE FROM outbox
+		WHERE published_at IS NOT NULL AND published_at < NOW() - make_interval(secs => $1)
+	`
+)
+
+const (
+	enqueueJobQuery = `
+		INSERT INTO jobs (kind, payload, priority, run_at, max_attempts, timeout_seconds)
+		VALUES ($1, $2::jsonb, $3, COALESCE($4, NOW()), $5, $6)
+		RETURNING id, kind, payload, status, priority, run_at, attempts, max_attempts, timeout_seconds,
+			result, last_error, locked_by, heartbeat_at, started_at, finished_at, created_at, updated_at
+	`
+
+	getJobQuery = `
+		SELECT id, kind, payload, status, priority, run_at, attempts, max_attempts, timeout_seconds,
+			result, last_error, locked_by, heartbeat_at, started_at, finished_at, created_at, updated_at
+		FROM jobs
+		WHERE id = $1
+	`
+
+	listJobsQuery = `
+		SELECT id, kind, payload, status, priority, run_at, attempts, max_attempts, timeout_seconds,
+			result, last_error, locked_by, heartbeat_at, started_at, finished_at, created_at, updated_at
+		FROM jobs
+		WHERE ($1::text IS NULL OR status = $1)
+			AND ($2::text IS NULL OR kind = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	countJobsQuery = `
+		SELECT COUNT(*)
+		FROM jobs
+		WHERE ($1::text IS NULL OR status = $1)
+			AND ($2::text IS NULL OR kind = $2)
+	`
+
+	// claimJobQuery takes the highest-priority ready job of the given kinds
+	// and marks it running under worker $1
+	claimJobQuery = `
+		UPDATE jobs
+		SET status = 'running',
+			attempts = attempts + 1,
+			locked_by = $1,
+			heartbeat_at = NOW(),
+			started_at = NOW(),
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id
+			FROM jobs
+			WHERE status = 'queued' AND run_at <= NOW() AND kind = ANY($2::text[])
+			ORDER BY priority DESC, run_at, created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, payload, status, priority, run_at, attempts, max_attempts, timeout_seconds,
+			result, last_error, locked_by, heartbeat_at, started_at, finished_at, created_at, updated_at
+	`
+
+	heartbeatJobQuery = `
+		UPDATE jobs
+		SET heartbeat_at = NOW()
+		WHERE id = $1 AND status = 'running' AND locked_by = $2
+	`
+
+	completeJobQuery = `
+		UPDATE jobs
+		SET status = 'succeeded',
+			result = $3::jsonb,
+			last_error = NULL,
+			locked_by = NULL,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'running' AND locked_by = $2
+	`
+
+	// failJobQuery requeues the job after $4 seconds, or fails it for good
+	// once it has used up its attempts
+	failJobQuery = `
+		UPDATE jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'queued' END,
+			run_at = CASE WHEN attempts >= max_attempts THEN run_at ELSE NOW() + make_interval(secs => $4) END,
+			finished_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END,
+			last_error = $3,
+			locked_by = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'running' AND locked_by = $2
+	`
+
+	// releaseJobQuery hands an interrupted job back to the queue without
+	// counting the attempt, e.g. when a worker shuts down mid-run
+	releaseJobQuery = `
+		UPDATE jobs
+		SET status = 'queued',
+			attempts = GREATEST(attempts - 1, 0),
+			run_at = NOW(),
+			locked_by = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'running' AND locked_by = $2
+	`
+
+	cancelJobQuery = `
+		UPDATE jobs
+		SET status = 'cancelled',
+			locked_by = NULL,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND status IN ('queued', 'running')
+		RETURNING id, kind, payload, status, priority, run_at, attempts, max_attempts, timeout_seconds,
+			result, last_error, locked_by, heartbeat_at, started_at, finished_at, created_at, updated_at
+	`
+
+	// reapJobsQuery requeues running jobs whose worker stopped heartbeating
+	reapJobsQuery = `
+		UPDATE jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'queued' END,
+			finished_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END,
+			run_at = NOW(),
+			last_error = 'worker stopped heartbeating',
+			locked_by = NULL,
+			updated_at = NOW()
+		WHERE status = 'running' AND heartbeat_at < NOW() - make_interval(secs => $1)
+	`
+)
+
+const (
+	retentionCandidatesQuery = `
+		SELECT id, name, description, metadata, status, created_at, updated_at
+		FROM items
+		WHERE status = ANY($1::text[]) AND updated_at < $2
+		ORDER BY updated_at
+		LIMIT $3
+	`
+
+	countRetentionCandidatesQuery = `
+		SELECT COUNT(*)
+		FROM items
+		WHERE status = ANY($1::text[]) AND updated_at < $2
+	`
+
+	// archiveRetentionBatchQuery archives up to $3 matching items, skipping
+	// rows locked by in-flight requests, and returns each item's old status
+	archiveRetentionBatchQuery = `
+		WITH candidates AS (
+			SELECT id, status
+			FROM items
+			WHERE status = ANY($1::text[]) AND updated_at < $2
+			ORDER BY updated_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE items i
+		SET status = 'archived',
+			updated_at = NOW()
+		FROM candidates c
+		WHERE i.id = c.id
+		RETURNING i.id, i.name, i.description, i.metadata, i.status, i.created_at, i.updated_at,
+			c.status AS previous_status
+	`
+
+	lockRetentionBatchQuery = `
+		SELECT id
+		FROM items
+		WHERE status = ANY($1::text[]) AND updated_at < $2
+		ORDER BY updated_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`
+
+	listAttachmentKeysByItemsQuery = `
+		SELECT storage_key
+		FROM item_attachments
+		WHERE item_id = ANY($1::uuid[])
+	`
+
+	deleteItemsByIDQuery = `
+		DELETE FROM items
+		WHERE id = ANY($1::uuid[])
+		RETURNING id, name, description, metadata, status, created_at, updated_at
+	`
+
+	// tryRetentionLockQuery takes a session-level advisory lock per rule so
+	// only one replica runs a rule at a time
+	tryRetentionLockQuery = `SELECT pg_try_advisory_lock(hashtext('retention:' || $1))`
+
+	releaseRetentionLockQuery = `SELECT pg_advisory_unlock(hashtext('retention:' || $1))`
+
+	createRetentionRunQuery = `
+		INSERT INTO retention_runs (rule_name, action, trigger, cutoff)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, rule_name, action, trigger, status, cutoff, affected_count, affected_ids,
+			error, started_at, finished_at
+	`
+
+	finishRetentionRunQuery = `
+		UPDATE retention_runs
+		SET status = $2,
+			affected_count = $3,
+			affected_ids = $4::jsonb,
+			error = $5,
+			finished_at = NOW()
+		WHERE id = $1
+		RETURNING id, rule_name, action, trigger, status, cutoff, affected_count, affected_ids,
+			error, started_at, finished_at
+	`
+
+	listRetentionRunsQuery = `
+		SELECT id, rule_name, action, trigger, status, cutoff, affected_count, affected_ids,
+			error, started_at, finished_at
+		FROM retention_runs
+		WHERE ($1::text IS NULL OR rule_name = $1)
+		ORDER BY started_at DESC
+		LIMIT $2
+	`
+)
+
+const (
+	takeRateLimitTokenQuery = `SELECT allowed, remaining FROM take_rate_limit_token($1, $2, $3)`
+
+	purgeRateLimitBucketsQuery = `
+		DELETE FROM rate_limit_buckets
+		WHERE updated_at < NOW() - make_interval(secs => $1)
 	`
 )
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemStatus is the lifecycle state of an item
+type ItemStatus string
+
+const (
+	StatusDraft     ItemStatus = "draft"
+	StatusPublished ItemStatus = "published"
+	StatusArchived  ItemStatus = "archived"
+)
+
+// Valid reports whether s is a known item status
+func (s ItemStatus) Valid() bool {
+	switch s {
+	case StatusDraft, StatusPublished, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// ParseStatusList normalises status query values, which may be repeated or
+// comma-separated, and rejects unknown statuses
+func ParseStatusList(values []string) ([]string, error) {
+	var statuses []string
+	for _, value := range values {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				continue
+			}
+			if !ItemStatus(status).Valid() {
+				return nil, fmt.Errorf("invalid status %q (expected draft, published or archived)", status)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// Transition is an action that moves an item between statuses
+type Transition string
+
+const (
+	TransitionPublish   Transition = "publish"
+	TransitionArchive   Transition = "archive"
+	TransitionUnarchive Transition = "unarchive"
+)
+
+// transitionRule lists the statuses a transition may start from and where it ends
+type transitionRule struct {
+	from []ItemStatus
+	to   ItemStatus
+}
+
+// transitions is the item state machine:
+//
+//	draft --publish--> published --archive--> archived --unarchive--> draft
+//	draft --archive--> archived
+var transitions = map[Transition]transitionRule{
+	TransitionPublish:   {from: []ItemStatus{StatusDraft}, to: StatusPublished},
+	TransitionArchive:   {from: []ItemStatus{StatusDraft, StatusPublished}, to: StatusArchived},
+	TransitionUnarchive: {from: []ItemStatus{StatusArchived}, to: StatusDraft},
+}
+
+// Target returns the status the transition leads to from the given status,
+// or a *TransitionError when the move is not allowed
+func (t Transition) Target(id uuid.UUID, from ItemStatus) (ItemStatus, error) {
+	rule, ok := transitions[t]
+	if !ok {
+		return "", fmt.Errorf("unknown transition %q", t)
+	}
+	for _, allowed := range rule.from {
+		if allowed == from {
+			return rule.to, nil
+		}
+	}
+	return "", &TransitionError{ItemID: id, Transition: t, From: from}
+}
+
+// TransitionError is returned when a transition is not allowed from the item's current status
+type TransitionError struct {
+	ItemID     uuid.UUID
+	Transition Transition
+	From       ItemStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot %s item %s while it is %s", e.Transition, e.ItemID, e.From)
+}
+
+// ItemStatusEvent records a single status transition of an item
+type ItemStatusEvent struct {
+	ID         int64      `db:"id" json:"id"`
+	ItemID     uuid.UUID  `db:"item_id" json:"item_id"`
+	Transition Transition `db:"transition" json:"transition"`
+	FromStatus ItemStatus `db:"from_status" json:"from_status"`
+	ToStatus   ItemStatus `db:"to_status" json:"to_status"`
+	OccurredAt time.Time  `db:"occurred_at" json:"occurred_at"`
+}
@@ -3,12 +3,23 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 )
 
+// Postgres error codes for unique and foreign key constraint violations
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
 // Store handles all database operations
 type Store struct {
 	db *sqlx.DB
@@ -21,11 +32,36 @@ func New(db *sqlx.DB) *Store {
 	}
 }
 
+// PoolStats reports the connection pool's usage and wait counters
+func (s *Store) PoolStats() sql.DBStats {
+	return s.db.Stats()
+}
+
 // CreateItem creates a new item in the database
 func (s *Store) CreateItem(ctx context.Context, req CreateItemRequest) (*Item, error) {
+	return withRetry(ctx, false, func() (*Item, error) {
+		return s.createItem(ctx, req)
+	})
+}
+
+func (s *Store) createItem(ctx context.Context, req CreateItemRequest) (*Item, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var item Item
-	err := s.db.GetContext(ctx, &item, createItemQuery, req.Name, req.Description)
+	err = tx.GetContext(ctx, &item, createItemQuery, req.Name, req.Description, req.Metadata)
 	if err != nil {
+		return nil, itemConstraintError(err)
+	}
+
+	if err := enqueueOutbox(ctx, tx, EventItemCreated, &item); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 	return &item, nil
@@ -33,6 +69,12 @@ func (s *Store) CreateItem(ctx context.Context, req CreateItemRequest) (*Item, e
 
 // ListItems retrieves a paginated list of items from the database
 func (s *Store) ListItems(ctx context.Context, req ListItemsRequest) (*ListItemsResponse, error) {
+	return withRetry(ctx, true, func() (*ListItemsResponse, error) {
+		return s.listItems(ctx, req)
+	})
+}
+
+func (s *Store) listItems(ctx context.Context, req ListItemsRequest) (*ListItemsResponse, error) {
 	// Set default values for pagination
 	if req.Limit <= 0 {
 		req.Limit = 10 // Default to 10 items per page
@@ -44,30 +86,144 @@ func (s *Store) ListItems(ctx context.Context, req ListItemsRequest) (*ListItems
 		req.Offset = 0
 	}
 
-	// Get total count
-	var total int
-	err := s.db.GetContext(ctx, &total, countItemsQuery)
+	where, args, err := buildListFilters(req)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get items
+	response := &ListItemsResponse{
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}
+
+	// Count the matching items and read the page from one snapshot, so the
+	// total and the version agree with the items returned
+	var q sqlx.QueryerContext = s.db
+	var tx *sqlx.Tx
+	if req.CountTotal() {
+		tx, err = s.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+		q = tx
+
+		var version ItemsVersion
+		err = sqlx.GetContext(ctx, tx, &version, fmt.Sprintf(itemsVersionQuery, where), args...)
+		if err != nil {
+			return nil, err
+		}
+		response.Total = &version.Count
+		response.Version = &version
+	}
+
+	// Get items, reading one extra row to learn whether more follow
 	var items []Item
-	err = s.db.SelectContext(ctx, &items, listItemsQuery, req.Limit, req.Offset)
+	query := fmt.Sprintf(listItemsQuery, itemSelectList(req.Fields), where, len(args)+1, len(args)+2)
+	err = sqlx.SelectContext(ctx, q, &items, query, append(args, req.Limit+1, req.Offset)...)
 	if err != nil {
 		return nil, err
 	}
+	if len(items) > req.Limit {
+		items = items[:req.Limit]
+		response.HasMore = true
+	}
+	response.Items = items
 
-	return &ListItemsResponse{
-		Items:  items,
-		Total:  total,
-		Limit:  req.Limit,
-		Offset: req.Offset,
-	}, nil
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// ListItemsPage retrieves a page of items, newest first, resuming after
+// req.After. It reads one extra row to learn whether another page follows.
+func (s *Store) ListItemsPage(ctx context.Context, req ItemPageRequest) (*ItemPage, error) {
+	return withRetry(ctx, true, func() (*ItemPage, error) {
+		return s.listItemsPage(ctx, req)
+	})
+}
+
+func (s *Store) listItemsPage(ctx context.Context, req ItemPageRequest) (*ItemPage, error) {
+	if req.First <= 0 {
+		req.First = 10
+	}
+	if req.First > 100 {
+		req.First = 100
+	}
+
+	where, args, err := buildPageFilters(req)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	query := fmt.Sprintf(listItemsPageQuery, where, len(args)+1)
+	err = s.db.SelectContext(ctx, &items, query, append(args, req.First+1)...)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ItemPage{Items: items}
+	if len(items) > req.First {
+		page.Items = items[:req.First]
+		page.HasNextPage = true
+	}
+	return page, nil
+}
+
+// CountItemsPage counts every item matching the filters of req, ignoring its cursor
+func (s *Store) CountItemsPage(ctx context.Context, req ItemPageRequest) (int, error) {
+	return withRetry(ctx, true, func() (int, error) {
+		return s.countItemsPage(ctx, req)
+	})
+}
+
+func (s *Store) countItemsPage(ctx context.Context, req ItemPageRequest) (int, error) {
+	req.After = nil
+	where, args, err := buildPageFilters(req)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	err = s.db.GetContext(ctx, &total, fmt.Sprintf(countItemsQuery, where), args...)
+	return total, err
+}
+
+// ListItemsVersion counts the items req matches and finds when the latest
+// of them changed, ignoring pagination and fields. HTTP list ETags are
+// derived from it, so a conditional request can be answered without
+// reading the page. ListItems returns the same version alongside the page.
+func (s *Store) ListItemsVersion(ctx context.Context, req ListItemsRequest) (*ItemsVersion, error) {
+	return withRetry(ctx, true, func() (*ItemsVersion, error) {
+		return s.listItemsVersion(ctx, req)
+	})
+}
+
+func (s *Store) listItemsVersion(ctx context.Context, req ListItemsRequest) (*ItemsVersion, error) {
+	where, args, err := buildListFilters(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var version ItemsVersion
+	if err := s.db.GetContext(ctx, &version, fmt.Sprintf(itemsVersionQuery, where), args...); err != nil {
+		return nil, err
+	}
+	return &version, nil
 }
 
 // GetItem retrieves a single item by ID
 func (s *Store) GetItem(ctx context.Context, id uuid.UUID) (*Item, error) {
+	return withRetry(ctx, true, func() (*Item, error) {
+		return s.getItem(ctx, id)
+	})
+}
+
+func (s *Store) getItem(ctx context.Context, id uuid.UUID) (*Item, error) {
 	var item Item
 	err := s.db.GetContext(ctx, &item, getItemQuery, id)
 	if err != nil {
@@ -79,28 +235,1468 @@ func (s *Store) GetItem(ctx context.Context, id uuid.UUID) (*Item, error) {
 	return &item, nil
 }
 
+// GetItemsByID loads the given items; ids that do not exist are left out
+func (s *Store) GetItemsByID(ctx context.Context, ids []uuid.UUID) ([]Item, error) {
+	return withRetry(ctx, true, func() ([]Item, error) {
+		return s.getItemsByID(ctx, ids)
+	})
+}
+
+func (s *Store) getItemsByID(ctx context.Context, ids []uuid.UUID) ([]Item, error) {
+	items := []Item{}
+	err := s.db.SelectContext(ctx, &items, getItemsByIDQuery, uuidStrings(ids))
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // UpdateItem updates an existing item
 func (s *Store) UpdateItem(ctx context.Context, id uuid.UUID, req UpdateItemRequest) (*Item, error) {
+	return withRetry(ctx, false, func() (*Item, error) {
+		return s.updateItem(ctx, id, req)
+	})
+}
+
+func (s *Store) updateItem(ctx context.Context, id uuid.UUID, req UpdateItemRequest) (*Item, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var item Item
-	err := s.db.GetContext(ctx, &item, updateItemQuery, id, req.Name, req.Description)
+	err = tx.GetContext(ctx, &item, updateItemQuery, id, req.Name, req.Description, req.Metadata)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("item not found")
 		}
+		return nil, itemConstraintError(err)
+	}
+
+	if err := enqueueOutbox(ctx, tx, EventItemUpdated, &item); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 	return &item, nil
 }
 
-// DeleteItem deletes an item by ID
-func (s *Store) DeleteItem(ctx context.Context, id uuid.UUID) (*Item, error) {
+// DeleteItem deletes an item by ID and returns it with the storage keys of
+// the attachment blobs whose rows were deleted with it. The item row is
+// locked first, so an attachment being added concurrently either commits
+// before the keys are read or fails its foreign key check afterwards.
+func (s *Store) DeleteItem(ctx context.Context, id uuid.UUID) (*Item, []string, error) {
+	type deleted struct {
+		item     *Item
+		blobKeys []string
+	}
+	result, err := withRetry(ctx, false, func() (deleted, error) {
+		item, blobKeys, err := s.deleteItem(ctx, id)
+		return deleted{item, blobKeys}, err
+	})
+	return result.item, result.blobKeys, err
+}
+
+func (s *Store) deleteItem(ctx context.Context, id uuid.UUID) (*Item, []string, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var status ItemStatus
+	err = tx.GetContext(ctx, &status, lockItemStatusQuery, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("item not found")
+		}
+		return nil, nil, err
+	}
+
+	blobKeys := []string{}
+	err = tx.SelectContext(ctx, &blobKeys, listAttachmentKeysByItemsQuery, uuidStrings([]uuid.UUID{id}))
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var item Item
-	err := s.db.GetContext(ctx, &item, deleteItemQuery, id)
+	err = tx.GetContext(ctx, &item, deleteItemQuery, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := enqueueOutbox(ctx, tx, EventItemDeleted, &item); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return &item, blobKeys, nil
+}
+
+// TransitionItem moves an item to a new status and records the transition.
+// It returns a *TransitionError when the state machine does not allow the move.
+func (s *Store) TransitionItem(ctx context.Context, id uuid.UUID, transition Transition) (*Item, error) {
+	return withRetry(ctx, false, func() (*Item, error) {
+		return s.transitionItem(ctx, id, transition)
+	})
+}
+
+func (s *Store) transitionItem(ctx context.Context, id uuid.UUID, transition Transition) (*Item, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the row so concurrent transitions are applied one at a time
+	var current ItemStatus
+	err = tx.GetContext(ctx, &current, lockItemStatusQuery, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("item not found")
 		}
 		return nil, err
 	}
+
+	target, err := transition.Target(id, current)
+	if err != nil {
+		return nil, err
+	}
+
+	var item Item
+	err = tx.GetContext(ctx, &item, setItemStatusQuery, id, target)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, insertItemStatusEventQuery, id, transition, current, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := enqueueOutbox(ctx, tx, EventItemUpdated, &item); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &item, nil
 }
+
+// ListItemStatusEvents returns the status history of an item, oldest first
+func (s *Store) ListItemStatusEvents(ctx context.Context, id uuid.UUID) ([]ItemStatusEvent, error) {
+	return withRetry(ctx, true, func() ([]ItemStatusEvent, error) {
+		return s.listItemStatusEvents(ctx, id)
+	})
+}
+
+func (s *Store) listItemStatusEvents(ctx context.Context, id uuid.UUID) ([]ItemStatusEvent, error) {
+	if _, err := s.getItem(ctx, id); err != nil {
+		return nil, err
+	}
+
+	events := []ItemStatusEvent{}
+	err := s.db.SelectContext(ctx, &events, listItemStatusEventsQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ListItemStatusEventsByItem returns the status history of several items at once, keyed by item id
+func (s *Store) ListItemStatusEventsByItem(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]ItemStatusEvent, error) {
+	return withRetry(ctx, true, func() (map[uuid.UUID][]ItemStatusEvent, error) {
+		return s.listItemStatusEventsByItem(ctx, ids)
+	})
+}
+
+func (s *Store) listItemStatusEventsByItem(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]ItemStatusEvent, error) {
+	var events []ItemStatusEvent
+	err := s.db.SelectContext(ctx, &events, listItemStatusEventsByItemsQuery, uuidStrings(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	byItem := make(map[uuid.UUID][]ItemStatusEvent, len(ids))
+	for _, event := range events {
+		byItem[event.ItemID] = append(byItem[event.ItemID], event)
+	}
+	return byItem, nil
+}
+
+// CreateItemLink links sourceID to req.TargetID. Dependency links (depends_on
+// and blocks) are rejected with a *LinkCycleError when they would close a cycle.
+func (s *Store) CreateItemLink(ctx context.Context, sourceID uuid.UUID, req CreateLinkRequest) (*ItemLink, error) {
+	return withRetry(ctx, false, func() (*ItemLink, error) {
+		return s.createItemLink(ctx, sourceID, req)
+	})
+}
+
+func (s *Store) createItemLink(ctx context.Context, sourceID uuid.UUID, req CreateLinkRequest) (*ItemLink, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var found int
+	err = tx.GetContext(ctx, &found, countItemsByIDQuery, []string{sourceID.String(), req.TargetID.String()})
+	if err != nil {
+		return nil, err
+	}
+	if found != 2 {
+		return nil, fmt.Errorf("item not found")
+	}
+
+	if req.Relation.IsDependency() {
+		if _, err := tx.ExecContext(ctx, lockDependencyGraphQuery); err != nil {
+			return nil, err
+		}
+
+		// Normalise to "dependent depends on dependency"; the link closes a
+		// cycle if the dependent is already reachable from the dependency
+		dependent, dependency := sourceID, req.TargetID
+		if req.Relation == RelationBlocks {
+			dependent, dependency = req.TargetID, sourceID
+		}
+
+		var cyclic bool
+		err = tx.GetContext(ctx, &cyclic, dependencyReachableQuery, dependency, dependent)
+		if err != nil {
+			return nil, err
+		}
+		if cyclic {
+			return nil, &LinkCycleError{SourceID: sourceID, TargetID: req.TargetID, Relation: req.Relation}
+		}
+	}
+
+	var link ItemLink
+	err = tx.GetContext(ctx, &link, createItemLinkQuery, sourceID, req.TargetID, req.Relation)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return nil, ErrLinkExists
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// DeleteItemLink removes a link that starts or ends at itemID
+func (s *Store) DeleteItemLink(ctx context.Context, itemID, linkID uuid.UUID) (*ItemLink, error) {
+	return withRetry(ctx, false, func() (*ItemLink, error) {
+		return s.deleteItemLink(ctx, itemID, linkID)
+	})
+}
+
+func (s *Store) deleteItemLink(ctx context.Context, itemID, linkID uuid.UUID) (*ItemLink, error) {
+	var link ItemLink
+	err := s.db.GetContext(ctx, &link, deleteItemLinkQuery, itemID, linkID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("link not found")
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListItemNeighbors returns every item directly linked to id, in either direction
+func (s *Store) ListItemNeighbors(ctx context.Context, id uuid.UUID) ([]ItemNeighbor, error) {
+	return withRetry(ctx, true, func() ([]ItemNeighbor, error) {
+		return s.listItemNeighbors(ctx, id)
+	})
+}
+
+func (s *Store) listItemNeighbors(ctx context.Context, id uuid.UUID) ([]ItemNeighbor, error) {
+	if _, err := s.getItem(ctx, id); err != nil {
+		return nil, err
+	}
+
+	neighbors := []ItemNeighbor{}
+	err := s.db.SelectContext(ctx, &neighbors, listItemNeighborsQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	return neighbors, nil
+}
+
+// ListItemNeighborsByItem returns the neighbors of several items at once, keyed by item id
+func (s *Store) ListItemNeighborsByItem(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]ItemNeighbor, error) {
+	return withRetry(ctx, true, func() (map[uuid.UUID][]ItemNeighbor, error) {
+		return s.listItemNeighborsByItem(ctx, ids)
+	})
+}
+
+func (s *Store) listItemNeighborsByItem(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]ItemNeighbor, error) {
+	var rows []struct {
+		OwnerID uuid.UUID `db:"owner_id"`
+		ItemNeighbor
+	}
+	err := s.db.SelectContext(ctx, &rows, listItemNeighborsByItemsQuery, uuidStrings(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	byItem := make(map[uuid.UUID][]ItemNeighbor, len(ids))
+	for _, row := range rows {
+		byItem[row.OwnerID] = append(byItem[row.OwnerID], row.ItemNeighbor)
+	}
+	return byItem, nil
+}
+
+// GetDependencyGraph walks dependency links from id up to maxDepth levels deep
+func (s *Store) GetDependencyGraph(ctx context.Context, id uuid.UUID, direction GraphDirection, maxDepth int) (*DependencyGraph, error) {
+	return withRetry(ctx, true, func() (*DependencyGraph, error) {
+		return s.getDependencyGraph(ctx, id, direction, maxDepth)
+	})
+}
+
+func (s *Store) getDependencyGraph(ctx context.Context, id uuid.UUID, direction GraphDirection, maxDepth int) (*DependencyGraph, error) {
+	if maxDepth <= 0 {
+		maxDepth = 10 // Default to 10 levels
+	}
+	if maxDepth > 50 {
+		maxDepth = 50 // Maximum 50 levels
+	}
+
+	root, err := s.getItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var query string
+	switch direction {
+	case GraphDependencies, "":
+		direction = GraphDependencies
+		query = fmt.Sprintf(dependencyGraphQuery, "dependent_id", "dependency_id")
+	case GraphDependents:
+		query = fmt.Sprintf(dependencyGraphQuery, "dependency_id", "dependent_id")
+	default:
+		return nil, fmt.Errorf("unknown graph direction %q", direction)
+	}
+
+	edges := []DependencyEdge{}
+	err = s.db.SelectContext(ctx, &edges, query, id, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{root.ID.String()}
+	seen := map[uuid.UUID]bool{root.ID: true}
+	for _, edge := range edges {
+		for _, nodeID := range []uuid.UUID{edge.From, edge.To} {
+			if !seen[nodeID] {
+				seen[nodeID] = true
+				ids = append(ids, nodeID.String())
+			}
+		}
+	}
+
+	nodes := []Item{}
+	err = s.db.SelectContext(ctx, &nodes, getItemsByIDQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DependencyGraph{
+		RootID:    root.ID,
+		Direction: direction,
+		MaxDepth:  maxDepth,
+		Nodes:     nodes,
+		Edges:     edges,
+	}, nil
+}
+
+// CreateAttachment records the metadata of an attachment whose blob has already been stored
+func (s *Store) CreateAttachment(ctx context.Context, a Attachment) (*Attachment, error) {
+	return withRetry(ctx, false, func() (*Attachment, error) {
+		return s.createAttachment(ctx, a)
+	})
+}
+
+func (s *Store) createAttachment(ctx context.Context, a Attachment) (*Attachment, error) {
+	var attachment Attachment
+	err := s.db.GetContext(ctx, &attachment, createAttachmentQuery,
+		a.ID, a.ItemID, a.Filename, a.ContentType, a.SizeBytes, a.SHA256, a.StorageKey)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			// The item was deleted while the blob was uploading
+			return nil, fmt.Errorf("item not found")
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// ListAttachments returns the attachments of an item, oldest first
+func (s *Store) ListAttachments(ctx context.Context, itemID uuid.UUID) ([]Attachment, error) {
+	return withRetry(ctx, true, func() ([]Attachment, error) {
+		return s.listAttachments(ctx, itemID)
+	})
+}
+
+func (s *Store) listAttachments(ctx context.Context, itemID uuid.UUID) ([]Attachment, error) {
+	if _, err := s.getItem(ctx, itemID); err != nil {
+		return nil, err
+	}
+
+	attachments := []Attachment{}
+	err := s.db.SelectContext(ctx, &attachments, listAttachmentsQuery, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// ListAttachmentsByItem returns the attachments of several items at once, keyed by item id
+func (s *Store) ListAttachmentsByItem(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]Attachment, error) {
+	return withRetry(ctx, true, func() (map[uuid.UUID][]Attachment, error) {
+		return s.listAttachmentsByItem(ctx, ids)
+	})
+}
+
+func (s *Store) listAttachmentsByItem(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]Attachment, error) {
+	var attachments []Attachment
+	err := s.db.SelectContext(ctx, &attachments, listAttachmentsByItemsQuery, uuidStrings(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	byItem := make(map[uuid.UUID][]Attachment, len(ids))
+	for _, attachment := range attachments {
+		byItem[attachment.ItemID] = append(byItem[attachment.ItemID], attachment)
+	}
+	return byItem, nil
+}
+
+// GetAttachment retrieves a single attachment of an item
+func (s *Store) GetAttachment(ctx context.Context, itemID, attachmentID uuid.UUID) (*Attachment, error) {
+	return withRetry(ctx, true, func() (*Attachment, error) {
+		return s.getAttachment(ctx, itemID, attachmentID)
+	})
+}
+
+func (s *Store) getAttachment(ctx context.Context, itemID, attachmentID uuid.UUID) (*Attachment, error) {
+	var attachment Attachment
+	err := s.db.GetContext(ctx, &attachment, getAttachmentQuery, itemID, attachmentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// DeleteAttachment removes an attachment's metadata and returns it so the caller can delete the blob
+func (s *Store) DeleteAttachment(ctx context.Context, itemID, attachmentID uuid.UUID) (*Attachment, error) {
+	return withRetry(ctx, false, func() (*Attachment, error) {
+		return s.deleteAttachment(ctx, itemID, attachmentID)
+	})
+}
+
+func (s *Store) deleteAttachment(ctx context.Context, itemID, attachmentID uuid.UUID) (*Attachment, error) {
+	var attachment Attachment
+	err := s.db.GetContext(ctx, &attachment, deleteAttachmentQuery, itemID, attachmentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetItemEventsByID loads the given item events, ordered by id
+func (s *Store) GetItemEventsByID(ctx context.Context, ids []int64) ([]ItemEvent, error) {
+	return withRetry(ctx, true, func() ([]ItemEvent, error) {
+		return s.getItemEventsByID(ctx, ids)
+	})
+}
+
+func (s *Store) getItemEventsByID(ctx context.Context, ids []int64) ([]ItemEvent, error) {
+	events := []ItemEvent{}
+	err := s.db.SelectContext(ctx, &events, getItemEventsByIDQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		if err := events[i].decodeItem(); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// ListItemEventsAfter returns up to limit events with an id greater than afterID
+func (s *Store) ListItemEventsAfter(ctx context.Context, afterID int64, filter ItemEventFilter, limit int) ([]ItemEvent, error) {
+	return withRetry(ctx, true, func() ([]ItemEvent, error) {
+		return s.listItemEventsAfter(ctx, afterID, filter, limit)
+	})
+}
+
+func (s *Store) listItemEventsAfter(ctx context.Context, afterID int64, filter ItemEventFilter, limit int) ([]ItemEvent, error) {
+	var tag *string
+	if filter.Tag != "" {
+		tag = &filter.Tag
+	}
+
+	events := []ItemEvent{}
+	err := s.db.SelectContext(ctx, &events, listItemEventsAfterQuery, afterID, filter.ItemID, tag, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		if err := events[i].decodeItem(); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// LatestItemEventID returns the id of the newest item event, or 0 when there are none
+func (s *Store) LatestItemEventID(ctx context.Context) (int64, error) {
+	return withRetry(ctx, true, func() (int64, error) {
+		return s.latestItemEventID(ctx)
+	})
+}
+
+func (s *Store) latestItemEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := s.db.GetContext(ctx, &id, latestItemEventIDQuery)
+	return id, err
+}
+
+// CreateWebhook stores a new webhook subscription
+func (s *Store) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (*WebhookSubscription, error) {
+	return withRetry(ctx, false, func() (*WebhookSubscription, error) {
+		return s.createWebhook(ctx, req)
+	})
+}
+
+func (s *Store) createWebhook(ctx context.Context, req CreateWebhookRequest) (*WebhookSubscription, error) {
+	if req.Secret == "" {
+		secret, err := GenerateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		req.Secret = secret
+	}
+
+	var webhook WebhookSubscription
+	err := s.db.GetContext(ctx, &webhook, createWebhookQuery, req.URL, req.Secret, req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetWebhook retrieves a webhook subscription by ID
+func (s *Store) GetWebhook(ctx context.Context, id uuid.UUID) (*WebhookSubscription, error) {
+	return withRetry(ctx, true, func() (*WebhookSubscription, error) {
+		return s.getWebhook(ctx, id)
+	})
+}
+
+func (s *Store) getWebhook(ctx context.Context, id uuid.UUID) (*WebhookSubscription, error) {
+	var webhook WebhookSubscription
+	err := s.db.GetContext(ctx, &webhook, getWebhookQuery, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks returns every webhook subscription
+func (s *Store) ListWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	return withRetry(ctx, true, func() ([]WebhookSubscription, error) {
+		return s.listWebhooks(ctx)
+	})
+}
+
+func (s *Store) listWebhooks(ctx context.Context) ([]WebhookSubscription, error) {
+	webhooks := []WebhookSubscription{}
+	err := s.db.SelectContext(ctx, &webhooks, listWebhooksQuery)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes a webhook subscription and its deliveries
+func (s *Store) DeleteWebhook(ctx context.Context, id uuid.UUID) (*WebhookSubscription, error) {
+	return withRetry(ctx, false, func() (*WebhookSubscription, error) {
+		return s.deleteWebhook(ctx, id)
+	})
+}
+
+func (s *Store) deleteWebhook(ctx context.Context, id uuid.UUID) (*WebhookSubscription, error) {
+	var webhook WebhookSubscription
+	err := s.db.GetContext(ctx, &webhook, deleteWebhookQuery, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// CreateTestDelivery queues a delivery that is not tied to an item event
+func (s *Store) CreateTestDelivery(ctx context.Context, subscriptionID uuid.UUID, payload []byte) (*WebhookDelivery, error) {
+	return withRetry(ctx, false, func() (*WebhookDelivery, error) {
+		return s.createTestDelivery(ctx, subscriptionID, payload)
+	})
+}
+
+func (s *Store) createTestDelivery(ctx context.Context, subscriptionID uuid.UUID, payload []byte) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := s.db.GetContext(ctx, &delivery, createTestDeliveryQuery, subscriptionID, WebhookEventTest, string(payload))
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = delivery.PayloadJSON
+	return &delivery, nil
+}
+
+// ClaimDueDeliveries leases up to limit due deliveries for the given duration
+func (s *Store) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]ClaimedDelivery, error) {
+	return withRetry(ctx, false, func() ([]ClaimedDelivery, error) {
+		return s.claimDueDeliveries(ctx, limit, lease)
+	})
+}
+
+func (s *Store) claimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]ClaimedDelivery, error) {
+	deliveries := []ClaimedDelivery{}
+	err := s.db.SelectContext(ctx, &deliveries, claimDeliveriesQuery, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = deliveries[i].PayloadJSON
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery leases a single pending delivery, e.g. to send it immediately
+func (s *Store) ClaimDelivery(ctx context.Context, id uuid.UUID, lease time.Duration) (*ClaimedDelivery, error) {
+	return withRetry(ctx, false, func() (*ClaimedDelivery, error) {
+		return s.claimDelivery(ctx, id, lease)
+	})
+}
+
+func (s *Store) claimDelivery(ctx context.Context, id uuid.UUID, lease time.Duration) (*ClaimedDelivery, error) {
+	var delivery ClaimedDelivery
+	err := s.db.GetContext(ctx, &delivery, claimDeliveryQuery, id, lease.Seconds())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("delivery not found")
+		}
+		return nil, err
+	}
+	delivery.Payload = delivery.PayloadJSON
+	return &delivery, nil
+}
+
+// CompleteDeliveryAttempt logs an attempt and updates the delivery in one transaction
+func (s *Store) CompleteDeliveryAttempt(ctx context.Context, result DeliveryResult) error {
+	_, err := withRetry(ctx, false, func() (struct{}, error) {
+		return struct{}{}, s.completeDeliveryAttempt(ctx, result)
+	})
+	return err
+}
+
+func (s *Store) completeDeliveryAttempt(ctx context.Context, result DeliveryResult) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, insertDeliveryAttemptQuery,
+		result.DeliveryID, result.Attempt, result.StatusCode, result.Error, result.Duration.Milliseconds())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, updateDeliveryResultQuery,
+		result.DeliveryID, result.Status, result.Attempt, result.NextAttemptAt, result.StatusCode, result.Error)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDelivery retrieves a delivery by ID
+func (s *Store) GetDelivery(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	return withRetry(ctx, true, func() (*WebhookDelivery, error) {
+		return s.getDelivery(ctx, id)
+	})
+}
+
+func (s *Store) getDelivery(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := s.db.GetContext(ctx, &delivery, getDeliveryQuery, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("delivery not found")
+		}
+		return nil, err
+	}
+	delivery.Payload = delivery.PayloadJSON
+	return &delivery, nil
+}
+
+// ListDeliveries returns a subscription's most recent deliveries
+func (s *Store) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, req ListDeliveriesRequest) ([]WebhookDelivery, error) {
+	return withRetry(ctx, true, func() ([]WebhookDelivery, error) {
+		return s.listDeliveries(ctx, subscriptionID, req)
+	})
+}
+
+func (s *Store) listDeliveries(ctx context.Context, subscriptionID uuid.UUID, req ListDeliveriesRequest) ([]WebhookDelivery, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20 // Default to 20 deliveries
+	}
+	if req.Limit > 100 {
+		req.Limit = 100 // Maximum 100 deliveries
+	}
+	var status *string
+	if req.Status != "" {
+		status = &req.Status
+	}
+
+	deliveries := []WebhookDelivery{}
+	err := s.db.SelectContext(ctx, &deliveries, listDeliveriesQuery, subscriptionID, status, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = deliveries[i].PayloadJSON
+	}
+	return deliveries, nil
+}
+
+// ListDeliveryAttempts returns the attempt log of a delivery
+func (s *Store) ListDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]WebhookDeliveryAttempt, error) {
+	return withRetry(ctx, true, func() ([]WebhookDeliveryAttempt, error) {
+		return s.listDeliveryAttempts(ctx, deliveryID)
+	})
+}
+
+func (s *Store) listDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]WebhookDeliveryAttempt, error) {
+	attempts := []WebhookDeliveryAttempt{}
+	err := s.db.SelectContext(ctx, &attempts, listDeliveryAttemptsQuery, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// Redeliver resets a delivery to pending with a fresh retry budget; its
+// attempt count carries on so attempt numbers stay unique
+func (s *Store) Redeliver(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	return withRetry(ctx, false, func() (*WebhookDelivery, error) {
+		return s.redeliver(ctx, id)
+	})
+}
+
+func (s *Store) redeliver(ctx context.Context, id uuid.UUID) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := s.db.GetContext(ctx, &delivery, redeliverQuery, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("delivery not found")
+		}
+		return nil, err
+	}
+	delivery.Payload = delivery.PayloadJSON
+	return &delivery, nil
+}
+
+// RelayOutbox leases up to limit publishable outbox messages for lease and
+// passes each to handle, then marks it published or schedules a retry after
+// retryDelay. The claim commits before anything is published, so a slow
+// publisher holds no row locks; outcomes are recorded in a second short
+// transaction. A crash mid-batch leaves the leases to expire and the messages
+// to be published again: delivery is at least once, and consumers
+// de-duplicate on the message ID.
+func (s *Store) RelayOutbox(ctx context.Context, limit int, lease time.Duration, retryDelay func(attempts int) time.Duration, handle OutboxHandler) (*OutboxBatch, error) {
+	// Each transaction is retried on its own, so a failed mark never
+	// publishes the batch again
+	messages, err := withRetry(ctx, false, func() ([]OutboxMessage, error) {
+		return s.claimOutbox(ctx, limit, lease)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]outboxOutcome, 0, len(messages))
+	for _, msg := range messages {
+		if err := json.Unmarshal(msg.ItemJSON, &msg.Item); err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, outboxOutcome{msg: msg, err: handle(ctx, msg)})
+	}
+
+	batch := &OutboxBatch{}
+	if len(outcomes) == 0 {
+		return batch, nil
+	}
+	_, err = withRetry(ctx, false, func() (struct{}, error) {
+		return struct{}{}, s.markOutbox(ctx, outcomes, retryDelay)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, o := range outcomes {
+		if o.err != nil {
+			batch.Failed++
+		} else {
+			batch.Published = append(batch.Published, o.msg)
+		}
+	}
+	return batch, nil
+}
+
+// outboxOutcome is the result of publishing one claimed message
+type outboxOutcome struct {
+	msg OutboxMessage
+	err error
+}
+
+func (s *Store) claimOutbox(ctx context.Context, limit int, lease time.Duration) ([]OutboxMessage, error) {
+	messages := []OutboxMessage{}
+	err := s.db.SelectContext(ctx, &messages, claimOutboxQuery, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (s *Store) markOutbox(ctx context.Context, outcomes []outboxOutcome, retryDelay func(attempts int) time.Duration) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, o := range outcomes {
+		if o.err != nil {
+			delay := retryDelay(o.msg.Attempts + 1)
+			_, err = tx.ExecContext(ctx, markOutboxFailedQuery, o.msg.ID, o.err.Error(), delay.Seconds())
+		} else {
+			_, err = tx.ExecContext(ctx, markOutboxPublishedQuery, o.msg.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetOutboxStats summarises the outbox backlog
+func (s *Store) GetOutboxStats(ctx context.Context) (*OutboxStats, error) {
+	return withRetry(ctx, true, func() (*OutboxStats, error) {
+		return s.getOutboxStats(ctx)
+	})
+}
+
+func (s *Store) getOutboxStats(ctx context.Context) (*OutboxStats, error) {
+	var stats OutboxStats
+	err := s.db.GetContext(ctx, &stats, outboxStatsQuery)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// PurgeOutbox deletes messages published more than retention ago
+func (s *Store) PurgeOutbox(ctx context.Context, retention time.Duration) (int64, error) {
+	return withRetry(ctx, false, func() (int64, error) {
+		return s.purgeOutbox(ctx, retention)
+	})
+}
+
+func (s *Store) purgeOutbox(ctx context.Context, retention time.Duration) (int64, error) {
+	result, err := s.db.ExecContext(ctx, purgeOutboxQuery, retention.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// EnqueueJob adds a job to the queue; call req.Validate first to apply defaults
+func (s *Store) EnqueueJob(ctx context.Context, req EnqueueJobRequest) (*Job, error) {
+	return withRetry(ctx, false, func() (*Job, error) {
+		return s.enqueueJob(ctx, req)
+	})
+}
+
+func (s *Store) enqueueJob(ctx context.Context, req EnqueueJobRequest) (*Job, error) {
+	var job Job
+	err := s.db.GetContext(ctx, &job, enqueueJobQuery,
+		req.Kind, string(req.Payload), req.Priority, req.RunAt, req.MaxAttempts, req.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	job.decodeJSON()
+	return &job, nil
+}
+
+// GetJob retrieves a job by ID
+func (s *Store) GetJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	return withRetry(ctx, true, func() (*Job, error) {
+		return s.getJob(ctx, id)
+	})
+}
+
+func (s *Store) getJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	var job Job
+	err := s.db.GetContext(ctx, &job, getJobQuery, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found")
+		}
+		return nil, err
+	}
+	job.decodeJSON()
+	return &job, nil
+}
+
+// ListJobs retrieves a paginated list of jobs, newest first
+func (s *Store) ListJobs(ctx context.Context, req ListJobsRequest) (*ListJobsResponse, error) {
+	return withRetry(ctx, true, func() (*ListJobsResponse, error) {
+		return s.listJobs(ctx, req)
+	})
+}
+
+func (s *Store) listJobs(ctx context.Context, req ListJobsRequest) (*ListJobsResponse, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20 // Default to 20 jobs per page
+	}
+	if req.Limit > 100 {
+		req.Limit = 100 // Maximum 100 jobs per page
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	var status, kind *string
+	if req.Status != "" {
+		status = &req.Status
+	}
+	if req.Kind != "" {
+		kind = &req.Kind
+	}
+
+	var total int
+	err := s.db.GetContext(ctx, &total, countJobsQuery, status, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []Job{}
+	err = s.db.SelectContext(ctx, &jobs, listJobsQuery, status, kind, req.Limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		jobs[i].decodeJSON()
+	}
+
+	return &ListJobsResponse{
+		Jobs:   jobs,
+		Total:  total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}, nil
+}
+
+// ClaimJob marks the next ready job of one of kinds as running under
+// workerID. It returns nil when no job is ready.
+func (s *Store) ClaimJob(ctx context.Context, workerID string, kinds []string) (*Job, error) {
+	return withRetry(ctx, false, func() (*Job, error) {
+		return s.claimJob(ctx, workerID, kinds)
+	})
+}
+
+func (s *Store) claimJob(ctx context.Context, workerID string, kinds []string) (*Job, error) {
+	var job Job
+	err := s.db.GetContext(ctx, &job, claimJobQuery, workerID, kinds)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	job.decodeJSON()
+	return &job, nil
+}
+
+// HeartbeatJob records that workerID is still running the job. It returns
+// false when the job is no longer held by the worker, e.g. it was cancelled
+// or reaped, and the worker should stop.
+func (s *Store) HeartbeatJob(ctx context.Context, id uuid.UUID, workerID string) (bool, error) {
+	// Repeating the update gives the same answer
+	return withRetry(ctx, true, func() (bool, error) {
+		return s.heartbeatJob(ctx, id, workerID)
+	})
+}
+
+func (s *Store) heartbeatJob(ctx context.Context, id uuid.UUID, workerID string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, heartbeatJobQuery, id, workerID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// CompleteJob marks a running job as succeeded with an optional JSON result
+func (s *Store) CompleteJob(ctx context.Context, id uuid.UUID, workerID string, result json.RawMessage) error {
+	_, err := withRetry(ctx, false, func() (struct{}, error) {
+		return struct{}{}, s.completeJob(ctx, id, workerID, result)
+	})
+	return err
+}
+
+func (s *Store) completeJob(ctx context.Context, id uuid.UUID, workerID string, result json.RawMessage) error {
+	var value *string
+	if len(result) > 0 {
+		str := string(result)
+		value = &str
+	}
+	_, err := s.db.ExecContext(ctx, completeJobQuery, id, workerID, value)
+	return err
+}
+
+// FailJob records a failed attempt, retrying after retryAfter unless the job
+// has used up its attempts
+func (s *Store) FailJob(ctx context.Context, id uuid.UUID, workerID string, jobErr error, retryAfter time.Duration) error {
+	_, err := withRetry(ctx, false, func() (struct{}, error) {
+		return struct{}{}, s.failJob(ctx, id, workerID, jobErr, retryAfter)
+	})
+	return err
+}
+
+func (s *Store) failJob(ctx context.Context, id uuid.UUID, workerID string, jobErr error, retryAfter time.Duration) error {
+	_, err := s.db.ExecContext(ctx, failJobQuery, id, workerID, jobErr.Error(), retryAfter.Seconds())
+	return err
+}
+
+// ReleaseJob returns an interrupted job to the queue without counting the attempt
+func (s *Store) ReleaseJob(ctx context.Context, id uuid.UUID, workerID string) error {
+	_, err := withRetry(ctx, false, func() (struct{}, error) {
+		return struct{}{}, s.releaseJob(ctx, id, workerID)
+	})
+	return err
+}
+
+func (s *Store) releaseJob(ctx context.Context, id uuid.UUID, workerID string) error {
+	_, err := s.db.ExecContext(ctx, releaseJobQuery, id, workerID)
+	return err
+}
+
+// CancelJob cancels a queued or running job. A running job's worker
+// notices on its next heartbeat.
+func (s *Store) CancelJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	return withRetry(ctx, false, func() (*Job, error) {
+		return s.cancelJob(ctx, id)
+	})
+}
+
+func (s *Store) cancelJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	var job Job
+	err := s.db.GetContext(ctx, &job, cancelJobQuery, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, getErr := s.getJob(ctx, id); getErr != nil {
+				return nil, getErr
+			}
+			return nil, ErrJobFinished
+		}
+		return nil, err
+	}
+	job.decodeJSON()
+	return &job, nil
+}
+
+// ReapStaleJobs requeues running jobs that have not heartbeated within staleAfter
+func (s *Store) ReapStaleJobs(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	return withRetry(ctx, false, func() (int64, error) {
+		return s.reapStaleJobs(ctx, staleAfter)
+	})
+}
+
+func (s *Store) reapStaleJobs(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	result, err := s.db.ExecContext(ctx, reapJobsQuery, staleAfter.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListRetentionCandidates returns the number of items a retention rule
+// selects and up to limit of them, least recently updated first
+func (s *Store) ListRetentionCandidates(ctx context.Context, sel RetentionSelector, limit int) ([]Item, int, error) {
+	type candidates struct {
+		items []Item
+		total int
+	}
+	result, err := withRetry(ctx, true, func() (candidates, error) {
+		items, total, err := s.listRetentionCandidates(ctx, sel, limit)
+		return candidates{items, total}, err
+	})
+	return result.items, result.total, err
+}
+
+func (s *Store) listRetentionCandidates(ctx context.Context, sel RetentionSelector, limit int) ([]Item, int, error) {
+	var total int
+	err := s.db.GetContext(ctx, &total, countRetentionCandidatesQuery, sel.Statuses, sel.Cutoff)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := []Item{}
+	err = s.db.SelectContext(ctx, &items, retentionCandidatesQuery, sel.Statuses, sel.Cutoff, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// ArchiveRetentionBatch archives up to limit selected items, recording a
+// status event and an outbox message for each
+func (s *Store) ArchiveRetentionBatch(ctx context.Context, sel RetentionSelector, limit int) ([]Item, error) {
+	return withRetry(ctx, false, func() ([]Item, error) {
+		return s.archiveRetentionBatch(ctx, sel, limit)
+	})
+}
+
+func (s *Store) archiveRetentionBatch(ctx context.Context, sel RetentionSelector, limit int) ([]Item, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rows []struct {
+		Item
+		PreviousStatus ItemStatus `db:"previous_status"`
+	}
+	err = tx.SelectContext(ctx, &rows, archiveRetentionBatchQuery, sel.Statuses, sel.Cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(rows))
+	for _, row := range rows {
+		_, err = tx.ExecContext(ctx, insertItemStatusEventQuery, row.ID, TransitionArchive, row.PreviousStatus, StatusArchived)
+		if err != nil {
+			return nil, err
+		}
+		if err := enqueueOutbox(ctx, tx, EventItemUpdated, &row.Item); err != nil {
+			return nil, err
+		}
+		items = append(items, row.Item)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PurgeRetentionBatch deletes up to limit selected items and returns them
+// together with the blob keys of their attachments, which the caller must
+// delete from the blob store
+func (s *Store) PurgeRetentionBatch(ctx context.Context, sel RetentionSelector, limit int) ([]Item, []string, error) {
+	type purged struct {
+		items    []Item
+		blobKeys []string
+	}
+	result, err := withRetry(ctx, false, func() (purged, error) {
+		items, blobKeys, err := s.purgeRetentionBatch(ctx, sel, limit)
+		return purged{items, blobKeys}, err
+	})
+	return result.items, result.blobKeys, err
+}
+
+func (s *Store) purgeRetentionBatch(ctx context.Context, sel RetentionSelector, limit int) ([]Item, []string, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var ids []string
+	err = tx.SelectContext(ctx, &ids, lockRetentionBatchQuery, sel.Statuses, sel.Cutoff, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+
+	blobKeys := []string{}
+	err = tx.SelectContext(ctx, &blobKeys, listAttachmentKeysByItemsQuery, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := []Item{}
+	err = tx.SelectContext(ctx, &items, deleteItemsByIDQuery, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range items {
+		if err := enqueueOutbox(ctx, tx, EventItemDeleted, &items[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return items, blobKeys, nil
+}
+
+// TryRetentionLock takes the advisory lock for a retention rule on a
+// dedicated connection. It returns ok=false when another replica holds it;
+// otherwise release must be called to unlock and return the connection.
+func (s *Store) TryRetentionLock(ctx context.Context, rule string) (release func(), ok bool, err error) {
+	type lock struct {
+		release func()
+		ok      bool
+	}
+	// A lock taken on a connection that then fails is released with it
+	result, err := withRetry(ctx, true, func() (lock, error) {
+		release, ok, err := s.tryRetentionLock(ctx, rule)
+		return lock{release, ok}, err
+	})
+	return result.release, result.ok, err
+}
+
+func (s *Store) tryRetentionLock(ctx context.Context, rule string) (release func(), ok bool, err error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := conn.GetContext(ctx, &ok, tryRetentionLockQuery, rule); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release = func() {
+		// Unlock even if the run's context was cancelled; closing the
+		// connection would also release the lock, but returns it to the pool
+		var unlocked bool
+		_ = conn.GetContext(context.WithoutCancel(ctx), &unlocked, releaseRetentionLockQuery, rule)
+		conn.Close()
+	}
+	return release, true, nil
+}
+
+// CreateRetentionRun records the start of a retention run
+func (s *Store) CreateRetentionRun(ctx context.Context, rule string, action RetentionAction, trigger string, cutoff time.Time) (*RetentionRun, error) {
+	return withRetry(ctx, false, func() (*RetentionRun, error) {
+		return s.createRetentionRun(ctx, rule, action, trigger, cutoff)
+	})
+}
+
+func (s *Store) createRetentionRun(ctx context.Context, rule string, action RetentionAction, trigger string, cutoff time.Time) (*RetentionRun, error) {
+	var run RetentionRun
+	err := s.db.GetContext(ctx, &run, createRetentionRunQuery, rule, action, trigger, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// FinishRetentionRun records the outcome of a retention run
+func (s *Store) FinishRetentionRun(ctx context.Context, id uuid.UUID, affected []string, runErr error) (*RetentionRun, error) {
+	// Setting the outcome again leaves the run as it was
+	return withRetry(ctx, true, func() (*RetentionRun, error) {
+		return s.finishRetentionRun(ctx, id, affected, runErr)
+	})
+}
+
+func (s *Store) finishRetentionRun(ctx context.Context, id uuid.UUID, affected []string, runErr error) (*RetentionRun, error) {
+	status := RetentionRunSucceeded
+	var errMsg *string
+	if runErr != nil {
+		status = RetentionRunFailed
+		msg := runErr.Error()
+		errMsg = &msg
+	}
+
+	var run RetentionRun
+	err := s.db.GetContext(ctx, &run, finishRetentionRunQuery, id, status, len(affected), StringList(affected), errMsg)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRetentionRuns returns recent retention runs, newest first
+func (s *Store) ListRetentionRuns(ctx context.Context, req ListRetentionRunsRequest) ([]RetentionRun, error) {
+	return withRetry(ctx, true, func() ([]RetentionRun, error) {
+		return s.listRetentionRuns(ctx, req)
+	})
+}
+
+func (s *Store) listRetentionRuns(ctx context.Context, req ListRetentionRunsRequest) ([]RetentionRun, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20 // Default to 20 runs
+	}
+	if req.Limit > 100 {
+		req.Limit = 100 // Maximum 100 runs
+	}
+	var rule *string
+	if req.Rule != "" {
+		rule = &req.Rule
+	}
+
+	runs := []RetentionRun{}
+	err := s.db.SelectContext(ctx, &runs, listRetentionRunsQuery, rule, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// TakeRateLimitToken refills the shared bucket for key and takes one token if
+// one is available. It returns whether the token was taken and how many remain.
+func (s *Store) TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (bool, float64, error) {
+	type token struct {
+		allowed   bool
+		remaining float64
+	}
+	result, err := withRetry(ctx, false, func() (token, error) {
+		allowed, remaining, err := s.takeRateLimitToken(ctx, key, rate, burst)
+		return token{allowed, remaining}, err
+	})
+	return result.allowed, result.remaining, err
+}
+
+func (s *Store) takeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (bool, float64, error) {
+	var result struct {
+		Allowed   bool    `db:"allowed"`
+		Remaining float64 `db:"remaining"`
+	}
+	err := s.db.GetContext(ctx, &result, takeRateLimitTokenQuery, key, rate, float64(burst))
+	if err != nil {
+		return false, 0, err
+	}
+	return result.Allowed, result.Remaining, nil
+}
+
+// PurgeRateLimitBuckets deletes buckets untouched for longer than idle and returns how many were removed
+func (s *Store) PurgeRateLimitBuckets(ctx context.Context, idle time.Duration) (int64, error) {
+	return withRetry(ctx, false, func() (int64, error) {
+		return s.purgeRateLimitBuckets(ctx, idle)
+	})
+}
+
+func (s *Store) purgeRateLimitBuckets(ctx context.Context, idle time.Duration) (int64, error) {
+	result, err := s.db.ExecContext(ctx, purgeRateLimitBucketsQuery, idle.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// buildListFilters turns the filters in a list request into a WHERE clause and its arguments
+func buildListFilters(req ListItemsRequest) (string, []any, error) {
+	conditions, args, err := listConditions(req.Status, req.MetadataFilters)
+	if err != nil {
+		return "", nil, err
+	}
+	return whereClause(conditions), args, nil
+}
+
+// buildPageFilters is buildListFilters for a page request, adding the search
+// text and the cursor position
+func buildPageFilters(req ItemPageRequest) (string, []any, error) {
+	conditions, args, err := listConditions(req.Status, req.MetadataFilters)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if req.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(req.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%[1]d OR description ILIKE $%[1]d)", len(args)))
+	}
+	if req.After != nil {
+		args = append(args, req.After.CreatedAt, req.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	return whereClause(conditions), args, nil
+}
+
+// likeEscaper escapes the LIKE wildcards so search text matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func listConditions(statuses []string, metadataFilters []MetadataFilter) ([]string, []any, error) {
+	var conditions []string
+	var args []any
+
+	if len(statuses) > 0 {
+		args = append(args, statuses)
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d::text[])", len(args)))
+	}
+
+	for _, filter := range metadataFilters {
+		condition, arg, err := filter.sqlCondition(len(args) + 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	return conditions, args, nil
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// uuidStrings formats ids for a $n::uuid[] parameter
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return strs
+}
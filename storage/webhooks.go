@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types. Item events mirror ItemEventType with an "item." prefix.
+const (
+	WebhookEventItemCreated = "item.created"
+	WebhookEventItemUpdated = "item.updated"
+	WebhookEventItemDeleted = "item.deleted"
+	WebhookEventTest        = "webhook.test"
+)
+
+// WebhookEventTypes lists the event types a subscription may filter on
+var WebhookEventTypes = []string{WebhookEventItemCreated, WebhookEventItemUpdated, WebhookEventItemDeleted}
+
+// ValidWebhookEventType reports whether t can be used in a subscription filter
+func ValidWebhookEventType(t string) bool {
+	for _, known := range WebhookEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateWebhookSecret returns a random 32-byte secret, hex encoded
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Delivery statuses. Failed attempts stay pending until they run out of
+// retries, at which point the delivery is dead-lettered.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// StringList is a list of strings stored as a JSONB array
+type StringList []string
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		l = StringList{}
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (l *StringList) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", src)
+	}
+}
+
+// WebhookSubscription is an endpoint that receives signed event payloads.
+// Secret is never serialized; only CreatedWebhook carries it to a client.
+type WebhookSubscription struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	URL        string     `db:"url" json:"url"`
+	Secret     string     `db:"secret" json:"-"`
+	EventTypes StringList `db:"event_types" json:"event_types"`
+	Active     bool       `db:"active" json:"active"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// CreatedWebhook is the response to creating a subscription, the only one
+// that includes the signing secret
+type CreatedWebhook struct {
+	WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// CreateWebhookRequest represents the request payload for creating a webhook subscription
+type CreateWebhookRequest struct {
+	URL        string     `json:"url" binding:"required"`
+	EventTypes StringList `json:"event_types"`
+	// Secret signs payloads; one is generated when omitted
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery is one event queued for one subscription
+type WebhookDelivery struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	SubscriptionID uuid.UUID       `db:"subscription_id" json:"subscription_id"`
+	EventID        *int64          `db:"event_id" json:"event_id"`
+	EventType      string          `db:"event_type" json:"event_type"`
+	Payload        json.RawMessage `db:"-" json:"payload,omitempty"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	LastStatusCode *int            `db:"last_status_code" json:"last_status_code"`
+	LastError      *string         `db:"last_error" json:"last_error"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+
+	PayloadJSON []byte `db:"payload" json:"-"`
+}
+
+// ClaimedDelivery is a due delivery together with where and how to send it
+type ClaimedDelivery struct {
+	WebhookDelivery
+	// RetryBase is the attempt count when the delivery was last redelivered;
+	// the retry budget counts attempts after it
+	RetryBase int    `db:"retry_base"`
+	URL       string `db:"url"`
+	Secret    string `db:"secret"`
+}
+
+// WebhookDeliveryAttempt records one HTTP attempt of a delivery
+type WebhookDeliveryAttempt struct {
+	ID          int64     `db:"id" json:"id"`
+	DeliveryID  uuid.UUID `db:"delivery_id" json:"delivery_id"`
+	Attempt     int       `db:"attempt" json:"attempt"`
+	StatusCode  *int      `db:"status_code" json:"status_code"`
+	Error       *string   `db:"error" json:"error"`
+	DurationMS  int       `db:"duration_ms" json:"duration_ms"`
+	AttemptedAt time.Time `db:"attempted_at" json:"attempted_at"`
+}
+
+// ListDeliveriesRequest represents filters for listing a subscription's deliveries
+type ListDeliveriesRequest struct {
+	Status string `form:"status" json:"status"`
+	Limit  int    `form:"limit" json:"limit"`
+}
+
+// DeliveryResult is the outcome of one delivery attempt, recorded by CompleteDeliveryAttempt
+type DeliveryResult struct {
+	DeliveryID uuid.UUID
+	Attempt    int
+	StatusCode *int
+	Error      *string
+	Duration   time.Duration
+	// Status is the delivery's new status: succeeded, pending (retry) or dead
+	Status        string
+	NextAttemptAt time.Time
+}
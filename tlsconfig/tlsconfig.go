@@ -0,0 +1,251 @@
+// Package tlsconfig builds the server's TLS configuration: a certificate
+// that is reloaded when its files change on disk, and optional client
+// certificate verification for mutual TLS.
+//
+// Certificates are watched by polling their modification times, so a
+// renewal written by cert-manager, certbot or a mounted secret is picked up
+// without a restart. Connections already established keep the certificate
+// they were handshaken with.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval is how often certificate files are checked for changes
+const DefaultReloadInterval = 30 * time.Second
+
+// ClientAuth selects how client certificates are treated
+type ClientAuth string
+
+const (
+	// ClientAuthNone never asks for a client certificate
+	ClientAuthNone ClientAuth = "none"
+	// ClientAuthRequest verifies a client certificate when one is sent
+	ClientAuthRequest ClientAuth = "request"
+	// ClientAuthRequire refuses the handshake without a verified client certificate
+	ClientAuthRequire ClientAuth = "require"
+)
+
+// ParseClientAuth reads a client auth mode, with "" meaning ClientAuthNone
+func ParseClientAuth(s string) (ClientAuth, error) {
+	switch ClientAuth(s) {
+	case "", ClientAuthNone:
+		return ClientAuthNone, nil
+	case ClientAuthRequest, ClientAuthRequire:
+		return ClientAuth(s), nil
+	default:
+		return "", fmt.Errorf("invalid client auth %q (expected none, request or require)", s)
+	}
+}
+
+func (m ClientAuth) tlsType() tls.ClientAuthType {
+	switch m {
+	case ClientAuthRequest:
+		return tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
+
+// Reloader serves a certificate and key pair from disk, replacing it when
+// either file changes. It is safe for concurrent use.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewReloader loads the pair from certFile and keyFile
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload reads the pair again if either file changed since it was last
+// loaded, and reports whether it did. On error the current certificate is
+// kept, so a half-written renewal never takes the listener down.
+func (r *Reloader) Reload() (bool, error) {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+	return true, nil
+}
+
+// NotAfter returns when the current certificate expires
+func (r *Reloader) NotAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cert == nil || r.cert.Leaf == nil {
+		return time.Time{}
+	}
+	return r.cert.Leaf.NotAfter
+}
+
+// Run checks the files every interval until ctx is cancelled
+func (r *Reloader) Run(ctx context.Context, logger *slog.Logger, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				logger.Error("Failed to reload TLS certificate", "cert_file", r.certFile, "error", err)
+				continue
+			}
+			if reloaded {
+				logger.Info("Reloaded TLS certificate", "cert_file", r.certFile, "not_after", r.NotAfter())
+			}
+		}
+	}
+}
+
+// latestModTime returns the newer of the two files' modification times
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("stat TLS certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// LoadCertPool reads PEM certificates from file into a pool
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+// LoadIdentities reads a JSON object mapping client certificate subjects to
+// identities, for example {"CN=ci,O=Example": "ci-bot"}. Keys are either a
+// full subject in RFC 2253 form or just "CN=<common name>".
+func LoadIdentities(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var identities map[string]string
+	if err := json.Unmarshal(data, &identities); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+	for subject, identity := range identities {
+		if identity == "" {
+			return nil, fmt.Errorf("%s: subject %q maps to an empty identity", file, subject)
+		}
+	}
+	return identities, nil
+}
+
+// Config holds what Server needs to build a tls.Config
+type Config struct {
+	// Certificate serves the server certificate
+	Certificate *Reloader
+
+	// ClientCAs verifies client certificates; required unless ClientAuth is none
+	ClientCAs *x509.CertPool
+
+	// ClientAuth selects whether client certificates are asked for
+	ClientAuth ClientAuth
+}
+
+// Server returns a TLS 1.2+ server configuration for c
+func Server(c Config) (*tls.Config, error) {
+	if c.Certificate == nil {
+		return nil, errors.New("no server certificate")
+	}
+	if c.ClientAuth != ClientAuthNone && c.ClientAuth != "" && c.ClientCAs == nil {
+		return nil, fmt.Errorf("client auth %q needs a client CA", c.ClientAuth)
+	}
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.Certificate.GetCertificate,
+		ClientCAs:      c.ClientCAs,
+		ClientAuth:     c.ClientAuth.tlsType(),
+	}, nil
+}
+
+// Client returns a client configuration trusting caFile (the system roots
+// when empty) and presenting the certificate in certFile and keyFile when
+// they are set
+func Client(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := LoadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("a client certificate needs both a certificate and a key file")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
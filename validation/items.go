@@ -0,0 +1,50 @@
+package validation
+
+import "github.com/joel-thompson/my-go-service/storage"
+
+// ItemName checks and normalises an item name
+func (v *Validator) ItemName(name string) (string, error) {
+	var errs Errors
+	name = v.itemName(&errs, name)
+	return name, errs.Err()
+}
+
+// ItemDescription checks and normalises an item description
+func (v *Validator) ItemDescription(description string) (string, error) {
+	var errs Errors
+	description = v.itemDescription(&errs, description)
+	return description, errs.Err()
+}
+
+// CreateItem normalises req in place and returns Errors listing every invalid field
+func (v *Validator) CreateItem(req *storage.CreateItemRequest) error {
+	var errs Errors
+	req.Name = v.itemName(&errs, req.Name)
+	if req.Description != nil {
+		description := v.itemDescription(&errs, *req.Description)
+		req.Description = &description
+	}
+	return errs.Err()
+}
+
+// UpdateItem normalises the fields req sets and returns Errors listing every invalid field
+func (v *Validator) UpdateItem(req *storage.UpdateItemRequest) error {
+	var errs Errors
+	if req.Name != nil {
+		name := v.itemName(&errs, *req.Name)
+		req.Name = &name
+	}
+	if req.Description != nil {
+		description := v.itemDescription(&errs, *req.Description)
+		req.Description = &description
+	}
+	return errs.Err()
+}
+
+func (v *Validator) itemName(errs *Errors, name string) string {
+	return text(errs, "name", name, textRule{required: true, maxLength: v.limits.NameMaxLength})
+}
+
+func (v *Validator) itemDescription(errs *Errors, description string) string {
+	return text(errs, "description", description, textRule{maxLength: v.limits.DescriptionMaxLength, multiline: true})
+}
@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// textRule describes how one text field is checked
+type textRule struct {
+	required  bool
+	maxLength int
+	// multiline allows tabs and line breaks, which are otherwise control characters
+	multiline bool
+}
+
+// text normalises value to NFC with surrounding whitespace trimmed, then
+// checks it against rule. Length is counted in characters after
+// normalisation, the way Postgres counts VARCHAR lengths.
+func text(errs *Errors, field, value string, rule textRule) string {
+	if !utf8.ValidString(value) {
+		errs.Add(field, RuleEncoding, "must be valid UTF-8")
+		return value
+	}
+
+	value = strings.TrimSpace(norm.NFC.String(value))
+
+	if value == "" {
+		if rule.required {
+			errs.Add(field, RuleRequired, "must not be empty or only whitespace")
+		}
+		return value
+	}
+
+	if r, ok := forbiddenRune(value, rule.multiline); ok {
+		errs.Add(field, RuleControlChar, "must not contain control characters (found %U)", r)
+	}
+
+	if n := utf8.RuneCountInString(value); rule.maxLength > 0 && n > rule.maxLength {
+		errs.Add(field, RuleMaxLength, "must be at most %d characters (got %d)", rule.maxLength, n)
+	}
+
+	return value
+}
+
+// forbiddenRune returns the first control character in s. Bidirectional
+// overrides count too, since they can disguise what a name says.
+func forbiddenRune(s string, multiline bool) (rune, bool) {
+	for _, r := range s {
+		if multiline && (r == '\n' || r == '\r' || r == '\t') {
+			continue
+		}
+		if unicode.IsControl(r) || isBidiControl(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+func isBidiControl(r rune) bool {
+	return (r >= '\u202A' && r <= '\u202E') || (r >= '\u2066' && r <= '\u2069')
+}
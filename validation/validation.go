@@ -0,0 +1,111 @@
+// Package validation checks and normalises request bodies before they reach
+// the database. The server and the CLI share it so both apply the same rules.
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule names reported in FieldError.Rule
+const (
+	RuleRequired    = "required"
+	RuleMaxLength   = "max_length"
+	RuleControlChar = "control_characters"
+	RuleEncoding    = "encoding"
+	RuleType        = "type"
+	RuleInvalid     = "invalid"
+)
+
+// FieldError describes why one field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Errors collects every field error in a request so clients can fix them all at once
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add records a field error
+func (e *Errors) Add(field, rule, format string, args ...any) {
+	*e = append(*e, FieldError{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err returns the errors as an error, or nil when there are none
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Limits are the configurable bounds on item fields
+type Limits struct {
+	// NameMaxLength caps item names in characters; the column is VARCHAR(255)
+	NameMaxLength int
+	// DescriptionMaxLength caps item descriptions in characters
+	DescriptionMaxLength int
+}
+
+// Default limits, matching the items table
+const (
+	DefaultNameMaxLength        = 255
+	DefaultDescriptionMaxLength = 10000
+)
+
+// DefaultLimits returns the limits used when none are configured
+func DefaultLimits() Limits {
+	return Limits{
+		NameMaxLength:        DefaultNameMaxLength,
+		DescriptionMaxLength: DefaultDescriptionMaxLength,
+	}
+}
+
+// Validate reports limits the database could not store
+func (l Limits) Validate() error {
+	if l.NameMaxLength < 1 || l.NameMaxLength > DefaultNameMaxLength {
+		return fmt.Errorf("name max length must be between 1 and %d, got %d", DefaultNameMaxLength, l.NameMaxLength)
+	}
+	if l.DescriptionMaxLength < 1 {
+		return fmt.Errorf("description max length must be positive, got %d", l.DescriptionMaxLength)
+	}
+	return nil
+}
+
+// Validator applies the rules for a set of limits
+type Validator struct {
+	limits Limits
+}
+
+// New creates a validator; zero limits fall back to the defaults
+func New(limits Limits) *Validator {
+	defaults := DefaultLimits()
+	if limits.NameMaxLength <= 0 {
+		limits.NameMaxLength = defaults.NameMaxLength
+	}
+	if limits.DescriptionMaxLength <= 0 {
+		limits.DescriptionMaxLength = defaults.DescriptionMaxLength
+	}
+	return &Validator{limits: limits}
+}
+
+// Limits returns the limits the validator enforces
+func (v *Validator) Limits() Limits {
+	return v.limits
+}
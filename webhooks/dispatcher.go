@@ -0,0 +1,249 @@
+// Package webhooks delivers item events to subscribed HTTP endpoints.
+//
+// Deliveries are queued in the webhook_deliveries table by a database trigger
+// in the same transaction as the item change. The dispatcher polls for due
+// deliveries, leases them with SKIP LOCKED so several server instances can
+// share the queue, signs and POSTs each payload, and records every attempt.
+// Failed deliveries are retried with exponential backoff until they run out of
+// attempts, at which point they are dead-lettered. A redelivery grants a fresh
+// budget of attempts while attempt numbers keep counting up.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const (
+	// baseRetryDelay is the wait before the first retry; it doubles per attempt
+	baseRetryDelay = 10 * time.Second
+	// maxRetryDelay caps the wait between attempts
+	maxRetryDelay = time.Hour
+	// maxErrorBody is how much of a failed response body is kept in the attempt log
+	maxErrorBody = 512
+)
+
+// Config holds dispatcher settings
+type Config struct {
+	// MaxAttempts is how many times a delivery is tried before it is dead-lettered
+	MaxAttempts int
+	// Timeout bounds a single HTTP attempt
+	Timeout time.Duration
+	// PollInterval is how often the queue is checked when it was last empty
+	PollInterval time.Duration
+	// Workers is how many deliveries are sent concurrently
+	Workers int
+}
+
+// Store is the subset of storage the dispatcher uses to lease deliveries and
+// record attempts. *storage.Store implements it.
+type Store interface {
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]storage.ClaimedDelivery, error)
+	ClaimDelivery(ctx context.Context, id uuid.UUID, lease time.Duration) (*storage.ClaimedDelivery, error)
+	CompleteDeliveryAttempt(ctx context.Context, result storage.DeliveryResult) error
+	GetDelivery(ctx context.Context, id uuid.UUID) (*storage.WebhookDelivery, error)
+}
+
+// Dispatcher sends queued webhook deliveries
+type Dispatcher struct {
+	logger *slog.Logger
+	store  Store
+	client *http.Client
+	config Config
+}
+
+// NewDispatcher creates a dispatcher; call Run to start sending
+func NewDispatcher(logger *slog.Logger, store Store, config Config) *Dispatcher {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+
+	return &Dispatcher{
+		logger: logger,
+		store:  store,
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}
+}
+
+// lease is how long a claimed delivery is hidden from other dispatchers
+func (d *Dispatcher) lease() time.Duration {
+	return d.config.Timeout + 30*time.Second
+}
+
+// Run sends due deliveries until ctx is cancelled. Attempts already in
+// flight are allowed to finish so their outcome is recorded.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.logger.Info("Webhook dispatcher started", "workers", d.config.Workers)
+	defer d.logger.Info("Webhook dispatcher stopped")
+
+	for {
+		claimed, err := d.store.ClaimDueDeliveries(ctx, d.config.Workers, d.lease())
+		if err != nil && ctx.Err() == nil {
+			d.logger.Error("Failed to claim webhook deliveries", "error", err)
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range claimed {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := d.attempt(context.WithoutCancel(ctx), delivery); err != nil {
+					d.logger.Error("Failed to record webhook attempt",
+						"delivery_id", delivery.ID,
+						"error", err,
+					)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// A full batch suggests more work is waiting, so only sleep when the queue ran dry
+		if len(claimed) == d.config.Workers && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.config.PollInterval):
+		}
+	}
+}
+
+// Deliver sends a pending delivery immediately, bypassing the poll loop,
+// and returns its updated state
+func (d *Dispatcher) Deliver(ctx context.Context, id uuid.UUID) (*storage.WebhookDelivery, error) {
+	claimed, err := d.store.ClaimDelivery(ctx, id, d.lease())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.attempt(ctx, *claimed); err != nil {
+		return nil, err
+	}
+	return d.store.GetDelivery(ctx, id)
+}
+
+// attempt sends one delivery and records the outcome
+func (d *Dispatcher) attempt(ctx context.Context, delivery storage.ClaimedDelivery) (*storage.DeliveryResult, error) {
+	result := storage.DeliveryResult{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+	}
+
+	start := time.Now()
+	statusCode, sendErr := d.send(ctx, delivery)
+	result.Duration = time.Since(start)
+	if statusCode != 0 {
+		result.StatusCode = &statusCode
+	}
+
+	switch {
+	case sendErr == nil:
+		result.Status = storage.DeliverySucceeded
+		result.NextAttemptAt = time.Now()
+	case result.Attempt-delivery.RetryBase >= d.config.MaxAttempts:
+		msg := sendErr.Error()
+		result.Error = &msg
+		result.Status = storage.DeliveryDead
+		result.NextAttemptAt = time.Now()
+	default:
+		msg := sendErr.Error()
+		result.Error = &msg
+		result.Status = storage.DeliveryPending
+		// A redelivery starts a fresh schedule, as it does a fresh budget
+		result.NextAttemptAt = time.Now().Add(Backoff(result.Attempt - delivery.RetryBase))
+	}
+
+	logger := d.logger.With(
+		"delivery_id", delivery.ID,
+		"subscription_id", delivery.SubscriptionID,
+		"event_type", delivery.EventType,
+		"attempt", result.Attempt,
+		"status", result.Status,
+	)
+	if sendErr != nil {
+		logger.Warn("Webhook delivery failed", "error", sendErr)
+	} else {
+		logger.Info("Webhook delivered", "duration_ms", result.Duration.Milliseconds())
+	}
+
+	if err := d.store.CompleteDeliveryAttempt(ctx, result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// send POSTs the signed payload and returns the response status code
+func (d *Dispatcher) send(ctx context.Context, delivery storage.ClaimedDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "my-go-service-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		msg := fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		if s := strings.TrimSpace(string(snippet)); s != "" {
+			msg += ": " + s
+		}
+		return resp.StatusCode, fmt.Errorf("%s", msg)
+	}
+
+	// Drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the wait before retrying after the given attempt:
+// 10s doubling per attempt, capped at one hour, with ±20% jitter
+func Backoff(attempt int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	jitter := time.Duration((rand.Float64()*0.4 - 0.2) * float64(delay))
+	return delay + jitter
+}
@@ -0,0 +1,393 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joel-thompson/my-go-service/storage"
+)
+
+const testSecret = "test-signing-secret"
+
+// memoryStore keeps deliveries and their attempt log the way the
+// webhook_deliveries and webhook_delivery_attempts tables do
+type memoryStore struct {
+	mu         sync.Mutex
+	deliveries map[uuid.UUID]*storage.ClaimedDelivery
+	attempts   []storage.WebhookDeliveryAttempt
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{deliveries: map[uuid.UUID]*storage.ClaimedDelivery{}}
+}
+
+// add queues a pending delivery to url and returns its ID
+func (m *memoryStore) add(url string) uuid.UUID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := uuid.New()
+	m.deliveries[id] = &storage.ClaimedDelivery{
+		WebhookDelivery: storage.WebhookDelivery{
+			ID:             id,
+			SubscriptionID: uuid.New(),
+			EventType:      storage.WebhookEventItemCreated,
+			Payload:        []byte(`{"type":"item.created","data":{"name":"Widget"}}`),
+			Status:         storage.DeliveryPending,
+			NextAttemptAt:  time.Now(),
+		},
+		URL:    url,
+		Secret: testSecret,
+	}
+	return id
+}
+
+// redeliver mirrors redeliverQuery
+func (m *memoryStore) redeliver(id uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.deliveries[id]
+	d.Status = storage.DeliveryPending
+	d.RetryBase = d.Attempts
+	d.NextAttemptAt = time.Now()
+}
+
+func (m *memoryStore) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]storage.ClaimedDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed []storage.ClaimedDelivery
+	for _, d := range m.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status == storage.DeliveryPending && !d.NextAttemptAt.After(time.Now()) {
+			d.NextAttemptAt = time.Now().Add(lease)
+			claimed = append(claimed, *d)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *memoryStore) ClaimDelivery(ctx context.Context, id uuid.UUID, lease time.Duration) (*storage.ClaimedDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[id]
+	if !ok || d.Status != storage.DeliveryPending {
+		return nil, fmt.Errorf("delivery not found")
+	}
+	d.NextAttemptAt = time.Now().Add(lease)
+	claimed := *d
+	return &claimed, nil
+}
+
+func (m *memoryStore) CompleteDeliveryAttempt(ctx context.Context, result storage.DeliveryResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = append(m.attempts, storage.WebhookDeliveryAttempt{
+		ID:          int64(len(m.attempts) + 1),
+		DeliveryID:  result.DeliveryID,
+		Attempt:     result.Attempt,
+		StatusCode:  result.StatusCode,
+		Error:       result.Error,
+		DurationMS:  int(result.Duration.Milliseconds()),
+		AttemptedAt: time.Now(),
+	})
+
+	d := m.deliveries[result.DeliveryID]
+	d.Status = result.Status
+	d.Attempts = result.Attempt
+	d.NextAttemptAt = result.NextAttemptAt
+	d.LastStatusCode = result.StatusCode
+	d.LastError = result.Error
+	if result.Status == storage.DeliverySucceeded {
+		now := time.Now()
+		d.DeliveredAt = &now
+	}
+	return nil
+}
+
+func (m *memoryStore) GetDelivery(ctx context.Context, id uuid.UUID) (*storage.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[id]
+	if !ok {
+		return nil, fmt.Errorf("delivery not found")
+	}
+	delivery := d.WebhookDelivery
+	return &delivery, nil
+}
+
+// log returns the attempt rows recorded for a delivery, in order
+func (m *memoryStore) log(id uuid.UUID) []storage.WebhookDeliveryAttempt {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []storage.WebhookDeliveryAttempt
+	for _, a := range m.attempts {
+		if a.DeliveryID == id {
+			rows = append(rows, a)
+		}
+	}
+	return rows
+}
+
+// receiver is an httptest endpoint that answers deliveries with the next
+// status from statuses (repeating the last one) and keeps every request
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		status := r.statuses[min(len(r.requests), len(r.statuses)-1)]
+		r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+		r.mu.Unlock()
+
+		w.WriteHeader(status)
+		if status >= 300 {
+			fmt.Fprint(w, "receiver unavailable")
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+func newTestDispatcher(store Store, maxAttempts int) *Dispatcher {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewDispatcher(logger, store, Config{
+		MaxAttempts:  maxAttempts,
+		Timeout:      2 * time.Second,
+		PollInterval: 10 * time.Millisecond,
+		Workers:      2,
+	})
+}
+
+func TestDeliverySignature(t *testing.T) {
+	store := newMemoryStore()
+	recv := newReceiver(t, http.StatusNoContent)
+	id := store.add(recv.URL)
+
+	delivery, err := newTestDispatcher(store, 3).Deliver(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != storage.DeliverySucceeded || delivery.DeliveredAt == nil {
+		t.Fatalf("delivery status = %s, delivered_at = %v; want succeeded with a time", delivery.Status, delivery.DeliveredAt)
+	}
+
+	requests := recv.received()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if got := string(req.body); got != `{"type":"item.created","data":{"name":"Widget"}}` {
+		t.Errorf("body = %s", got)
+	}
+	if got := req.header.Get("X-Webhook-Id"); got != id.String() {
+		t.Errorf("X-Webhook-Id = %q, want %q", got, id)
+	}
+	if got := req.header.Get("X-Webhook-Event"); got != storage.WebhookEventItemCreated {
+		t.Errorf("X-Webhook-Event = %q", got)
+	}
+
+	// Recompute the documented format by hand: t=<unix>,v1=hex(HMAC-SHA256(secret, "<t>.<body>"))
+	header := req.header.Get(SignatureHeader)
+	tsPart, sigPart, ok := strings.Cut(header, ",")
+	if !ok || !strings.HasPrefix(tsPart, "t=") || !strings.HasPrefix(sigPart, "v1=") {
+		t.Fatalf("%s = %q, want t=<unix>,v1=<hex>", SignatureHeader, header)
+	}
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(strings.TrimPrefix(tsPart, "t=") + "."))
+	mac.Write(req.body)
+	if want := hex.EncodeToString(mac.Sum(nil)); strings.TrimPrefix(sigPart, "v1=") != want {
+		t.Errorf("signature = %s, want %s", strings.TrimPrefix(sigPart, "v1="), want)
+	}
+
+	if err := Verify(testSecret, header, req.body, 5*time.Minute); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if err := Verify("wrong-secret", header, req.body, 5*time.Minute); err == nil {
+		t.Error("Verify accepted the wrong secret")
+	}
+	if err := Verify(testSecret, header, append(req.body, ' '), 5*time.Minute); err == nil {
+		t.Error("Verify accepted a modified body")
+	}
+}
+
+func TestDeliveryRetriesUntilMaxAttempts(t *testing.T) {
+	const maxAttempts = 3
+	store := newMemoryStore()
+	recv := newReceiver(t, http.StatusServiceUnavailable)
+	id := store.add(recv.URL)
+	dispatcher := newTestDispatcher(store, maxAttempts)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		before := time.Now()
+		delivery, err := dispatcher.Deliver(context.Background(), id)
+		if err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		if delivery.Attempts != attempt {
+			t.Fatalf("attempts = %d, want %d", delivery.Attempts, attempt)
+		}
+
+		if attempt < maxAttempts {
+			if delivery.Status != storage.DeliveryPending {
+				t.Fatalf("attempt %d: status = %s, want pending", attempt, delivery.Status)
+			}
+			// 10s doubling per attempt, with ±20% jitter
+			base := baseRetryDelay << (attempt - 1)
+			wait := delivery.NextAttemptAt.Sub(before)
+			if wait < base*8/10 || wait > base*12/10+time.Second {
+				t.Errorf("attempt %d: next attempt in %s, want about %s", attempt, wait, base)
+			}
+		} else if delivery.Status != storage.DeliveryDead {
+			t.Fatalf("attempt %d: status = %s, want dead", attempt, delivery.Status)
+		}
+	}
+
+	if _, err := dispatcher.Deliver(context.Background(), id); err == nil {
+		t.Error("a dead delivery was sent again")
+	}
+	if got := len(recv.received()); got != maxAttempts {
+		t.Errorf("receiver got %d requests, want %d", got, maxAttempts)
+	}
+
+	rows := store.log(id)
+	if len(rows) != maxAttempts {
+		t.Fatalf("delivery log has %d rows, want %d", len(rows), maxAttempts)
+	}
+	for i, row := range rows {
+		if row.Attempt != i+1 {
+			t.Errorf("row %d: attempt = %d, want %d", i, row.Attempt, i+1)
+		}
+		if row.StatusCode == nil || *row.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("row %d: status code = %v, want 503", i, row.StatusCode)
+		}
+		if row.Error == nil || !strings.Contains(*row.Error, "receiver unavailable") {
+			t.Errorf("row %d: error = %v, want the response body", i, row.Error)
+		}
+	}
+}
+
+func TestRedeliverKeepsAttemptNumbers(t *testing.T) {
+	store := newMemoryStore()
+	recv := newReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError,
+		http.StatusInternalServerError, http.StatusOK)
+	id := store.add(recv.URL)
+	dispatcher := newTestDispatcher(store, 2)
+
+	var delivery *storage.WebhookDelivery
+	var err error
+	for range 2 {
+		if delivery, err = dispatcher.Deliver(context.Background(), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if delivery.Status != storage.DeliveryDead {
+		t.Fatalf("status = %s after using up the budget, want dead", delivery.Status)
+	}
+
+	// The redelivery gets two more attempts, numbered 3 and 4, and its
+	// first retry waits the base delay rather than carrying on the backoff
+	store.redeliver(id)
+	before := time.Now()
+	if delivery, err = dispatcher.Deliver(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != storage.DeliveryPending {
+		t.Fatalf("status = %s after the first attempt of a redelivery, want pending", delivery.Status)
+	}
+	if wait := delivery.NextAttemptAt.Sub(before); wait < baseRetryDelay*8/10 || wait > baseRetryDelay*12/10+time.Second {
+		t.Errorf("retry after a redelivered failure in %s, want about %s", wait, baseRetryDelay)
+	}
+	if delivery, err = dispatcher.Deliver(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != storage.DeliverySucceeded || delivery.Attempts != 4 {
+		t.Fatalf("after redeliver: status = %s, attempts = %d; want succeeded after attempt 4", delivery.Status, delivery.Attempts)
+	}
+
+	var numbers []int
+	for _, row := range store.log(id) {
+		numbers = append(numbers, row.Attempt)
+	}
+	if fmt.Sprint(numbers) != "[1 2 3 4]" {
+		t.Errorf("attempt numbers = %v, want [1 2 3 4]", numbers)
+	}
+}
+
+func TestRunDeliversDueDeliveries(t *testing.T) {
+	store := newMemoryStore()
+	recv := newReceiver(t, http.StatusOK)
+	ids := []uuid.UUID{store.add(recv.URL), store.add(recv.URL), store.add(recv.URL)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		newTestDispatcher(store, 3).Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(recv.received()) < len(ids) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	for _, id := range ids {
+		delivery, _ := store.GetDelivery(context.Background(), id)
+		if delivery.Status != storage.DeliverySucceeded {
+			t.Errorf("delivery %s: status = %s, want succeeded", id, delivery.Status)
+		}
+		if rows := store.log(id); len(rows) != 1 {
+			t.Errorf("delivery %s: %d log rows, want 1", id, len(rows))
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		4:  80 * time.Second,
+		20: time.Hour,
+	} {
+		for range 50 {
+			got := Backoff(attempt)
+			if got < want*8/10 || got > want*12/10 {
+				t.Fatalf("Backoff(%d) = %s, want %s ±20%%", attempt, got, want)
+			}
+		}
+	}
+}